@@ -11,21 +11,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/approval"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/fault"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/handshake"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/localtool"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/progress"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/proxy"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/respfilter"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/screen"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/serverreq"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/shadow"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/transform"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "records" {
+		os.Exit(runRecords(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	listen := flag.String("listen", ":8080", "listen address")
 	upstream := flag.String("upstream", "", "upstream MCP server URL")
 	policyPath := flag.String("policy", "", "policy file (yaml/json)")
 	recordPath := flag.String("record", "", "record file path (NDJSON)")
 	recordMaxBytes := flag.Int64("record-max-bytes", -1, "record rotation size in bytes (0 disables, -1 uses policy)")
 	recordMaxFiles := flag.Int("record-max-files", -1, "record rotation backups to retain (0 keeps none, -1 uses policy/default)")
-	replayPath := flag.String("replay", "", "replay file path (NDJSON)")
+	replayPath := flag.String("replay", "", "replay source: NDJSON file, directory of fixtures, or glob")
+	replayReload := flag.Duration("replay-reload-interval", 2*time.Second, "poll interval for reloading changed replay fixtures (0 disables)")
 	replayStrict := flag.Bool("replay-strict", false, "error on replay miss")
+	shadowReplay := flag.String("shadow-replay", "", "shadow mode: compare live upstream responses against this replay source")
+	shadowCandidate := flag.String("shadow-candidate", "", "shadow mode: mirror requests to this candidate upstream URL and compare responses")
+	shadowReport := flag.String("shadow-report", "", "shadow mode: append diverging comparisons to this NDJSON report")
 	prometheusMetrics := flag.Bool("prometheus-metrics", false, "enable Prometheus text exposition at GET /metrics")
 	maxBody := flag.Int64("max-body", 1<<20, "max request/response body in bytes")
 	timeout := flag.Duration("timeout", 10*time.Second, "upstream request timeout")
@@ -55,10 +74,32 @@ func main() {
 	recordPolicy := config.RecordPolicy{}
 	replayPolicy := config.ReplayPolicy{}
 	httpPolicy := config.HTTPPolicy{}
+	faultPolicy := config.FaultPolicy{}
+	shadowPolicy := config.ShadowPolicy{}
+	filterPolicy := config.ResponseFilterPolicy{}
+	screeningPolicy := config.ScreeningPolicy{}
+	approvalPolicy := config.ApprovalPolicy{}
+	initPolicy := config.InitializePolicy{}
+	serverRequestPolicy := config.ServerRequestPolicy{}
+	progressPolicy := config.ProgressPolicy{}
+	var toolPolicies map[string]config.ToolEntry
+	var aliasPolicies map[string]config.ToolAlias
+	var localToolPolicies map[string]config.LocalTool
 	if policy != nil {
 		recordPolicy = policy.Record
 		replayPolicy = policy.Replay
 		httpPolicy = policy.HTTP
+		faultPolicy = policy.Faults
+		shadowPolicy = policy.Shadow
+		filterPolicy = policy.ResponseFilter
+		screeningPolicy = policy.Screening
+		approvalPolicy = policy.Approval
+		initPolicy = policy.Initialize
+		serverRequestPolicy = policy.ServerRequests
+		progressPolicy = policy.Progress
+		toolPolicies = policy.Tools
+		aliasPolicies = policy.Aliases
+		localToolPolicies = policy.LocalTools
 	}
 
 	// Enablement precedence: CLI flag enables regardless of policy; otherwise defer to policy.
@@ -94,7 +135,7 @@ func main() {
 		rotateFiles = 0
 	}
 
-	redactor, err := record.NewRedactor(recordPolicy.RedactKeys, recordPolicy.RedactKeyRegex)
+	redactor, err := record.NewRedactorFromPolicy(recordPolicy)
 	if err != nil {
 		logger.Fatalf("failed to init record redactor: %v", err)
 	}
@@ -103,8 +144,83 @@ func main() {
 	if err != nil {
 		logger.Fatalf("failed to load replay file: %v", err)
 	}
+	if replay != nil {
+		st := replay.Stats()
+		logger.Printf("replay loaded: files=%d entries=%d conflicts=%d", st.Files, st.Entries, st.Conflicts)
+	}
+
+	faults, err := fault.New(faultPolicy)
+	if err != nil {
+		logger.Fatalf("failed to init fault injection: %v", err)
+	}
+
+	respFilter, err := respfilter.New(filterPolicy)
+	if err != nil {
+		logger.Fatalf("failed to init response filter: %v", err)
+	}
+
+	screener, err := screen.New(screeningPolicy)
+	if err != nil {
+		logger.Fatalf("failed to init content screening: %v", err)
+	}
+
+	transformer, err := transform.New(toolPolicies)
+	if err != nil {
+		logger.Fatalf("failed to init argument transforms: %v", err)
+	}
+
+	aliases, err := alias.New(aliasPolicies)
+	if err != nil {
+		logger.Fatalf("failed to init tool aliases: %v", err)
+	}
+
+	localTools, err := localtool.New(localToolPolicies)
+	if err != nil {
+		logger.Fatalf("failed to init local tools: %v", err)
+	}
+
+	approvals, err := approval.New(approvalPolicy, logger)
+	if err != nil {
+		logger.Fatalf("failed to init approvals: %v", err)
+	}
+
+	shadowOpts := shadow.Options{
+		Timeout:     *timeout,
+		MaxInflight: shadowPolicy.MaxInflight,
+		Ignore:      shadowPolicy.IgnorePaths,
+		ReportPath:  *shadowReport,
+	}
+	if *shadowReplay != "" {
+		shadowOpts.Replay, err = record.LoadReplay(*shadowReplay, record.ReplayMatch(replayPolicy.Match))
+		if err != nil {
+			logger.Fatalf("failed to load shadow replay source: %v", err)
+		}
+	}
+	if *shadowCandidate != "" {
+		shadowOpts.Candidate, err = url.Parse(*shadowCandidate)
+		if err != nil {
+			logger.Fatalf("invalid shadow candidate URL: %v", err)
+		}
+	}
+	shadowCmp, err := shadow.New(shadowOpts)
+	if err != nil {
+		logger.Fatalf("failed to init shadow mode: %v", err)
+	}
+	defer shadowCmp.Close()
 
 	srv := proxy.NewServer(upstreamURL, validator, recorder, replay, *replayStrict, httpPolicy.OriginAllowlist, httpPolicy.ForwardHeaders, enablePromMetrics, *maxBody, *timeout, logger)
+	srv.SetFaultInjector(faults)
+	srv.SetReplayStreamTiming(replayPolicy.StreamTiming)
+	srv.SetShadow(shadowCmp)
+	srv.SetResponseFilter(respFilter)
+	srv.SetScreener(screener)
+	srv.SetApprovals(approvals)
+	srv.SetTransformer(transformer)
+	srv.SetAliases(aliases)
+	srv.SetLocalTools(localTools)
+	srv.SetHandshake(handshake.New(initPolicy))
+	srv.SetServerRequests(serverreq.New(serverRequestPolicy))
+	srv.SetProgress(progress.New(progressPolicy))
 
 	httpServer := &http.Server{
 		Addr:              *listen,
@@ -115,12 +231,36 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	if replay != nil && *replayReload > 0 {
+		go replay.Watch(ctx, *replayReload, logger.Printf)
+	}
+	if shadowOpts.Replay != nil && *replayReload > 0 {
+		go shadowOpts.Replay.Watch(ctx, *replayReload, logger.Printf)
+	}
+
 	logger.Printf("listening on %s", *listen)
 	if enablePromMetrics {
 		logger.Printf("endpoints: POST /rpc (/mcp alias), GET /healthz, GET /metricsz, GET /metrics")
 	} else {
 		logger.Printf("endpoints: POST /rpc (/mcp alias), GET /healthz, GET /metricsz")
 	}
+	if faults != nil {
+		logger.Printf("fault injection enabled (%d rules)", len(faultPolicy.Rules))
+	}
+	if respFilter != nil {
+		logger.Printf("response filter enabled (mode=%s)", filterPolicy.Mode)
+	}
+	if screener != nil {
+		logger.Printf("content screening enabled (action=%s)", screeningPolicy.Action)
+	}
+	if approvals != nil {
+		logger.Printf("approvals enabled (%d rules), admin API at /admin/approvals", len(approvalPolicy.Rules))
+	}
+	if shadowOpts.Replay != nil {
+		logger.Printf("shadow mode comparing against recordings %s", *shadowReplay)
+	} else if shadowOpts.Candidate != nil {
+		logger.Printf("shadow mode mirroring to candidate %s", shadowOpts.Candidate.String())
+	}
 	if upstreamURL != nil {
 		logger.Printf("upstream %s", upstreamURL.String())
 	} else {
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+const recordsUsage = `usage: mcp-proxy-gateway records <command> [flags] <recordings>...
+
+commands:
+  verify   check recordings for invalid, stale, duplicate, or unredacted entries
+  diff     compare two recordings by signature
+  merge    merge recordings with a conflict policy
+  dedupe   drop entries whose signature was already recorded
+  filter   keep entries matching a method, tool, or time range
+  redact   apply the policy's redaction rules to existing recordings in place
+           (only the named files; use a glob like 'records.ndjson*' to
+           include rotated backups; stop the gateway recording to them first)
+`
+
+// runRecords implements the offline "records" subcommands and returns the
+// process exit code: 0 on success, 1 when verify finds problems or diff finds
+// differences, 2 on usage or I/O errors.
+func runRecords(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, recordsUsage)
+		return 2
+	}
+	switch args[0] {
+	case "verify":
+		return runRecordsVerify(args[1:], stdout, stderr)
+	case "diff":
+		return runRecordsDiff(args[1:], stdout, stderr)
+	case "merge":
+		return runRecordsMerge(args[1:], stdout, stderr)
+	case "dedupe":
+		return runRecordsDedupe(args[1:], stdout, stderr)
+	case "filter":
+		return runRecordsFilter(args[1:], stdout, stderr)
+	case "redact":
+		return runRecordsRedact(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, recordsUsage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown records command %q\n\n%s", args[0], recordsUsage)
+		return 2
+	}
+}
+
+func runRecordsVerify(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	policyPath := fs.String("policy", "", "policy file (yaml/json) providing redaction keys and tool schemas")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(stderr, "records verify: at least one recording file, directory, or glob is required")
+		return 2
+	}
+
+	opts, err := verifyOptionsFromPolicy(*policyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "records verify: %v\n", err)
+		return 2
+	}
+	report, err := record.Verify(fs.Args(), opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "records verify: %v\n", err)
+		return 2
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		for _, p := range report.Problems {
+			fmt.Fprintln(stdout, p.String())
+		}
+		fmt.Fprintf(stdout, "verified %d entries in %d files: %d problems\n", report.Entries, report.Files, len(report.Problems))
+	}
+	if len(report.Problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func verifyOptionsFromPolicy(path string) (record.VerifyOptions, error) {
+	opts := record.VerifyOptions{}
+	policy, err := config.LoadPolicy(path)
+	if err != nil || policy == nil {
+		return opts, err
+	}
+	opts.Redactor, err = record.NewRedactorFromPolicy(policy.Record)
+	if err != nil {
+		return opts, err
+	}
+	// Audit-mode policies still report violations, so schema drift is caught
+	// either way.
+	validator, err := validate.New(policy)
+	if err != nil {
+		return opts, err
+	}
+	opts.ValidateArgs = func(tool string, args json.RawMessage) ([]string, error) {
+		decision, err := validator.ValidateToolCall(tool, args)
+		return decision.Violations, err
+	}
+	return opts, nil
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func runRecordsDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var ignore stringList
+	fs.Var(&ignore, "ignore", "response JSON path to ignore, e.g. $.result.meta.timestamp (repeatable)")
+	asJSON := fs.Bool("json", false, "print differences as NDJSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(stderr, "records diff: expected <old> <new>")
+		return 2
+	}
+	patterns := make([]jsondiff.PathPattern, 0, len(ignore))
+	for _, raw := range ignore {
+		p, err := jsondiff.ParsePath(raw)
+		if err != nil {
+			fmt.Fprintf(stderr, "records diff: %v\n", err)
+			return 2
+		}
+		patterns = append(patterns, p)
+	}
+	base, err := record.ReadEntries(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "records diff: %v\n", err)
+		return 2
+	}
+	next, err := record.ReadEntries(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "records diff: %v\n", err)
+		return 2
+	}
+	diffs, err := record.DiffEntries(base, next, patterns)
+	if err != nil {
+		fmt.Fprintf(stderr, "records diff: %v\n", err)
+		return 2
+	}
+
+	counts := map[string]int{}
+	for _, d := range diffs {
+		counts[d.Status]++
+		if *asJSON {
+			line, _ := json.Marshal(d)
+			fmt.Fprintln(stdout, string(line))
+			continue
+		}
+		fmt.Fprintf(stdout, "%s %s%s\n", diffStatusMarker(d.Status), d.Signature, describeDiffEntry(d))
+		for _, diff := range d.Differences {
+			baseline, _ := json.Marshal(diff.Baseline)
+			live, _ := json.Marshal(diff.Live)
+			fmt.Fprintf(stdout, "    %s %s: %s -> %s\n", diff.Kind, diff.Path, baseline, live)
+		}
+	}
+	if !*asJSON {
+		fmt.Fprintf(stdout, "%d added, %d removed, %d changed\n", counts[record.DiffAdded], counts[record.DiffRemoved], counts[record.DiffChanged])
+	}
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func diffStatusMarker(status string) string {
+	switch status {
+	case record.DiffAdded:
+		return "+"
+	case record.DiffRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func describeDiffEntry(d record.EntryDiff) string {
+	var parts []string
+	if d.Method != "" {
+		parts = append(parts, d.Method)
+	}
+	if d.Tool != "" {
+		parts = append(parts, "tool="+d.Tool)
+	}
+	if d.Scenario != "" {
+		parts = append(parts, "scenario="+d.Scenario)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, " ") + ")"
+}
+
+func runRecordsMerge(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records merge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	conflict := fs.String("conflict", string(record.MergeKeepOld), "conflict policy: keep-old, keep-new, or keep-both-as-sequence")
+	dedupe := fs.Bool("dedupe", false, "drop duplicate signatures from the result")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 2 {
+		fmt.Fprintln(stderr, "records merge: expected <base> <other>...")
+		return 2
+	}
+	policy, err := record.ParseMergeConflict(*conflict)
+	if err != nil {
+		fmt.Fprintf(stderr, "records merge: %v\n", err)
+		return 2
+	}
+	merged, err := record.ReadEntries(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "records merge: %v\n", err)
+		return 2
+	}
+	for _, source := range fs.Args()[1:] {
+		next, err := record.ReadEntries(source)
+		if err != nil {
+			fmt.Fprintf(stderr, "records merge: %v\n", err)
+			return 2
+		}
+		var stats record.MergeStats
+		merged, stats = record.MergeEntries(merged, next, policy)
+		fmt.Fprintf(stderr, "merged %s: %d added, %d identical, %d conflicts (%d replaced, %d sequenced)\n",
+			source, stats.Added, stats.Identical, stats.Conflicts, stats.Replaced, stats.Sequenced)
+	}
+	if *dedupe {
+		var removed, conflicts int
+		merged, removed, conflicts = record.DedupeEntries(merged)
+		fmt.Fprintf(stderr, "deduped: %d removed (%d conflicting)\n", removed, conflicts)
+	}
+	return writeRecordsOutput(*out, merged, stdout, stderr)
+}
+
+func runRecordsDedupe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records dedupe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "output file (default stdout; may be the input file)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "records dedupe: expected <recordings>")
+		return 2
+	}
+	entries, err := record.ReadEntries(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "records dedupe: %v\n", err)
+		return 2
+	}
+	entries, removed, conflicts := record.DedupeEntries(entries)
+	fmt.Fprintf(stderr, "deduped: %d removed (%d conflicting), %d kept\n", removed, conflicts, len(entries))
+	return writeRecordsOutput(*out, entries, stdout, stderr)
+}
+
+func runRecordsFilter(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records filter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var methods, tools stringList
+	fs.Var(&methods, "method", "keep entries with this JSON-RPC method (repeatable)")
+	fs.Var(&tools, "tool", "keep tools/call entries for this tool (repeatable)")
+	since := fs.String("since", "", "keep entries recorded at or after this RFC 3339 time")
+	until := fs.String("until", "", "keep entries recorded at or before this RFC 3339 time")
+	out := fs.String("o", "", "output file (default stdout; may be the input file)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "records filter: expected <recordings>")
+		return 2
+	}
+	filter := record.EntryFilter{Methods: methods, Tools: tools}
+	for _, bound := range []struct {
+		raw string
+		dst *time.Time
+	}{{*since, &filter.Since}, {*until, &filter.Until}} {
+		if bound.raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			fmt.Fprintf(stderr, "records filter: invalid time %q: %v\n", bound.raw, err)
+			return 2
+		}
+		*bound.dst = t
+	}
+	entries, err := record.ReadEntries(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "records filter: %v\n", err)
+		return 2
+	}
+	kept := record.FilterEntries(entries, filter)
+	fmt.Fprintf(stderr, "filtered: %d of %d entries kept\n", len(kept), len(entries))
+	return writeRecordsOutput(*out, kept, stdout, stderr)
+}
+
+func writeRecordsOutput(path string, entries []record.Entry, stdout, stderr io.Writer) int {
+	var err error
+	if path == "" {
+		err = record.WriteEntries(stdout, entries)
+	} else {
+		err = record.WriteEntriesFile(path, entries)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "write output: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+func runRecordsRedact(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("records redact", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	policyPath := fs.String("policy", "", "policy file (yaml/json) providing the record.redact_* rules")
+	dryRun := fs.Bool("dry-run", false, "report what would be redacted without modifying files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *policyPath == "" || fs.NArg() == 0 {
+		fmt.Fprintln(stderr, "records redact: --policy and at least one recording file or glob are required")
+		return 2
+	}
+	policy, err := config.LoadPolicy(*policyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "records redact: %v\n", err)
+		return 2
+	}
+	redactor, err := record.NewRedactorFromPolicy(policy.Record)
+	if err != nil {
+		fmt.Fprintf(stderr, "records redact: %v\n", err)
+		return 2
+	}
+	if redactor == nil {
+		fmt.Fprintln(stderr, "records redact: policy has no record redaction rules")
+		return 2
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			fmt.Fprintf(stderr, "records redact: %v\n", err)
+			return 2
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(stderr, "records redact: no files match %q\n", arg)
+			return 2
+		}
+		files = append(files, matches...)
+	}
+
+	verb := "redacted"
+	if *dryRun {
+		verb = "would redact"
+	}
+	total := map[string]int{}
+	for _, f := range files {
+		stats, err := record.RedactFile(f, redactor, *dryRun)
+		if err != nil {
+			fmt.Fprintf(stderr, "records redact: %v\n", err)
+			return 2
+		}
+		fmt.Fprintf(stdout, "%s: %s %d values in %d of %d entries\n", f, verb, stats.Redacted(), stats.EntriesChanged, stats.Entries)
+		for k, n := range stats.Keys {
+			total[k] += n
+		}
+	}
+	keys := make([]string, 0, len(total))
+	for k := range total {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(stdout, "  %s: %d\n", k, total[k])
+	}
+	return 0
+}
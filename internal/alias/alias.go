@@ -0,0 +1,235 @@
+package alias
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+type toolAlias struct {
+	name        string
+	tool        string
+	description string
+	inputSchema map[string]any
+	hide        []string
+	fixed       map[string]json.RawMessage
+}
+
+// Aliases rewrites tools/call requests for client-visible alias names and
+// tools/list results so clients only see the curated tool surface.
+type Aliases struct {
+	byName map[string]*toolAlias
+	// byTool lists the aliases of each upstream tool in name order.
+	byTool map[string][]*toolAlias
+	// hidden upstream tools are only listed through their aliases.
+	hidden map[string]bool
+}
+
+// New builds the alias table. It returns nil when no aliases are configured.
+func New(policy map[string]config.ToolAlias) (*Aliases, error) {
+	if len(policy) == 0 {
+		return nil, nil
+	}
+	a := &Aliases{byName: map[string]*toolAlias{}, byTool: map[string][]*toolAlias{}, hidden: map[string]bool{}}
+	names := make([]string, 0, len(policy))
+	for name := range policy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	keep := map[string]bool{}
+	for _, name := range names {
+		cfg := policy[name]
+		ta := &toolAlias{name: name, tool: cfg.Tool, description: cfg.Description, inputSchema: cfg.InputSchema, hide: cfg.HideParams}
+		if len(cfg.Arguments) > 0 {
+			ta.fixed = map[string]json.RawMessage{}
+			for k, v := range cfg.Arguments {
+				data, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				ta.fixed[k] = data
+			}
+		}
+		a.byName[name] = ta
+		a.byTool[cfg.Tool] = append(a.byTool[cfg.Tool], ta)
+		keep[cfg.Tool] = keep[cfg.Tool] || cfg.KeepUpstream
+	}
+	for tool := range a.byTool {
+		a.hidden[tool] = !keep[tool]
+	}
+	return a, nil
+}
+
+// Upstream returns the upstream tool for a client-visible name and whether
+// the name is an alias.
+func (a *Aliases) Upstream(name string) (string, bool) {
+	if a == nil {
+		return name, false
+	}
+	ta, ok := a.byName[name]
+	if !ok {
+		return name, false
+	}
+	return ta.tool, true
+}
+
+// Hidden reports whether name is an upstream tool that is only reachable
+// through its aliases: none of them sets keep_upstream and no alias has the
+// same name.
+func (a *Aliases) Hidden(name string) bool {
+	return a != nil && a.hidden[name] && a.byName[name] == nil
+}
+
+// RewriteCall rewrites tools/call params for an alias: the tool name becomes
+// the upstream tool, hidden parameters are dropped, and fixed arguments are
+// merged in. Params for other tools are returned unchanged with ok false.
+func (a *Aliases) RewriteCall(params json.RawMessage) (json.RawMessage, bool, error) {
+	if a == nil || len(params) == 0 {
+		return params, false, nil
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil, false, err
+	}
+	var name string
+	if err := json.Unmarshal(fields["tool"], &name); err != nil {
+		return params, false, nil
+	}
+	ta, ok := a.byName[name]
+	if !ok {
+		return params, false, nil
+	}
+	fields["tool"], _ = json.Marshal(ta.tool)
+
+	if len(ta.hide) > 0 || len(ta.fixed) > 0 {
+		args := map[string]json.RawMessage{}
+		if raw := fields["arguments"]; len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, false, err
+			}
+		}
+		for _, p := range ta.hide {
+			delete(args, p)
+		}
+		for k, v := range ta.fixed {
+			args[k] = v
+		}
+		rawArgs, err := json.Marshal(args)
+		if err != nil {
+			return nil, false, err
+		}
+		fields["arguments"] = rawArgs
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	return json.RawMessage(out), true, nil
+}
+
+// RewriteList rewrites the result of a tools/list response: upstream tools
+// with aliases are replaced by (or, with keep_upstream, followed by) their
+// alias entries. Responses without a result.tools array are returned
+// unchanged.
+func (a *Aliases) RewriteList(raw json.RawMessage) json.RawMessage {
+	if a == nil {
+		return raw
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw
+	}
+	result := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg["result"], &result); err != nil {
+		return raw
+	}
+	var tools []map[string]any
+	if err := json.Unmarshal(result["tools"], &tools); err != nil {
+		return raw
+	}
+
+	out := make([]map[string]any, 0, len(tools))
+	changed := false
+	for _, t := range tools {
+		name, _ := t["name"].(string)
+		aliases := a.byTool[name]
+		if len(aliases) == 0 {
+			out = append(out, t)
+			continue
+		}
+		changed = true
+		if !a.hidden[name] {
+			out = append(out, t)
+		}
+		for _, ta := range aliases {
+			out = append(out, ta.entry(t))
+		}
+	}
+	if !changed {
+		return raw
+	}
+	var err error
+	if result["tools"], err = json.Marshal(out); err != nil {
+		return raw
+	}
+	if msg["result"], err = json.Marshal(result); err != nil {
+		return raw
+	}
+	rewritten, err := json.Marshal(msg)
+	if err != nil {
+		return raw
+	}
+	return json.RawMessage(rewritten)
+}
+
+// entry derives the tools/list entry of the alias from its upstream entry.
+func (ta *toolAlias) entry(upstream map[string]any) map[string]any {
+	e := deepCopy(upstream).(map[string]any)
+	e["name"] = ta.name
+	if ta.description != "" {
+		e["description"] = ta.description
+	}
+	if ta.inputSchema != nil {
+		e["inputSchema"] = deepCopy(ta.inputSchema)
+		return e
+	}
+	schema, ok := e["inputSchema"].(map[string]any)
+	if !ok {
+		return e
+	}
+	drop := map[string]bool{}
+	for _, p := range ta.hide {
+		drop[p] = true
+	}
+	for k := range ta.fixed {
+		drop[k] = true
+	}
+	if props, ok := schema["properties"].(map[string]any); ok {
+		for p := range drop {
+			delete(props, p)
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		kept := make([]any, 0, len(required))
+		for _, r := range required {
+			if s, _ := r.(string); !drop[s] {
+				kept = append(kept, r)
+			}
+		}
+		schema["required"] = kept
+	}
+	return e
+}
+
+func deepCopy(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
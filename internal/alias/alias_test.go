@@ -0,0 +1,91 @@
+package alias
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func newTestAliases(t *testing.T) *Aliases {
+	t.Helper()
+	a, err := New(map[string]config.ToolAlias{
+		"fs.read": {Tool: "filesystem_read_file", Description: "Read a file", HideParams: []string{"encoding"}},
+		"readme":  {Tool: "filesystem_read_file", Arguments: map[string]any{"path": "/repo/README.md"}},
+		"search":  {Tool: "web_search", KeepUpstream: true, InputSchema: map[string]any{"type": "object"}},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	return a
+}
+
+func TestRewriteCall(t *testing.T) {
+	a := newTestAliases(t)
+
+	out, ok, err := a.RewriteCall(json.RawMessage(`{"tool":"readme","arguments":{"path":"/etc/passwd","encoding":"utf8"}}`))
+	if err != nil || !ok {
+		t.Fatalf("rewrite: ok=%v err=%v", ok, err)
+	}
+	var got map[string]any
+	_ = json.Unmarshal(out, &got)
+	want := map[string]any{"tool": "filesystem_read_file", "arguments": map[string]any{"path": "/repo/README.md", "encoding": "utf8"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	out, _, _ = a.RewriteCall(json.RawMessage(`{"tool":"fs.read","arguments":{"path":"a","encoding":"latin1"}}`))
+	if string(out) != `{"arguments":{"path":"a"},"tool":"filesystem_read_file"}` {
+		t.Fatalf("unexpected rewrite: %s", out)
+	}
+
+	raw := json.RawMessage(`{"tool":"other","arguments":{}}`)
+	if out, ok, _ := a.RewriteCall(raw); ok || string(out) != string(raw) {
+		t.Fatalf("non-alias call changed: %s", out)
+	}
+}
+
+func TestRewriteList(t *testing.T) {
+	a := newTestAliases(t)
+	raw := json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"nextCursor":"c1","tools":[
+		{"name":"filesystem_read_file","description":"upstream","inputSchema":{"type":"object","properties":{"path":{"type":"string"},"encoding":{"type":"string"}},"required":["path"]}},
+		{"name":"web_search","inputSchema":{"type":"object","properties":{"q":{"type":"string"}}}},
+		{"name":"clock"}]}}`)
+
+	var resp struct {
+		Result struct {
+			NextCursor string           `json:"nextCursor"`
+			Tools      []map[string]any `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(a.RewriteList(raw), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	var names []string
+	for _, tool := range resp.Result.Tools {
+		names = append(names, tool["name"].(string))
+	}
+	if want := []string{"fs.read", "readme", "web_search", "search", "clock"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("names=%v want %v", names, want)
+	}
+	if resp.Result.NextCursor != "c1" {
+		t.Fatalf("cursor lost: %+v", resp.Result)
+	}
+
+	fsRead := resp.Result.Tools[0]
+	if fsRead["description"] != "Read a file" {
+		t.Fatalf("description not overridden: %v", fsRead)
+	}
+	props := fsRead["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["encoding"]; ok {
+		t.Fatalf("hidden param still listed: %v", props)
+	}
+	readme := resp.Result.Tools[1]["inputSchema"].(map[string]any)
+	if len(readme["properties"].(map[string]any)) != 1 || len(readme["required"].([]any)) != 0 {
+		t.Fatalf("fixed argument still listed: %v", readme)
+	}
+	if !reflect.DeepEqual(resp.Result.Tools[3]["inputSchema"], map[string]any{"type": "object"}) {
+		t.Fatalf("input schema not overridden: %v", resp.Result.Tools[3])
+	}
+}
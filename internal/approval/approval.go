@@ -0,0 +1,411 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+const (
+	ActionRequire = "require_approval"
+	ActionAllow   = "allow"
+)
+
+// DefaultTimeout applies when approval.timeout is unset.
+const DefaultTimeout = 5 * time.Minute
+
+// ErrNotFound is returned when resolving an id that is not pending, either
+// because it never existed or because it was already decided or expired.
+var ErrNotFound = errors.New("approval request not found")
+
+// Pending is a queued tools/call as listed by the admin API and sent to the
+// webhook.
+type Pending struct {
+	ID        string          `json:"id"`
+	Rule      string          `json:"rule"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// Stats are cumulative approval counters plus the current queue length.
+type Stats struct {
+	Pending       int    `json:"pending"`
+	ApprovedTotal uint64 `json:"approved_total"`
+	DeniedTotal   uint64 `json:"denied_total"`
+	TimedOutTotal uint64 `json:"timed_out_total"`
+	CanceledTotal uint64 `json:"canceled_total"`
+}
+
+type argMatcher struct {
+	segs []string
+	re   *regexp.Regexp
+}
+
+type rule struct {
+	name    string
+	tools   map[string]struct{}
+	args    []argMatcher
+	action  string
+	timeout time.Duration
+}
+
+type decision struct {
+	approve bool
+	reason  string
+}
+
+type entry struct {
+	info     Pending
+	decision chan decision
+}
+
+// Queue parks tools/call requests matched by an approval rule until an
+// operator resolves them or they time out.
+type Queue struct {
+	rules      []rule
+	adminToken string
+	webhook    string
+	client     *http.Client
+	logger     *log.Logger
+
+	mu      sync.Mutex
+	pending map[string]*entry
+
+	approved atomic.Uint64
+	denied   atomic.Uint64
+	timedOut atomic.Uint64
+	canceled atomic.Uint64
+}
+
+// New builds a queue from policy. It returns nil when no rules are
+// configured.
+func New(policy config.ApprovalPolicy, logger *log.Logger) (*Queue, error) {
+	if len(policy.Rules) == 0 {
+		return nil, nil
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	timeout := DefaultTimeout
+	if policy.Timeout != "" {
+		d, err := time.ParseDuration(policy.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("approval timeout: %w", err)
+		}
+		timeout = d
+	}
+	q := &Queue{
+		webhook: policy.WebhookURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		logger:  logger,
+		pending: map[string]*entry{},
+	}
+	if policy.AdminTokenEnv == "" {
+		return nil, errors.New("approval admin token env is required")
+	}
+	q.adminToken = os.Getenv(policy.AdminTokenEnv)
+	if q.adminToken == "" {
+		return nil, fmt.Errorf("approval admin token env %s is empty", policy.AdminTokenEnv)
+	}
+	for i, cfg := range policy.Rules {
+		r := rule{name: cfg.Name, action: cfg.Action, timeout: timeout}
+		if r.name == "" {
+			r.name = fmt.Sprintf("rule-%d", i)
+		}
+		if r.action == "" {
+			r.action = ActionRequire
+		}
+		if cfg.Timeout != "" {
+			d, err := time.ParseDuration(cfg.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("approval rule %s: %w", r.name, err)
+			}
+			r.timeout = d
+		}
+		if len(cfg.Tools) > 0 {
+			r.tools = map[string]struct{}{}
+			for _, t := range cfg.Tools {
+				r.tools[t] = struct{}{}
+			}
+		}
+		paths := make([]string, 0, len(cfg.Arguments))
+		for path := range cfg.Arguments {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			p, err := jsondiff.ParsePath(path)
+			if err != nil {
+				return nil, fmt.Errorf("approval rule %s: %w", r.name, err)
+			}
+			re, err := regexp.Compile(cfg.Arguments[path])
+			if err != nil {
+				return nil, fmt.Errorf("approval rule %s: invalid regex for %s: %w", r.name, path, err)
+			}
+			r.args = append(r.args, argMatcher{segs: p.Segments(), re: re})
+		}
+		q.rules = append(q.rules, r)
+	}
+	return q, nil
+}
+
+// match returns the first rule matching a call, or nil.
+func (q *Queue) match(tool string, args json.RawMessage) *rule {
+	var doc any
+	for i := range q.rules {
+		r := &q.rules[i]
+		if r.tools != nil {
+			if _, ok := r.tools[tool]; !ok {
+				continue
+			}
+		}
+		if len(r.args) > 0 && doc == nil {
+			var v any
+			if len(args) > 0 {
+				_ = json.Unmarshal(args, &v)
+			}
+			doc = map[string]any{"params": map[string]any{"arguments": v}}
+		}
+		if r.matchesArgs(doc) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (r *rule) matchesArgs(doc any) bool {
+	for _, m := range r.args {
+		found := false
+		for _, v := range lookup(doc, m.segs) {
+			s, ok := v.(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				s = string(data)
+			}
+			if m.re.MatchString(s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns every value at segs, where "*" matches any object key or
+// array index.
+func lookup(v any, segs []string) []any {
+	if len(segs) == 0 {
+		return []any{v}
+	}
+	seg, rest := segs[0], segs[1:]
+	var out []any
+	switch vv := v.(type) {
+	case map[string]any:
+		if seg == "*" {
+			for _, child := range vv {
+				out = append(out, lookup(child, rest)...)
+			}
+		} else if child, ok := vv[seg]; ok {
+			out = lookup(child, rest)
+		}
+	case []any:
+		if seg == "*" {
+			for _, child := range vv {
+				out = append(out, lookup(child, rest)...)
+			}
+		} else if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(vv) {
+			out = lookup(vv[i], rest)
+		}
+	}
+	return out
+}
+
+// Await blocks a call matched by a require_approval rule until it is
+// approved, denied, times out, or ctx is done, and returns the decision for
+// the audit trail. It returns nil when the call needs no approval.
+func (q *Queue) Await(ctx context.Context, tool string, args json.RawMessage) *record.Approval {
+	if q == nil {
+		return nil
+	}
+	r := q.match(tool, args)
+	if r == nil || r.action != ActionRequire {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	e := &entry{
+		info: Pending{
+			ID:        newID(),
+			Rule:      r.name,
+			Tool:      tool,
+			Arguments: args,
+			CreatedAt: now,
+			ExpiresAt: now.Add(r.timeout),
+		},
+		decision: make(chan decision, 1),
+	}
+	q.mu.Lock()
+	q.pending[e.info.ID] = e
+	q.mu.Unlock()
+	q.logger.Printf("approval pending: id=%s rule=%s tool=%s expires=%s", e.info.ID, r.name, tool, e.info.ExpiresAt.Format(time.RFC3339))
+	q.notify("approval.pending", e.info, nil)
+
+	res := &record.Approval{ID: e.info.ID, Rule: r.name}
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+	select {
+	case d := <-e.decision:
+		res.Outcome, res.Reason = outcome(d)
+	case <-timer.C:
+		if q.remove(e.info.ID) {
+			res.Outcome, res.Reason = record.ApprovalTimedOut, "no decision within "+r.timeout.String()
+		} else {
+			res.Outcome, res.Reason = outcome(<-e.decision)
+		}
+	case <-ctx.Done():
+		if q.remove(e.info.ID) {
+			res.Outcome, res.Reason = record.ApprovalCanceled, "client went away"
+		} else {
+			res.Outcome, res.Reason = outcome(<-e.decision)
+		}
+	}
+	res.WaitMs = time.Since(now).Milliseconds()
+
+	switch res.Outcome {
+	case record.ApprovalApproved:
+		q.approved.Add(1)
+	case record.ApprovalDenied:
+		q.denied.Add(1)
+	case record.ApprovalTimedOut:
+		q.timedOut.Add(1)
+	case record.ApprovalCanceled:
+		q.canceled.Add(1)
+	}
+	q.logger.Printf("approval %s: id=%s rule=%s tool=%s wait_ms=%d reason=%q", res.Outcome, res.ID, res.Rule, tool, res.WaitMs, res.Reason)
+	q.notify("approval.resolved", e.info, res)
+	return res
+}
+
+func outcome(d decision) (string, string) {
+	if d.approve {
+		return record.ApprovalApproved, d.reason
+	}
+	return record.ApprovalDenied, d.reason
+}
+
+// Resolve approves or denies a pending call.
+func (q *Queue) Resolve(id string, approve bool, reason string) error {
+	q.mu.Lock()
+	e, ok := q.pending[id]
+	delete(q.pending, id)
+	q.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	e.decision <- decision{approve: approve, reason: reason}
+	return nil
+}
+
+func (q *Queue) remove(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.pending[id]
+	delete(q.pending, id)
+	return ok
+}
+
+// Pending lists queued calls, oldest first.
+func (q *Queue) Pending() []Pending {
+	q.mu.Lock()
+	out := make([]Pending, 0, len(q.pending))
+	for _, e := range q.pending {
+		out = append(out, e.info)
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Authorized reports whether an Authorization header grants access to the
+// admin API. Without a configured token no request is authorized.
+func (q *Queue) Authorized(header string) bool {
+	if q.adminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(q.adminToken)) == 1
+}
+
+// notify posts an event to the webhook in the background.
+func (q *Queue) notify(event string, info Pending, res *record.Approval) {
+	if q.webhook == "" {
+		return
+	}
+	payload, err := json.Marshal(struct {
+		Event    string           `json:"event"`
+		Request  Pending          `json:"request"`
+		Decision *record.Approval `json:"decision,omitempty"`
+	}{event, info, res})
+	if err != nil {
+		return
+	}
+	go func() {
+		resp, err := q.client.Post(q.webhook, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			q.logger.Printf("approval webhook failed: event=%s id=%s: %v", event, info.ID, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			q.logger.Printf("approval webhook failed: event=%s id=%s: status %d", event, info.ID, resp.StatusCode)
+		}
+	}()
+}
+
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	n := len(q.pending)
+	q.mu.Unlock()
+	return Stats{
+		Pending:       n,
+		ApprovedTotal: q.approved.Load(),
+		DeniedTotal:   q.denied.Load(),
+		TimedOutTotal: q.timedOut.Load(),
+		CanceledTotal: q.canceled.Load(),
+	}
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
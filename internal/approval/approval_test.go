@@ -0,0 +1,155 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func newTestQueue(t *testing.T, policy config.ApprovalPolicy) *Queue {
+	t.Helper()
+	if policy.AdminTokenEnv == "" {
+		t.Setenv("APPROVAL_TOKEN", "s3cret")
+		policy.AdminTokenEnv = "APPROVAL_TOKEN"
+	}
+	q, err := New(policy, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("new queue: %v", err)
+	}
+	return q
+}
+
+// waitPending polls until n calls are queued and returns them.
+func waitPending(t *testing.T, q *Queue, n int) []Pending {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if p := q.Pending(); len(p) == n {
+			return p
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d pending calls, got %d", n, len(q.Pending()))
+	return nil
+}
+
+func TestRulesMatchToolsAndArguments(t *testing.T) {
+	q := newTestQueue(t, config.ApprovalPolicy{
+		Timeout: "20ms",
+		Rules: []config.ApprovalRule{
+			{Name: "staging", Tools: []string{"deploy"}, Arguments: map[string]string{"$.params.arguments.env": "^staging$"}, Action: ActionAllow},
+			{Name: "deploy", Tools: []string{"deploy"}},
+			{Name: "big-payment", Tools: []string{"pay"}, Arguments: map[string]string{"$.params.arguments.items[*].amount": `^\d{4,}$`}},
+		},
+	})
+
+	cases := []struct {
+		tool string
+		args string
+		want string
+	}{
+		{"deploy", `{"env":"staging"}`, ""},
+		{"deploy", `{"env":"prod"}`, "deploy"},
+		{"pay", `{"items":[{"amount":5},{"amount":12000}]}`, "big-payment"},
+		{"pay", `{"items":[{"amount":5}]}`, ""},
+		{"search", `{}`, ""},
+	}
+	for _, tc := range cases {
+		got := q.Await(context.Background(), tc.tool, json.RawMessage(tc.args))
+		if tc.want == "" {
+			if got != nil {
+				t.Fatalf("%s %s: expected no approval, got %+v", tc.tool, tc.args, got)
+			}
+			continue
+		}
+		if got == nil || got.Rule != tc.want || got.Outcome != record.ApprovalTimedOut {
+			t.Fatalf("%s %s: got %+v want timed out under rule %s", tc.tool, tc.args, got, tc.want)
+		}
+	}
+	if st := q.Stats(); st.TimedOutTotal != 2 || st.Pending != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestResolveAndWebhook(t *testing.T) {
+	events := make(chan string, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Event string `json:"event"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		events <- msg.Event
+	}))
+	t.Cleanup(hook.Close)
+
+	q := newTestQueue(t, config.ApprovalPolicy{WebhookURL: hook.URL, Rules: []config.ApprovalRule{{Tools: []string{"rm"}}}})
+	done := make(chan *record.Approval, 1)
+	go func() { done <- q.Await(context.Background(), "rm", json.RawMessage(`{"path":"/"}`)) }()
+
+	pending := waitPending(t, q, 1)
+	if pending[0].Tool != "rm" || string(pending[0].Arguments) != `{"path":"/"}` {
+		t.Fatalf("unexpected pending call %+v", pending[0])
+	}
+	if err := q.Resolve("missing", true, ""); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := q.Resolve(pending[0].ID, false, "not today"); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	got := <-done
+	if !got.Denied() || got.Outcome != record.ApprovalDenied || got.Reason != "not today" {
+		t.Fatalf("unexpected decision %+v", got)
+	}
+	if err := q.Resolve(pending[0].ID, true, ""); err != ErrNotFound {
+		t.Fatalf("resolving twice should fail, got %v", err)
+	}
+
+	var seen []string
+	for len(seen) < 2 {
+		select {
+		case e := <-events:
+			seen = append(seen, e)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("webhook events=%v", seen)
+		}
+	}
+	if !strings.Contains(strings.Join(seen, ","), "approval.pending") || !strings.Contains(strings.Join(seen, ","), "approval.resolved") {
+		t.Fatalf("webhook events=%v", seen)
+	}
+}
+
+func TestAwaitCanceledAndAdminToken(t *testing.T) {
+	t.Setenv("APPROVAL_TOKEN", "s3cret")
+	q := newTestQueue(t, config.ApprovalPolicy{AdminTokenEnv: "APPROVAL_TOKEN", Rules: []config.ApprovalRule{{}}})
+	if q.Authorized("") || q.Authorized("Bearer nope") || !q.Authorized("Bearer s3cret") {
+		t.Fatal("admin token not enforced")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *record.Approval, 1)
+	go func() { done <- q.Await(ctx, "any", nil) }()
+	waitPending(t, q, 1)
+	cancel()
+	if got := <-done; got.Outcome != record.ApprovalCanceled {
+		t.Fatalf("expected canceled, got %+v", got)
+	}
+	if st := q.Stats(); st.CanceledTotal != 1 || st.Pending != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	if _, err := New(config.ApprovalPolicy{AdminTokenEnv: "APPROVAL_TOKEN_UNSET", Rules: []config.ApprovalRule{{}}}, nil); err == nil {
+		t.Fatal("expected error for empty admin token env")
+	}
+	if _, err := New(config.ApprovalPolicy{Rules: []config.ApprovalRule{{}}}, nil); err == nil {
+		t.Fatal("expected error for rules without an admin token")
+	}
+}
@@ -5,10 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
 )
 
 type Policy struct {
@@ -21,12 +25,55 @@ type Policy struct {
 	Record      RecordPolicy         `json:"record" yaml:"record"`
 	Replay      ReplayPolicy         `json:"replay" yaml:"replay"`
 	HTTP        HTTPPolicy           `json:"http" yaml:"http"`
+	Faults      FaultPolicy          `json:"faults" yaml:"faults"`
+	Shadow      ShadowPolicy         `json:"shadow" yaml:"shadow"`
+
+	ResponseFilter ResponseFilterPolicy `json:"response_filter" yaml:"response_filter"`
+	Screening      ScreeningPolicy      `json:"screening" yaml:"screening"`
+	PII            PIIPolicy            `json:"pii" yaml:"pii"`
+	Approval       ApprovalPolicy       `json:"approval" yaml:"approval"`
+
+	// Aliases maps client-visible tool names onto upstream tools.
+	Aliases map[string]ToolAlias `json:"aliases" yaml:"aliases"`
+
+	// LocalTools are answered by the gateway itself without an upstream.
+	LocalTools map[string]LocalTool `json:"local_tools" yaml:"local_tools"`
+
+	// Resources and Prompts gate resources/read, resources/subscribe, and
+	// prompts/get and filter the matching list responses.
+	Resources ResourcePolicy `json:"resources" yaml:"resources"`
+	Prompts   PromptPolicy   `json:"prompts" yaml:"prompts"`
+
+	// Methods restricts which JSON-RPC methods reach the upstream.
+	Methods MethodPolicy `json:"methods" yaml:"methods"`
+
+	// Initialize rewrites the initialize handshake.
+	Initialize InitializePolicy `json:"initialize" yaml:"initialize"`
+
+	// ServerRequests governs requests the upstream sends to the client.
+	ServerRequests ServerRequestPolicy `json:"server_requests" yaml:"server_requests"`
+
+	// Progress governs notifications/progress in upstream streams.
+	Progress ProgressPolicy `json:"progress" yaml:"progress"`
 }
 
 type RecordPolicy struct {
 	RedactKeys     []string `json:"redact_keys" yaml:"redact_keys"`
 	RedactKeyRegex []string `json:"redact_key_regex" yaml:"redact_key_regex"`
 
+	// Optional value-based redaction for secrets embedded in string values.
+	// redact_detectors enables built-in detectors by name (jwt, aws_access_key,
+	// aws_secret_key, pem_private_key, bearer_token, url_credentials, or all);
+	// redact_value_patterns adds custom regexes.
+	RedactDetectors     []string             `json:"redact_detectors" yaml:"redact_detectors"`
+	RedactValuePatterns []ValuePatternPolicy `json:"redact_value_patterns" yaml:"redact_value_patterns"`
+
+	// Optional path-scoped rules, applied before key rules and detectors.
+	// Pseudonymize replaces values with a keyed HMAC token read from the
+	// environment variable named by pseudonym_key_env.
+	RedactRules     []RedactRulePolicy `json:"redact_rules" yaml:"redact_rules"`
+	PseudonymKeyEnv string             `json:"pseudonym_key_env" yaml:"pseudonym_key_env"`
+
 	// Optional recorder lifecycle controls.
 	// max_bytes: rotate the active record file when the next append would exceed this size.
 	// max_files: number of rotated backup files to retain (e.g. path.1..path.N).
@@ -34,8 +81,33 @@ type RecordPolicy struct {
 	MaxFiles *int   `json:"max_files" yaml:"max_files"`
 }
 
+// ValuePatternPolicy is a custom value detector. When Regex has a capture
+// group named "secret" only that group is masked; otherwise the whole match
+// is. KeepPrefix leading characters of the masked text stay visible.
+type ValuePatternPolicy struct {
+	Name       string `json:"name" yaml:"name"`
+	Regex      string `json:"regex" yaml:"regex"`
+	KeepPrefix int    `json:"keep_prefix" yaml:"keep_prefix"`
+}
+
+// RedactRulePolicy redacts or pseudonymizes the value at a JSON path of the
+// recorded request, response, and stream events (e.g.
+// "$.params.arguments.user.email" or "$.result.content[*].text"). Empty
+// methods/tools match every exchange; tools only match tools/call.
+type RedactRulePolicy struct {
+	Path    string   `json:"path" yaml:"path"`
+	Methods []string `json:"methods" yaml:"methods"`
+	Tools   []string `json:"tools" yaml:"tools"`
+	// redact (default) or pseudonymize.
+	Action string `json:"action" yaml:"action"`
+}
+
 type ReplayPolicy struct {
 	Match string `json:"match" yaml:"match"`
+
+	// Optional: when replaying recorded SSE streams, wait between events for the
+	// recorded inter-event offsets instead of emitting them back to back.
+	StreamTiming bool `json:"stream_timing" yaml:"stream_timing"`
 }
 
 type HTTPPolicy struct {
@@ -55,8 +127,346 @@ type HTTPPolicy struct {
 	PrometheusMetrics bool `json:"prometheus_metrics" yaml:"prometheus_metrics"`
 }
 
+// FaultPolicy configures chaos/fault injection for live upstream traffic.
+// Faults are never injected for replayed responses.
+type FaultPolicy struct {
+	// Optional seed for probability rolls. When set, the sequence of injected
+	// faults is deterministic for a given request order.
+	Seed  *int64      `json:"seed" yaml:"seed"`
+	Rules []FaultRule `json:"rules" yaml:"rules"`
+}
+
+type FaultRule struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Optional match filters. Empty lists match everything; a non-empty tools
+	// list only matches tools/call requests.
+	Methods []string `json:"methods" yaml:"methods"`
+	Tools   []string `json:"tools" yaml:"tools"`
+
+	// Probability in [0,1] that a matched request is faulted (default 1).
+	Probability *float64 `json:"probability" yaml:"probability"`
+
+	// phase: before (default) injects without calling the upstream when the
+	// fault replaces the response; after calls the upstream first and then
+	// replaces or delays its response.
+	Phase string `json:"phase" yaml:"phase"`
+
+	// Fault components; at least one is required.
+	Latency          string `json:"latency" yaml:"latency"`
+	ErrorCode        int    `json:"error_code" yaml:"error_code"`
+	ErrorMessage     string `json:"error_message" yaml:"error_message"`
+	HTTPStatus       int    `json:"http_status" yaml:"http_status"`
+	TruncateSSEBytes int64  `json:"truncate_sse_bytes" yaml:"truncate_sse_bytes"`
+}
+
+// WithDefaults returns the rule at index i with unset fields defaulted: name
+// rule-<i>, phase before, probability 1, and error message "injected fault"
+// for error_code rules. LoadPolicy applies it; the fault injector applies it
+// again for policies built in code.
+func (r FaultRule) WithDefaults(i int) FaultRule {
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("rule-%d", i)
+	}
+	if r.Phase == "" {
+		r.Phase = "before"
+	}
+	if r.Probability == nil {
+		p := 1.0
+		r.Probability = &p
+	}
+	if r.ErrorCode != 0 && r.ErrorMessage == "" {
+		r.ErrorMessage = "injected fault"
+	}
+	return r
+}
+
+// ShadowPolicy tunes shadow comparisons (see --shadow-replay and
+// --shadow-candidate).
+type ShadowPolicy struct {
+	// JSON paths excluded from comparison, e.g. "$.result.meta.timestamp" or
+	// "$.result.content[*].id". Response ids are always ignored.
+	IgnorePaths []string `json:"ignore_paths" yaml:"ignore_paths"`
+	// MaxInflight caps concurrent candidate mirrors (default 32); requests
+	// beyond it are not mirrored.
+	MaxInflight int `json:"max_inflight" yaml:"max_inflight"`
+}
+
+// ResponseFilterPolicy redacts upstream result payloads before they reach the
+// client, including JSON-RPC responses inside SSE data events. Rules use the
+// record redaction syntax; path rules are matched against the response
+// message (e.g. "$.result.content[*].text"). Error responses pass through.
+type ResponseFilterPolicy struct {
+	// enforce (default) masks matches, audit only logs and counts them, off
+	// disables the filter.
+	Mode string `json:"mode" yaml:"mode"`
+	// Optional per-tool mode overrides, e.g. {"crm.lookup": "audit"}.
+	Tools map[string]string `json:"tools" yaml:"tools"`
+
+	RedactKeys          []string             `json:"redact_keys" yaml:"redact_keys"`
+	RedactKeyRegex      []string             `json:"redact_key_regex" yaml:"redact_key_regex"`
+	RedactDetectors     []string             `json:"redact_detectors" yaml:"redact_detectors"`
+	RedactValuePatterns []ValuePatternPolicy `json:"redact_value_patterns" yaml:"redact_value_patterns"`
+	RedactRules         []RedactRulePolicy   `json:"redact_rules" yaml:"redact_rules"`
+	PseudonymKeyEnv     string               `json:"pseudonym_key_env" yaml:"pseudonym_key_env"`
+}
+
+// Redaction returns the filter's redaction rules in the shape the record
+// redactor is built from.
+func (p ResponseFilterPolicy) Redaction() RecordPolicy {
+	return RecordPolicy{
+		RedactKeys:          p.RedactKeys,
+		RedactKeyRegex:      p.RedactKeyRegex,
+		RedactDetectors:     p.RedactDetectors,
+		RedactValuePatterns: p.RedactValuePatterns,
+		RedactRules:         p.RedactRules,
+		PseudonymKeyEnv:     p.PseudonymKeyEnv,
+	}
+}
+
+// ScreeningPolicy screens tools/call results for prompt-injection content
+// before they reach the client.
+type ScreeningPolicy struct {
+	// annotate records findings in result._meta, strip also removes the
+	// matched text, block replaces the result with a JSON-RPC error. off
+	// (default) disables screening.
+	Action string `json:"action" yaml:"action"`
+	// Optional per-tool action overrides, e.g. {"web.fetch": "block"}.
+	Tools map[string]string `json:"tools" yaml:"tools"`
+	// Built-in detectors to run (instructions, hidden_unicode, exfil_links);
+	// empty runs all of them.
+	Detectors []string `json:"detectors" yaml:"detectors"`
+	// Optional extra detectors matching custom regexes.
+	Patterns []ScreenPatternPolicy `json:"patterns" yaml:"patterns"`
+}
+
+type ScreenPatternPolicy struct {
+	Name  string `json:"name" yaml:"name"`
+	Regex string `json:"regex" yaml:"regex"`
+}
+
+// PIIPolicy scans string values in tools/call arguments for personal data
+// during validation.
+type PIIPolicy struct {
+	// allow forwards the call and logs findings, mask replaces findings in
+	// the forwarded arguments, reject fails the call (subject to mode: audit
+	// only logs). off (default) disables detection.
+	Action string `json:"action" yaml:"action"`
+	// Optional per-tool action overrides, e.g. {"crm.lookup": "allow"}.
+	Tools map[string]string `json:"tools" yaml:"tools"`
+	// Detectors to run (email, phone, credit_card, national_id, ip); empty
+	// runs all of them.
+	Detectors []string `json:"detectors" yaml:"detectors"`
+}
+
+// ApprovalPolicy holds matched tools/call requests until an operator
+// approves or denies them through the admin API (/admin/approvals).
+type ApprovalPolicy struct {
+	// Rules are checked in order; the first match decides.
+	Rules []ApprovalRule `json:"rules" yaml:"rules"`
+	// How long a call waits for a decision before it is denied (default 5m).
+	Timeout string `json:"timeout" yaml:"timeout"`
+	// Optional URL that receives a JSON POST when a call is queued and when
+	// it is resolved.
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+	// Environment variable holding the bearer token required by the admin
+	// API. Required with rules: the admin API shares the MCP listener, so an
+	// unauthenticated one would let agents approve their own calls.
+	AdminTokenEnv string `json:"admin_token_env" yaml:"admin_token_env"`
+}
+
+// ApprovalRule matches tools/call requests by tool name and argument values.
+// Empty tools match every tool. Arguments maps a JSON path in the request
+// (e.g. "$.params.arguments.env") to a regex; every path must have a
+// matching value.
+type ApprovalRule struct {
+	Name      string            `json:"name" yaml:"name"`
+	Tools     []string          `json:"tools" yaml:"tools"`
+	Arguments map[string]string `json:"arguments" yaml:"arguments"`
+	// require_approval (default) or allow, which exempts matched calls from
+	// later rules.
+	Action string `json:"action" yaml:"action"`
+	// Optional per-rule timeout overriding approval.timeout.
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// ToolAlias exposes an upstream tool under another name. Calls to the alias
+// are rewritten to the upstream tool on the way up, and tools/list results
+// gain an entry for the alias derived from the upstream one. Policy sections
+// keyed by tool name (tools, allow_tools, pii, ...) and recordings use the
+// alias name; approval rules match the forwarded call and so the upstream
+// name.
+type ToolAlias struct {
+	// Upstream tool name (required).
+	Tool string `json:"tool" yaml:"tool"`
+	// Optional overrides for the listed description and inputSchema.
+	Description string         `json:"description" yaml:"description"`
+	InputSchema map[string]any `json:"input_schema" yaml:"input_schema"`
+	// Top-level parameters removed from the listed inputSchema and from
+	// client arguments.
+	HideParams []string `json:"hide_params" yaml:"hide_params"`
+	// Fixed arguments merged into every call, overriding client values; they
+	// are also removed from the listed inputSchema. An alias with fixed
+	// arguments is a virtual tool.
+	Arguments map[string]any `json:"arguments" yaml:"arguments"`
+	// Keep listing the upstream tool under its own name. By default it is
+	// hidden from tools/list once an alias points at it.
+	KeepUpstream bool `json:"keep_upstream" yaml:"keep_upstream"`
+}
+
+// LocalTool is a tool implemented by the gateway. Local tools are listed in
+// tools/list and go through validation, approvals, and recording like
+// upstream tools.
+type LocalTool struct {
+	// static returns result, echo returns the arguments, status reports
+	// gateway state, command runs a local program.
+	Kind        string         `json:"kind" yaml:"kind"`
+	Description string         `json:"description" yaml:"description"`
+	InputSchema map[string]any `json:"input_schema" yaml:"input_schema"`
+
+	// Tool result returned by static tools, e.g. {"content": [...]}.
+	Result map[string]any `json:"result" yaml:"result"`
+
+	// Command tools: argv without a shell. Elements may contain
+	// {{args.<name>}} placeholders naming input_schema properties; values
+	// must be scalars and may not start with "-".
+	Command []string `json:"command" yaml:"command"`
+	Dir     string   `json:"dir" yaml:"dir"`
+	// Maximum run time (default 10s).
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// ServerRequestPolicy governs requests the upstream sends to the client in
+// SSE streams (sampling/createMessage, elicitation/create, roots/list).
+type ServerRequestPolicy struct {
+	// Methods answered upstream with an error instead of being relayed to
+	// the client: names or path.Match patterns.
+	Deny []string `json:"deny" yaml:"deny"`
+	// MaxTokens caps params.maxTokens of sampling/createMessage; 0 keeps it.
+	MaxTokens int `json:"max_tokens" yaml:"max_tokens"`
+	// Timeout is how long a relayed request waits for the client's response
+	// (default 5m).
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// ProgressPolicy governs notifications/progress events in upstream SSE
+// streams and streamed results for clients that did not ask for SSE.
+type ProgressPolicy struct {
+	// Mode is "pass" (default), "throttle" (at most one notification per
+	// request per MinInterval), or "drop".
+	Mode string `json:"mode" yaml:"mode"`
+	// MinInterval is the throttle interval (default 1s).
+	MinInterval string `json:"min_interval" yaml:"min_interval"`
+	// ValidateTokens drops notifications whose progressToken is not the one
+	// in the request's params._meta.
+	ValidateTokens bool `json:"validate_tokens" yaml:"validate_tokens"`
+	// BufferStreams answers clients without Accept: text/event-stream with
+	// the final response of a streamed result instead of an error.
+	BufferStreams bool `json:"buffer_streams" yaml:"buffer_streams"`
+}
+
+// InitializePolicy rewrites initialize requests and responses.
+type InitializePolicy struct {
+	// Supported protocol versions, preferred first. Clients requesting
+	// another version are forwarded the preferred one; upstream answers with
+	// an unsupported version are rejected. Empty accepts any version.
+	ProtocolVersions []string `json:"protocol_versions" yaml:"protocol_versions"`
+
+	// Capability rewrites for the client's params.capabilities and the
+	// upstream's result.capabilities.
+	ClientCapabilities CapabilityRewrite `json:"client_capabilities" yaml:"client_capabilities"`
+	ServerCapabilities CapabilityRewrite `json:"server_capabilities" yaml:"server_capabilities"`
+
+	// ServerInfo overrides the non-empty fields of result.serverInfo.
+	ServerInfo ServerInfo `json:"server_info" yaml:"server_info"`
+	// Instructions is appended to result.instructions.
+	Instructions string `json:"instructions" yaml:"instructions"`
+}
+
+// CapabilityRewrite removes capabilities by dotted path (for example
+// "resources.subscribe") and then merges add into the capabilities object.
+type CapabilityRewrite struct {
+	Strip []string       `json:"strip" yaml:"strip"`
+	Add   map[string]any `json:"add" yaml:"add"`
+}
+
+type ServerInfo struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// MethodPolicy restricts JSON-RPC methods. Allow and deny entries are method
+// names or path.Match patterns such as "notifications/*". Deny wins; with
+// default_deny or a non-empty allow list a method must match allow.
+type MethodPolicy struct {
+	DefaultDeny bool     `json:"default_deny" yaml:"default_deny"`
+	Allow       []string `json:"allow" yaml:"allow"`
+	Deny        []string `json:"deny" yaml:"deny"`
+
+	// Optional JSON schemas for params keyed by method name. Missing params
+	// validate as {}.
+	Params map[string]map[string]any `json:"params" yaml:"params"`
+}
+
+// ResourcePolicy restricts the resource URIs clients may read or subscribe
+// to. Deny rules win; when allow is non-empty a URI must match one of its
+// rules.
+type ResourcePolicy struct {
+	Allow []URIRule `json:"allow" yaml:"allow"`
+	Deny  []URIRule `json:"deny" yaml:"deny"`
+}
+
+// URIRule matches resource URIs. Exactly one field is set: scheme compares
+// the URI scheme case-insensitively, prefix is a literal prefix, and glob is
+// a path.Match pattern where * does not cross "/".
+type URIRule struct {
+	Scheme string `json:"scheme" yaml:"scheme"`
+	Prefix string `json:"prefix" yaml:"prefix"`
+	Glob   string `json:"glob" yaml:"glob"`
+}
+
+// PromptPolicy restricts the prompts clients may get by name and validates
+// prompt arguments against per-prompt JSON schemas.
+type PromptPolicy struct {
+	Allow   []string                  `json:"allow" yaml:"allow"`
+	Deny    []string                  `json:"deny" yaml:"deny"`
+	Schemas map[string]map[string]any `json:"schemas" yaml:"schemas"`
+}
+
 type ToolEntry struct {
 	Schema map[string]any `json:"schema" yaml:"schema"`
+
+	// Optional JSON schema for the tool result's structuredContent. Results
+	// flagged isError are not checked.
+	OutputSchema map[string]any `json:"output_schema" yaml:"output_schema"`
+
+	// Optional argument rewriting applied after validation, before the call
+	// is forwarded upstream.
+	Transform TransformPolicy `json:"transform" yaml:"transform"`
+}
+
+// TransformPolicy rewrites tools/call arguments. strip_unknown runs first,
+// then apply_defaults, then ops in order.
+type TransformPolicy struct {
+	// Drop object properties not declared in the tool schema.
+	StripUnknown bool `json:"strip_unknown" yaml:"strip_unknown"`
+	// Fill missing properties from the tool schema's "default" keywords.
+	ApplyDefaults bool          `json:"apply_defaults" yaml:"apply_defaults"`
+	Ops           []TransformOp `json:"ops" yaml:"ops"`
+}
+
+// TransformOp is one rewrite step. Paths are JSON paths under
+// "$.params.arguments"; remove and clamp accept "*" segments.
+type TransformOp struct {
+	// set, default (set only when missing), remove, clamp, or rename.
+	Op    string `json:"op" yaml:"op"`
+	Path  string `json:"path" yaml:"path"`
+	Value any    `json:"value" yaml:"value"`
+	// Bounds for clamp; either may be omitted.
+	Min *float64 `json:"min" yaml:"min"`
+	Max *float64 `json:"max" yaml:"max"`
+	// Destination path for rename.
+	To string `json:"to" yaml:"to"`
 }
 
 func LoadPolicy(path string) (*Policy, error) {
@@ -96,6 +506,15 @@ func LoadPolicy(path string) (*Policy, error) {
 	if policy.Tools == nil {
 		policy.Tools = map[string]ToolEntry{}
 	}
+	for name, entry := range policy.Tools {
+		if (entry.Transform.StripUnknown || entry.Transform.ApplyDefaults) && entry.Schema == nil {
+			return nil, fmt.Errorf("tools[%q].transform: strip_unknown and apply_defaults require a schema", name)
+		}
+		if err := validateTransform(name, &entry.Transform); err != nil {
+			return nil, err
+		}
+		policy.Tools[name] = entry
+	}
 	if policy.Replay.Match == "" {
 		policy.Replay.Match = "signature"
 	}
@@ -111,6 +530,66 @@ func LoadPolicy(path string) (*Policy, error) {
 		return nil, errors.New("record.max_files must be >= 0")
 	}
 
+	if err := validateRedaction("record", &policy.Record); err != nil {
+		return nil, err
+	}
+	if err := validateResponseFilter(&policy.ResponseFilter); err != nil {
+		return nil, err
+	}
+
+	if err := validatePII(&policy.PII); err != nil {
+		return nil, err
+	}
+	if err := validateScreening(&policy.Screening); err != nil {
+		return nil, err
+	}
+	for name, alias := range policy.Aliases {
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("aliases: alias name must not be empty")
+		}
+		if alias.Tool == "" {
+			return nil, fmt.Errorf("aliases[%q].tool is required", name)
+		}
+		if alias.Tool == name && alias.KeepUpstream {
+			return nil, fmt.Errorf("aliases[%q]: keep_upstream cannot be set on an alias named after its tool", name)
+		}
+	}
+	if err := validateResources(&policy.Resources); err != nil {
+		return nil, err
+	}
+	if err := validateMethods(&policy.Methods); err != nil {
+		return nil, err
+	}
+	if err := validateInitialize(&policy.Initialize); err != nil {
+		return nil, err
+	}
+	if err := validateServerRequests(&policy.ServerRequests); err != nil {
+		return nil, err
+	}
+	if err := validateProgress(&policy.Progress); err != nil {
+		return nil, err
+	}
+	for name, tool := range policy.LocalTools {
+		if err := validateLocalTool(name, &tool); err != nil {
+			return nil, err
+		}
+		policy.LocalTools[name] = tool
+	}
+	if err := validateApproval(&policy.Approval); err != nil {
+		return nil, err
+	}
+	if err := validateFaults(&policy.Faults); err != nil {
+		return nil, err
+	}
+	for i, raw := range policy.Shadow.IgnorePaths {
+		if _, err := jsondiff.ParsePath(raw); err != nil {
+			return nil, fmt.Errorf("shadow.ignore_paths[%d]: %w", i, err)
+		}
+	}
+	if policy.Shadow.MaxInflight < 0 {
+		return nil, errors.New("shadow.max_inflight must be >= 0")
+	}
+
 	// Validate configured header names early to avoid silently ignoring typos.
 	for _, h := range policy.HTTP.ForwardHeaders {
 		h = strings.TrimSpace(h)
@@ -124,6 +603,371 @@ func LoadPolicy(path string) (*Policy, error) {
 	return policy, nil
 }
 
+// validateRedaction checks and normalizes the redaction rules of a record or
+// response_filter section.
+func validateRedaction(section string, p *RecordPolicy) error {
+	for i := range p.RedactValuePatterns {
+		pattern := &p.RedactValuePatterns[i]
+		if pattern.Name == "" {
+			pattern.Name = fmt.Sprintf("pattern-%d", i)
+		}
+		if pattern.Regex == "" {
+			return fmt.Errorf("%s.redact_value_patterns[%d].regex is required", section, i)
+		}
+		if pattern.KeepPrefix < 0 {
+			return fmt.Errorf("%s.redact_value_patterns[%d].keep_prefix must be >= 0", section, i)
+		}
+	}
+
+	for i := range p.RedactRules {
+		rule := &p.RedactRules[i]
+		if _, err := jsondiff.ParsePath(rule.Path); err != nil {
+			return fmt.Errorf("%s.redact_rules[%d].path: %w", section, i, err)
+		}
+		rule.Action = strings.ToLower(rule.Action)
+		if rule.Action == "" {
+			rule.Action = "redact"
+		}
+		if rule.Action != "redact" && rule.Action != "pseudonymize" {
+			return fmt.Errorf("%s.redact_rules[%d].action must be redact or pseudonymize", section, i)
+		}
+		if rule.Action == "pseudonymize" && p.PseudonymKeyEnv == "" {
+			return fmt.Errorf("%s.redact_rules[%d]: pseudonymize requires %s.pseudonym_key_env", section, i, section)
+		}
+	}
+	return nil
+}
+
+func validateResponseFilter(filter *ResponseFilterPolicy) error {
+	normalize := func(mode string) (string, bool) {
+		mode = strings.ToLower(mode)
+		if mode == "" {
+			mode = "enforce"
+		}
+		return mode, mode == "enforce" || mode == "audit" || mode == "off"
+	}
+	var ok bool
+	if filter.Mode, ok = normalize(filter.Mode); !ok {
+		return errors.New("response_filter.mode must be enforce, audit, or off")
+	}
+	for tool, mode := range filter.Tools {
+		if filter.Tools[tool], ok = normalize(mode); !ok {
+			return fmt.Errorf("response_filter.tools[%q] must be enforce, audit, or off", tool)
+		}
+	}
+
+	// Redaction shares the rule slices, so normalized defaults apply in place.
+	redaction := filter.Redaction()
+	return validateRedaction("response_filter", &redaction)
+}
+
+func validatePII(pii *PIIPolicy) error {
+	normalize := func(action string) (string, bool) {
+		action = strings.ToLower(action)
+		if action == "" {
+			action = "off"
+		}
+		switch action {
+		case "allow", "mask", "reject", "off":
+			return action, true
+		}
+		return action, false
+	}
+	var ok bool
+	if pii.Action, ok = normalize(pii.Action); !ok {
+		return errors.New("pii.action must be allow, mask, reject, or off")
+	}
+	for tool, action := range pii.Tools {
+		if pii.Tools[tool], ok = normalize(action); !ok {
+			return fmt.Errorf("pii.tools[%q] must be allow, mask, reject, or off", tool)
+		}
+	}
+	return nil
+}
+
+func validateScreening(screening *ScreeningPolicy) error {
+	normalize := func(action string) (string, bool) {
+		action = strings.ToLower(action)
+		if action == "" {
+			action = "off"
+		}
+		switch action {
+		case "annotate", "strip", "block", "off":
+			return action, true
+		}
+		return action, false
+	}
+	var ok bool
+	if screening.Action, ok = normalize(screening.Action); !ok {
+		return errors.New("screening.action must be annotate, strip, block, or off")
+	}
+	for tool, action := range screening.Tools {
+		if screening.Tools[tool], ok = normalize(action); !ok {
+			return fmt.Errorf("screening.tools[%q] must be annotate, strip, block, or off", tool)
+		}
+	}
+	for i := range screening.Patterns {
+		pattern := &screening.Patterns[i]
+		if pattern.Name == "" {
+			pattern.Name = fmt.Sprintf("pattern-%d", i)
+		}
+		if pattern.Regex == "" {
+			return fmt.Errorf("screening.patterns[%d].regex is required", i)
+		}
+	}
+	return nil
+}
+
+func validateServerRequests(sr *ServerRequestPolicy) error {
+	for i, pattern := range sr.Deny {
+		if pattern == "" {
+			return fmt.Errorf("server_requests.deny[%d] must not be empty", i)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("server_requests.deny[%d]: %w", i, err)
+		}
+	}
+	if sr.MaxTokens < 0 {
+		return errors.New("server_requests.max_tokens must be >= 0")
+	}
+	if sr.Timeout != "" {
+		d, err := time.ParseDuration(sr.Timeout)
+		if err != nil || d <= 0 {
+			return errors.New("server_requests.timeout must be a positive duration")
+		}
+	}
+	return nil
+}
+
+func validateProgress(p *ProgressPolicy) error {
+	p.Mode = strings.ToLower(strings.TrimSpace(p.Mode))
+	switch p.Mode {
+	case "", "pass", "throttle", "drop":
+	default:
+		return fmt.Errorf("progress.mode must be pass, throttle, or drop (got %q)", p.Mode)
+	}
+	if p.MinInterval != "" {
+		d, err := time.ParseDuration(p.MinInterval)
+		if err != nil || d <= 0 {
+			return errors.New("progress.min_interval must be a positive duration")
+		}
+	}
+	return nil
+}
+
+func validateInitialize(init *InitializePolicy) error {
+	for i, v := range init.ProtocolVersions {
+		if strings.TrimSpace(v) == "" {
+			return fmt.Errorf("initialize.protocol_versions[%d] must not be empty", i)
+		}
+	}
+	check := func(list string, paths []string) error {
+		for i, p := range paths {
+			for _, part := range strings.Split(p, ".") {
+				if part == "" {
+					return fmt.Errorf("initialize.%s.strip[%d]: invalid capability path %q", list, i, p)
+				}
+			}
+		}
+		return nil
+	}
+	if err := check("client_capabilities", init.ClientCapabilities.Strip); err != nil {
+		return err
+	}
+	return check("server_capabilities", init.ServerCapabilities.Strip)
+}
+
+func validateMethods(methods *MethodPolicy) error {
+	check := func(list string, patterns []string) error {
+		for i, pattern := range patterns {
+			if pattern == "" {
+				return fmt.Errorf("methods.%s[%d] must not be empty", list, i)
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("methods.%s[%d]: %w", list, i, err)
+			}
+		}
+		return nil
+	}
+	if err := check("allow", methods.Allow); err != nil {
+		return err
+	}
+	return check("deny", methods.Deny)
+}
+
+func validateResources(resources *ResourcePolicy) error {
+	check := func(list string, rules []URIRule) error {
+		for i, rule := range rules {
+			set := 0
+			for _, v := range []string{rule.Scheme, rule.Prefix, rule.Glob} {
+				if v != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("resources.%s[%d] must set exactly one of scheme, prefix, or glob", list, i)
+			}
+			if rule.Glob != "" {
+				if _, err := path.Match(rule.Glob, ""); err != nil {
+					return fmt.Errorf("resources.%s[%d].glob: %w", list, i, err)
+				}
+			}
+		}
+		return nil
+	}
+	if err := check("allow", resources.Allow); err != nil {
+		return err
+	}
+	return check("deny", resources.Deny)
+}
+
+func validateLocalTool(name string, tool *LocalTool) error {
+	tool.Kind = strings.ToLower(tool.Kind)
+	switch tool.Kind {
+	case "static":
+		if tool.Result == nil {
+			return fmt.Errorf("local_tools[%q]: static tools require result", name)
+		}
+	case "echo", "status":
+	case "command":
+		if len(tool.Command) == 0 {
+			return fmt.Errorf("local_tools[%q]: command tools require command", name)
+		}
+		if tool.Timeout != "" {
+			d, err := time.ParseDuration(tool.Timeout)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("local_tools[%q].timeout must be a positive duration", name)
+			}
+		}
+	default:
+		return fmt.Errorf("local_tools[%q].kind must be static, echo, status, or command", name)
+	}
+	return nil
+}
+
+func validateApproval(approval *ApprovalPolicy) error {
+	if len(approval.Rules) > 0 && approval.AdminTokenEnv == "" {
+		return errors.New("approval.admin_token_env is required when approval rules are configured")
+	}
+	if approval.Timeout != "" {
+		d, err := time.ParseDuration(approval.Timeout)
+		if err != nil || d <= 0 {
+			return errors.New("approval.timeout must be a positive duration")
+		}
+	}
+	for i := range approval.Rules {
+		rule := &approval.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i)
+		}
+		rule.Action = strings.ToLower(rule.Action)
+		if rule.Action == "" {
+			rule.Action = "require_approval"
+		}
+		if rule.Action != "require_approval" && rule.Action != "allow" {
+			return fmt.Errorf("approval.rules[%d].action must be require_approval or allow", i)
+		}
+		if rule.Timeout != "" {
+			d, err := time.ParseDuration(rule.Timeout)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("approval.rules[%d].timeout must be a positive duration", i)
+			}
+		}
+		for path := range rule.Arguments {
+			if _, err := jsondiff.ParsePath(path); err != nil {
+				return fmt.Errorf("approval.rules[%d].arguments: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTransform(tool string, t *TransformPolicy) error {
+	argPath := func(field string, i int, raw string, wildcard bool) error {
+		p, err := jsondiff.ParsePath(raw)
+		if err != nil {
+			return fmt.Errorf("tools[%q].transform.ops[%d].%s: %w", tool, i, field, err)
+		}
+		segs := p.Segments()
+		if len(segs) < 3 || segs[0] != "params" || segs[1] != "arguments" {
+			return fmt.Errorf("tools[%q].transform.ops[%d].%s must be below $.params.arguments", tool, i, field)
+		}
+		for _, seg := range segs {
+			if seg == "*" && !wildcard {
+				return fmt.Errorf("tools[%q].transform.ops[%d].%s cannot use * with %s", tool, i, field, t.Ops[i].Op)
+			}
+		}
+		return nil
+	}
+	for i := range t.Ops {
+		op := &t.Ops[i]
+		op.Op = strings.ToLower(op.Op)
+		switch op.Op {
+		case "set", "default":
+			if err := argPath("path", i, op.Path, false); err != nil {
+				return err
+			}
+		case "remove":
+			if err := argPath("path", i, op.Path, true); err != nil {
+				return err
+			}
+		case "clamp":
+			if err := argPath("path", i, op.Path, true); err != nil {
+				return err
+			}
+			if op.Min == nil && op.Max == nil {
+				return fmt.Errorf("tools[%q].transform.ops[%d]: clamp requires min or max", tool, i)
+			}
+			if op.Min != nil && op.Max != nil && *op.Min > *op.Max {
+				return fmt.Errorf("tools[%q].transform.ops[%d]: clamp min must be <= max", tool, i)
+			}
+		case "rename":
+			if err := argPath("path", i, op.Path, false); err != nil {
+				return err
+			}
+			if err := argPath("to", i, op.To, false); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("tools[%q].transform.ops[%d].op must be set, default, remove, clamp, or rename", tool, i)
+		}
+	}
+	return nil
+}
+
+func validateFaults(faults *FaultPolicy) error {
+	for i := range faults.Rules {
+		rule := &faults.Rules[i]
+		rule.Phase = strings.ToLower(rule.Phase)
+		*rule = rule.WithDefaults(i)
+		if rule.Phase != "before" && rule.Phase != "after" {
+			return fmt.Errorf("faults.rules[%d].phase must be before or after", i)
+		}
+		if *rule.Probability < 0 || *rule.Probability > 1 {
+			return fmt.Errorf("faults.rules[%d].probability must be between 0 and 1", i)
+		}
+		if rule.Latency != "" {
+			d, err := time.ParseDuration(rule.Latency)
+			if err != nil || d < 0 {
+				return fmt.Errorf("faults.rules[%d].latency must be a non-negative duration", i)
+			}
+		}
+		if rule.HTTPStatus != 0 && (rule.HTTPStatus < 500 || rule.HTTPStatus > 599) {
+			return fmt.Errorf("faults.rules[%d].http_status must be a 5xx status", i)
+		}
+		if rule.ErrorCode != 0 && rule.HTTPStatus != 0 {
+			return fmt.Errorf("faults.rules[%d] cannot set both error_code and http_status", i)
+		}
+		if rule.TruncateSSEBytes < 0 {
+			return fmt.Errorf("faults.rules[%d].truncate_sse_bytes must be >= 0", i)
+		}
+		if rule.Latency == "" && rule.ErrorCode == 0 && rule.HTTPStatus == 0 && rule.TruncateSSEBytes == 0 {
+			return fmt.Errorf("faults.rules[%d] must set latency, error_code, http_status, or truncate_sse_bytes", i)
+		}
+	}
+	return nil
+}
+
 func isValidHeaderName(name string) bool {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -0,0 +1,191 @@
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+type Phase string
+
+const (
+	PhaseBefore Phase = "before"
+	PhaseAfter  Phase = "after"
+)
+
+// Fault is a single injected fault selected for one request.
+type Fault struct {
+	Rule             string
+	Phase            Phase
+	Latency          time.Duration
+	ErrorCode        int
+	ErrorMessage     string
+	HTTPStatus       int
+	TruncateSSEBytes int64
+}
+
+type rule struct {
+	fault       Fault
+	methods     map[string]struct{}
+	tools       map[string]struct{}
+	probability float64
+}
+
+type Injector struct {
+	mu    sync.Mutex
+	rng   *rand.Rand
+	rules []rule
+}
+
+func New(policy config.FaultPolicy) (*Injector, error) {
+	if len(policy.Rules) == 0 {
+		return nil, nil
+	}
+	seed := time.Now().UnixNano()
+	if policy.Seed != nil {
+		seed = *policy.Seed
+	}
+	inj := &Injector{rng: rand.New(rand.NewSource(seed))}
+	for i, cfg := range policy.Rules {
+		cfg = cfg.WithDefaults(i)
+		r := rule{
+			fault: Fault{
+				Rule:             cfg.Name,
+				Phase:            Phase(cfg.Phase),
+				ErrorCode:        cfg.ErrorCode,
+				ErrorMessage:     cfg.ErrorMessage,
+				HTTPStatus:       cfg.HTTPStatus,
+				TruncateSSEBytes: cfg.TruncateSSEBytes,
+			},
+			probability: *cfg.Probability,
+		}
+		if cfg.Latency != "" {
+			d, err := time.ParseDuration(cfg.Latency)
+			if err != nil {
+				return nil, fmt.Errorf("fault rule %s: %w", r.fault.Rule, err)
+			}
+			r.fault.Latency = d
+		}
+		if len(cfg.Methods) > 0 {
+			r.methods = map[string]struct{}{}
+			for _, m := range cfg.Methods {
+				r.methods[m] = struct{}{}
+			}
+		}
+		if len(cfg.Tools) > 0 {
+			r.tools = map[string]struct{}{}
+			for _, t := range cfg.Tools {
+				r.tools[t] = struct{}{}
+			}
+		}
+		inj.rules = append(inj.rules, r)
+	}
+	return inj, nil
+}
+
+// Pick returns the first matching rule whose probability roll succeeds, or nil
+// when no fault should be injected. Rules are tried in order; a matching rule
+// with probability below 1 consumes one roll, one with probability 1 fires
+// without rolling, and no rolls are made after a rule fires. Seeded runs are
+// therefore reproducible for the same request sequence.
+func (i *Injector) Pick(method, tool string) *Fault {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, r := range i.rules {
+		if !r.matches(method, tool) {
+			continue
+		}
+		if r.probability < 1 && i.rng.Float64() >= r.probability {
+			continue
+		}
+		f := r.fault
+		return &f
+	}
+	return nil
+}
+
+func (r rule) matches(method, tool string) bool {
+	if r.methods != nil {
+		if _, ok := r.methods[method]; !ok {
+			return false
+		}
+	}
+	if r.tools != nil {
+		if method != "tools/call" {
+			return false
+		}
+		if _, ok := r.tools[tool]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Delay blocks for the configured latency or until ctx is done.
+func (f *Fault) Delay(ctx context.Context) error {
+	if f == nil || f.Latency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(f.Latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Replaces reports whether the fault replaces the upstream response.
+func (f *Fault) Replaces() bool {
+	return f != nil && (f.ErrorCode != 0 || f.HTTPStatus != 0)
+}
+
+// Response builds the HTTP status and JSON-RPC payload that stand in for the
+// upstream response. Injected errors carry the rule name in error.data so
+// clients and recordings can tell them apart from real upstream failures.
+func (f *Fault) Response(id json.RawMessage) (int, json.RawMessage) {
+	status := http.StatusOK
+	code := f.ErrorCode
+	message := f.ErrorMessage
+	if f.HTTPStatus != 0 {
+		status = f.HTTPStatus
+		code = jsonrpc.ErrServer
+		message = fmt.Sprintf("injected upstream HTTP %d", f.HTTPStatus)
+	}
+	resp := jsonrpc.ErrorResponse(id, code, message, map[string]any{"injected_fault": f.Rule})
+	payload, _ := json.Marshal(resp)
+	return status, json.RawMessage(payload)
+}
+
+// String describes the fault for log lines.
+func (f *Fault) String() string {
+	if f == nil {
+		return ""
+	}
+	parts := []string{"rule=" + f.Rule, "phase=" + string(f.Phase)}
+	if f.Latency > 0 {
+		parts = append(parts, "latency="+f.Latency.String())
+	}
+	if f.ErrorCode != 0 {
+		parts = append(parts, fmt.Sprintf("error_code=%d", f.ErrorCode))
+	}
+	if f.HTTPStatus != 0 {
+		parts = append(parts, fmt.Sprintf("http_status=%d", f.HTTPStatus))
+	}
+	if f.TruncateSSEBytes > 0 {
+		parts = append(parts, fmt.Sprintf("truncate_sse_bytes=%d", f.TruncateSSEBytes))
+	}
+	return strings.Join(parts, " ")
+}
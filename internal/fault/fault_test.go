@@ -0,0 +1,107 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func TestInjectorMatchesMethodsAndTools(t *testing.T) {
+	inj, err := New(config.FaultPolicy{
+		Rules: []config.FaultRule{
+			{Name: "search-errors", Tools: []string{"web.search"}, Phase: "before", ErrorCode: -32001},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new injector: %v", err)
+	}
+
+	if f := inj.Pick("tools/call", "fs.read"); f != nil {
+		t.Fatalf("expected no fault for unmatched tool, got %s", f)
+	}
+	if f := inj.Pick("ping", ""); f != nil {
+		t.Fatalf("expected tools filter to skip non tools/call methods, got %s", f)
+	}
+	f := inj.Pick("tools/call", "web.search")
+	if f == nil {
+		t.Fatalf("expected fault for matched tool")
+	}
+	if f.Rule != "search-errors" || !f.Replaces() {
+		t.Fatalf("unexpected fault: %s", f)
+	}
+	if f.ErrorMessage != "injected fault" {
+		t.Fatalf("expected default error message, got %q", f.ErrorMessage)
+	}
+}
+
+func TestInjectorSeedIsDeterministic(t *testing.T) {
+	seed := int64(42)
+	prob := 0.5
+	policy := config.FaultPolicy{
+		Seed:  &seed,
+		Rules: []config.FaultRule{{Name: "flaky", Probability: &prob, HTTPStatus: 503}},
+	}
+
+	run := func() []bool {
+		inj, err := New(policy)
+		if err != nil {
+			t.Fatalf("new injector: %v", err)
+		}
+		out := make([]bool, 64)
+		for i := range out {
+			out[i] = inj.Pick("ping", "") != nil
+		}
+		return out
+	}
+
+	a, b := run(), run()
+	hits := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("roll %d differs between seeded runs", i)
+		}
+		if a[i] {
+			hits++
+		}
+	}
+	if hits == 0 || hits == len(a) {
+		t.Fatalf("expected probability 0.5 to fault some but not all requests, got %d/%d", hits, len(a))
+	}
+}
+
+func TestFaultResponseMarksInjection(t *testing.T) {
+	f := &Fault{Rule: "outage", HTTPStatus: http.StatusServiceUnavailable}
+	status, payload := f.Response(json.RawMessage(`7`))
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d", status)
+	}
+	var out struct {
+		ID    int `json:"id"`
+		Error struct {
+			Code int            `json:"code"`
+			Data map[string]any `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if out.ID != 7 || out.Error.Code != -32000 {
+		t.Fatalf("unexpected payload: %s", payload)
+	}
+	if out.Error.Data["injected_fault"] != "outage" {
+		t.Fatalf("expected injected_fault marker, got %s", payload)
+	}
+}
+
+func TestInjectorAppliesPolicyDefaults(t *testing.T) {
+	inj, err := New(config.FaultPolicy{Rules: []config.FaultRule{{ErrorCode: -32001}}})
+	if err != nil {
+		t.Fatalf("new injector: %v", err)
+	}
+	f := inj.Pick("ping", "")
+	if f == nil || f.Rule != "rule-0" || f.Phase != PhaseBefore || f.ErrorMessage != "injected fault" {
+		t.Fatalf("unexpected defaults: %+v", f)
+	}
+}
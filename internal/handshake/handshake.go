@@ -0,0 +1,186 @@
+package handshake
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// Handshake rewrites initialize requests and responses.
+type Handshake struct {
+	versions     []string
+	client       config.CapabilityRewrite
+	server       config.CapabilityRewrite
+	serverInfo   config.ServerInfo
+	instructions string
+}
+
+// New builds the rewriter. It returns nil when the policy changes nothing.
+func New(policy config.InitializePolicy) *Handshake {
+	if len(policy.ProtocolVersions) == 0 && empty(policy.ClientCapabilities) && empty(policy.ServerCapabilities) &&
+		policy.ServerInfo == (config.ServerInfo{}) && policy.Instructions == "" {
+		return nil
+	}
+	return &Handshake{
+		versions:     policy.ProtocolVersions,
+		client:       policy.ClientCapabilities,
+		server:       policy.ServerCapabilities,
+		serverInfo:   policy.ServerInfo,
+		instructions: policy.Instructions,
+	}
+}
+
+func empty(c config.CapabilityRewrite) bool {
+	return len(c.Strip) == 0 && len(c.Add) == 0
+}
+
+// Supported reports whether version is accepted. Any version is accepted
+// when no versions are configured.
+func (h *Handshake) Supported(version string) bool {
+	if h == nil || len(h.versions) == 0 {
+		return true
+	}
+	for _, v := range h.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the version to request upstream for a client asking for
+// version: the version itself when supported, otherwise the preferred one.
+func (h *Handshake) Negotiate(version string) string {
+	if h.Supported(version) {
+		return version
+	}
+	return h.versions[0]
+}
+
+// RewriteRequest negotiates params.protocolVersion and rewrites
+// params.capabilities of an initialize request. changes describes what was
+// rewritten.
+func (h *Handshake) RewriteRequest(params json.RawMessage) (json.RawMessage, []string, error) {
+	if h == nil {
+		return params, nil, nil
+	}
+	obj := map[string]any{}
+	if err := json.Unmarshal(params, &obj); err != nil {
+		return nil, nil, err
+	}
+	var changes []string
+	requested, _ := obj["protocolVersion"].(string)
+	if v := h.Negotiate(requested); v != requested {
+		obj["protocolVersion"] = v
+		changes = append(changes, "protocolVersion "+requested+" -> "+v)
+	}
+	if !empty(h.client) {
+		caps, _ := obj["capabilities"].(map[string]any)
+		obj["capabilities"] = rewriteCapabilities(caps, h.client)
+		changes = append(changes, "client capabilities")
+	}
+	if len(changes) == 0 {
+		return params, nil, nil
+	}
+	out, err := json.Marshal(obj)
+	return out, changes, err
+}
+
+// RewriteResponse rewrites the result of an initialize response: server
+// capabilities, serverInfo, and instructions. A result with an unsupported
+// protocolVersion is replaced by an Unsupported protocol version error.
+// Error responses and unparsable payloads are returned unchanged.
+func (h *Handshake) RewriteResponse(raw json.RawMessage) json.RawMessage {
+	if h == nil {
+		return raw
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw
+	}
+	result := map[string]any{}
+	if err := json.Unmarshal(msg["result"], &result); err != nil {
+		return raw
+	}
+	version, _ := result["protocolVersion"].(string)
+	if !h.Supported(version) {
+		resp := jsonrpc.ErrorResponse(msg["id"], jsonrpc.ErrInvalidParams, "Unsupported protocol version", map[string]any{
+			"supported": h.versions,
+			"upstream":  version,
+		})
+		payload, _ := json.Marshal(resp)
+		return payload
+	}
+
+	if !empty(h.server) {
+		caps, _ := result["capabilities"].(map[string]any)
+		result["capabilities"] = rewriteCapabilities(caps, h.server)
+	}
+	if h.serverInfo != (config.ServerInfo{}) {
+		info, _ := result["serverInfo"].(map[string]any)
+		if info == nil {
+			info = map[string]any{}
+		}
+		if h.serverInfo.Name != "" {
+			info["name"] = h.serverInfo.Name
+		}
+		if h.serverInfo.Version != "" {
+			info["version"] = h.serverInfo.Version
+		}
+		result["serverInfo"] = info
+	}
+	if h.instructions != "" {
+		if existing, _ := result["instructions"].(string); existing != "" {
+			result["instructions"] = existing + "\n\n" + h.instructions
+		} else {
+			result["instructions"] = h.instructions
+		}
+	}
+
+	var err error
+	if msg["result"], err = json.Marshal(result); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return raw
+	}
+	return json.RawMessage(out)
+}
+
+// rewriteCapabilities strips the configured paths from caps and merges in
+// the added capabilities. caps may be nil.
+func rewriteCapabilities(caps map[string]any, rw config.CapabilityRewrite) map[string]any {
+	if caps == nil {
+		caps = map[string]any{}
+	}
+	for _, path := range rw.Strip {
+		strip(caps, strings.Split(path, "."))
+	}
+	merge(caps, rw.Add)
+	return caps
+}
+
+func strip(obj map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(obj, path[0])
+		return
+	}
+	if child, ok := obj[path[0]].(map[string]any); ok {
+		strip(child, path[1:])
+	}
+}
+
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		sv, srcObj := v.(map[string]any)
+		dv, dstObj := dst[k].(map[string]any)
+		if srcObj && dstObj {
+			merge(dv, sv)
+			continue
+		}
+		dst[k] = v
+	}
+}
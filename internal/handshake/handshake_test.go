@@ -0,0 +1,71 @@
+package handshake
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func newTestHandshake() *Handshake {
+	return New(config.InitializePolicy{
+		ProtocolVersions:   []string{"2025-06-18", "2025-03-26"},
+		ClientCapabilities: config.CapabilityRewrite{Strip: []string{"sampling"}},
+		ServerCapabilities: config.CapabilityRewrite{
+			Strip: []string{"resources.subscribe", "experimental"},
+			Add:   map[string]any{"logging": map[string]any{}},
+		},
+		ServerInfo:   config.ServerInfo{Name: "mcp-proxy-gateway"},
+		Instructions: "Calls are audited.",
+	})
+}
+
+func TestRewriteRequest(t *testing.T) {
+	h := newTestHandshake()
+	out, changes, err := h.RewriteRequest(json.RawMessage(`{"protocolVersion":"2024-11-05","capabilities":{"sampling":{},"roots":{}}}`))
+	if err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	var got map[string]any
+	_ = json.Unmarshal(out, &got)
+	want := map[string]any{"protocolVersion": "2025-06-18", "capabilities": map[string]any{"roots": map[string]any{}}}
+	if !reflect.DeepEqual(got, want) || len(changes) != 2 {
+		t.Fatalf("got %v changes=%v", got, changes)
+	}
+}
+
+func TestRewriteResponse(t *testing.T) {
+	h := newTestHandshake()
+	out := h.RewriteResponse(json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-03-26",
+		"capabilities":{"resources":{"subscribe":true,"listChanged":true},"experimental":{"x":{}}},
+		"serverInfo":{"name":"upstream","version":"1.2.3"},"instructions":"Use search first."}}`))
+	var resp struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	wantCaps := map[string]any{"resources": map[string]any{"listChanged": true}, "logging": map[string]any{}}
+	if !reflect.DeepEqual(resp.Result["capabilities"], wantCaps) {
+		t.Fatalf("capabilities=%v", resp.Result["capabilities"])
+	}
+	if !reflect.DeepEqual(resp.Result["serverInfo"], map[string]any{"name": "mcp-proxy-gateway", "version": "1.2.3"}) {
+		t.Fatalf("serverInfo=%v", resp.Result["serverInfo"])
+	}
+	if resp.Result["instructions"] != "Use search first.\n\nCalls are audited." {
+		t.Fatalf("instructions=%q", resp.Result["instructions"])
+	}
+
+	out = h.RewriteResponse(json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05"}}`))
+	if !strings.Contains(string(out), `"code":-32602`) || !strings.Contains(string(out), `"Unsupported protocol version"`) {
+		t.Fatalf("expected unsupported version error, got %s", out)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if New(config.InitializePolicy{}) != nil {
+		t.Fatalf("expected nil handshake for empty policy")
+	}
+}
@@ -0,0 +1,212 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Difference is one structural divergence between two JSON documents.
+// Path uses JSONPath-style notation rooted at "$" (for example
+// "$.result.items[0].url").
+type Difference struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"` // changed, added, removed
+	Baseline any    `json:"baseline,omitempty"`
+	Live     any    `json:"live,omitempty"`
+}
+
+// PathPattern is a compiled ignore path. Segments match object keys or array
+// indexes; "*" matches any single key or index.
+type PathPattern struct {
+	raw      string
+	segments []string
+}
+
+// ParsePath compiles a path such as "$.result.meta.timestamp" or
+// "$.result.content[*].id".
+func ParsePath(raw string) (PathPattern, error) {
+	p := PathPattern{raw: raw}
+	rest := strings.TrimSpace(raw)
+	if !strings.HasPrefix(rest, "$") {
+		return p, fmt.Errorf("path %q must start with $", raw)
+	}
+	rest = rest[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return p, fmt.Errorf("path %q has an empty segment", raw)
+			}
+			p.segments = append(p.segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return p, fmt.Errorf("path %q has an unterminated index", raw)
+			}
+			seg := rest[1:end]
+			if seg != "*" {
+				if _, err := strconv.Atoi(seg); err != nil {
+					return p, fmt.Errorf("path %q has an invalid index %q", raw, seg)
+				}
+			}
+			p.segments = append(p.segments, seg)
+			rest = rest[end+1:]
+		default:
+			return p, fmt.Errorf("path %q is malformed near %q", raw, rest)
+		}
+	}
+	return p, nil
+}
+
+func (p PathPattern) String() string {
+	return p.raw
+}
+
+// Segments returns the path steps below "$": object keys, array indexes in
+// decimal, or "*".
+func (p PathPattern) Segments() []string {
+	return append([]string(nil), p.segments...)
+}
+
+// segment is one step of a concrete document path.
+type segment struct {
+	name  string
+	index bool
+}
+
+// matches reports whether the pattern covers segs or one of its ancestors,
+// so ignoring "$.result.meta" also ignores everything below it.
+func (p PathPattern) matches(segs []segment) bool {
+	if len(segs) < len(p.segments) {
+		return false
+	}
+	for i, want := range p.segments {
+		if want != "*" && want != segs[i].name {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff compares baseline and live JSON documents and returns their
+// differences, skipping any path covered by ignore.
+func Diff(baseline, live json.RawMessage, ignore []PathPattern) ([]Difference, error) {
+	var a, b any
+	if err := json.Unmarshal(baseline, &a); err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	if err := json.Unmarshal(live, &b); err != nil {
+		return nil, fmt.Errorf("live: %w", err)
+	}
+	d := &differ{ignore: ignore}
+	d.walk(nil, a, b)
+	return d.out, nil
+}
+
+type differ struct {
+	ignore []PathPattern
+	out    []Difference
+}
+
+func (d *differ) ignored(segs []segment) bool {
+	for _, p := range d.ignore {
+		if p.matches(segs) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) add(segs []segment, kind string, baseline, live any) {
+	d.out = append(d.out, Difference{Path: formatPath(segs), Kind: kind, Baseline: baseline, Live: live})
+}
+
+func (d *differ) walk(segs []segment, a, b any) {
+	if d.ignored(segs) {
+		return
+	}
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := append(append([]segment(nil), segs...), segment{name: k})
+			if d.ignored(child) {
+				continue
+			}
+			aChild, inA := av[k]
+			bChild, inB := bv[k]
+			switch {
+			case !inB:
+				d.add(child, "removed", aChild, nil)
+			case !inA:
+				d.add(child, "added", nil, bChild)
+			default:
+				d.walk(child, aChild, bChild)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+		for i := 0; i < n; i++ {
+			child := append(append([]segment(nil), segs...), segment{name: strconv.Itoa(i), index: true})
+			if d.ignored(child) {
+				continue
+			}
+			switch {
+			case i >= len(bv):
+				d.add(child, "removed", av[i], nil)
+			case i >= len(av):
+				d.add(child, "added", nil, bv[i])
+			default:
+				d.walk(child, av[i], bv[i])
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		d.add(segs, "changed", a, b)
+	}
+}
+
+func formatPath(segs []segment) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, s := range segs {
+		if s.index {
+			b.WriteString("[" + s.name + "]")
+			continue
+		}
+		b.WriteString("." + s.name)
+	}
+	return b.String()
+}
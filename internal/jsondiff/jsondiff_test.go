@@ -0,0 +1,74 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustPaths(t *testing.T, raws ...string) []PathPattern {
+	t.Helper()
+	var out []PathPattern
+	for _, raw := range raws {
+		p, err := ParsePath(raw)
+		if err != nil {
+			t.Fatalf("parse %q: %v", raw, err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestDiffReportsChangedAddedRemoved(t *testing.T) {
+	baseline := json.RawMessage(`{"id":1,"result":{"items":[{"n":1},{"n":2}],"old":true,"same":"x"}}`)
+	live := json.RawMessage(`{"id":2,"result":{"items":[{"n":1},{"n":3},{"n":4}],"new":1,"same":"x"}}`)
+
+	diffs, err := Diff(baseline, live, mustPaths(t, "$.id"))
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := []struct{ path, kind string }{
+		{"$.result.items[1].n", "changed"},
+		{"$.result.items[2]", "added"},
+		{"$.result.new", "added"},
+		{"$.result.old", "removed"},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs: %+v", len(diffs), diffs)
+	}
+	for i, w := range want {
+		if diffs[i].Path != w.path || diffs[i].Kind != w.kind {
+			t.Fatalf("diff[%d]=%+v want path=%s kind=%s", i, diffs[i], w.path, w.kind)
+		}
+	}
+}
+
+func TestDiffIgnoresWildcardAndSubtreePaths(t *testing.T) {
+	baseline := json.RawMessage(`{"result":{"meta":{"ts":1,"host":"a"},"content":[{"id":"x","text":"hi"},{"id":"y","text":"yo"}]}}`)
+	live := json.RawMessage(`{"result":{"meta":{"ts":2},"content":[{"id":"p","text":"hi"},{"id":"q","text":"yo"}]}}`)
+
+	diffs, err := Diff(baseline, live, mustPaths(t, "$.result.meta", "$.result.content[*].id"))
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs, got %+v", diffs)
+	}
+}
+
+func TestDiffTypeChange(t *testing.T) {
+	diffs, err := Diff(json.RawMessage(`{"a":[1]}`), json.RawMessage(`{"a":{"0":1}}`), nil)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Path != "$.a" || diffs[0].Kind != "changed" {
+		t.Fatalf("unexpected diffs: %+v", diffs)
+	}
+}
+
+func TestParsePathRejectsMalformed(t *testing.T) {
+	for _, raw := range []string{"result.x", "$..x", "$.a[", "$.a[x]", "$a"} {
+		if _, err := ParsePath(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package localtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+const (
+	KindStatic  = "static"
+	KindEcho    = "echo"
+	KindStatus  = "status"
+	KindCommand = "command"
+)
+
+// DefaultTimeout bounds command tools without a timeout.
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput caps the stdout and stderr kept from a command.
+const maxOutput = 1 << 20
+
+// ErrInvalidArguments wraps argument errors of command tools; the call is
+// rejected instead of run.
+var ErrInvalidArguments = errors.New("invalid arguments")
+
+// placeholder matches {{args.<name>}} in command arguments.
+var placeholder = regexp.MustCompile(`\{\{\s*args\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+type tool struct {
+	name        string
+	kind        string
+	description string
+	inputSchema map[string]any
+	result      json.RawMessage
+	command     []string
+	dir         string
+	timeout     time.Duration
+}
+
+// Registry holds the tools answered by the gateway.
+type Registry struct {
+	tools map[string]*tool
+	names []string
+}
+
+// New builds the registry. It returns nil when no local tools are configured.
+func New(policy map[string]config.LocalTool) (*Registry, error) {
+	if len(policy) == 0 {
+		return nil, nil
+	}
+	r := &Registry{tools: map[string]*tool{}}
+	for name, cfg := range policy {
+		t := &tool{name: name, kind: cfg.Kind, description: cfg.Description, inputSchema: cfg.InputSchema, dir: cfg.Dir, timeout: DefaultTimeout}
+		switch cfg.Kind {
+		case KindStatic:
+			data, err := json.Marshal(cfg.Result)
+			if err != nil {
+				return nil, fmt.Errorf("local tool %s: %w", name, err)
+			}
+			t.result = data
+		case KindCommand:
+			props, _ := cfg.InputSchema["properties"].(map[string]any)
+			for _, arg := range cfg.Command {
+				for _, m := range placeholder.FindAllStringSubmatch(arg, -1) {
+					if _, ok := props[m[1]]; !ok {
+						return nil, fmt.Errorf("local tool %s: placeholder %s is not an input_schema property", name, m[0])
+					}
+				}
+			}
+			if placeholder.MatchString(cfg.Command[0]) {
+				return nil, fmt.Errorf("local tool %s: the program name cannot be templated", name)
+			}
+			t.command = cfg.Command
+			if cfg.Timeout != "" {
+				d, err := time.ParseDuration(cfg.Timeout)
+				if err != nil {
+					return nil, fmt.Errorf("local tool %s: %w", name, err)
+				}
+				t.timeout = d
+			}
+		case KindEcho, KindStatus:
+		default:
+			return nil, fmt.Errorf("local tool %s: unknown kind %q", name, cfg.Kind)
+		}
+		r.tools[name] = t
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	return r, nil
+}
+
+// Has reports whether name is a local tool.
+func (r *Registry) Has(name string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Call runs a local tool and returns its tools/call result. status supplies
+// the payload of status tools.
+func (r *Registry) Call(ctx context.Context, name string, args json.RawMessage, status func() map[string]any) (json.RawMessage, error) {
+	t, ok := r.tools[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown local tool %q", name)
+	}
+	switch t.kind {
+	case KindStatic:
+		return t.result, nil
+	case KindEcho:
+		var v any = map[string]any{}
+		if len(args) > 0 && string(args) != "null" {
+			if err := json.Unmarshal(args, &v); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+			}
+		}
+		return structuredResult(v, false)
+	case KindStatus:
+		return structuredResult(status(), false)
+	case KindCommand:
+		return t.run(ctx, args)
+	}
+	return nil, fmt.Errorf("unknown local tool kind %q", t.kind)
+}
+
+// structuredResult returns v as structuredContent with a JSON text block for
+// clients that only read content.
+func structuredResult(v any, isError bool) (json.RawMessage, error) {
+	text, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{
+		"content":           []any{map[string]any{"type": "text", "text": string(text)}},
+		"structuredContent": v,
+	}
+	if isError {
+		result["isError"] = true
+	}
+	return json.Marshal(result)
+}
+
+// argv expands placeholders in the command with scalar argument values.
+func (t *tool) argv(args json.RawMessage) ([]string, error) {
+	values := map[string]any{}
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &values); err != nil {
+			return nil, fmt.Errorf("%w: arguments must be an object", ErrInvalidArguments)
+		}
+	}
+	var expandErr error
+	out := make([]string, len(t.command))
+	for i, arg := range t.command {
+		out[i] = placeholder.ReplaceAllStringFunc(arg, func(m string) string {
+			name := placeholder.FindStringSubmatch(m)[1]
+			v, ok := values[name]
+			if !ok {
+				expandErr = fmt.Errorf("%w: missing argument %q", ErrInvalidArguments, name)
+				return ""
+			}
+			s, err := scalar(v)
+			if err != nil {
+				expandErr = fmt.Errorf("%w: argument %q %v", ErrInvalidArguments, name, err)
+				return ""
+			}
+			return s
+		})
+		if expandErr != nil {
+			return nil, expandErr
+		}
+	}
+	return out, nil
+}
+
+func scalar(v any) (string, error) {
+	var s string
+	switch vv := v.(type) {
+	case string:
+		s = vv
+	case float64:
+		s = strconv.FormatFloat(vv, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(vv)
+	default:
+		return "", errors.New("must be a string, number, or boolean")
+	}
+	if strings.HasPrefix(s, "-") {
+		return "", errors.New(`must not start with "-"`)
+	}
+	if strings.ContainsRune(s, 0) {
+		return "", errors.New("must not contain NUL")
+	}
+	return s, nil
+}
+
+// run executes the command without a shell and with only PATH in the
+// environment. A non-zero exit is reported as an isError result.
+func (t *tool) run(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	argv, err := t.argv(args)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = t.dir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	stdout := &limitedBuffer{max: maxOutput}
+	stderr := &limitedBuffer{max: maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	runErr := cmd.Run()
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			return toolError("command timed out after " + t.timeout.String())
+		case errors.As(runErr, &exitErr):
+			exitCode = exitErr.ExitCode()
+		default:
+			return toolError("command failed: " + runErr.Error())
+		}
+	}
+	result := map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": stdout.String()}},
+		"structuredContent": map[string]any{
+			"exit_code": exitCode,
+			"stdout":    stdout.String(),
+			"stderr":    stderr.String(),
+			"truncated": stdout.truncated || stderr.truncated,
+		},
+	}
+	if exitCode != 0 {
+		result["isError"] = true
+	}
+	return json.Marshal(result)
+}
+
+func toolError(msg string) (json.RawMessage, error) {
+	return json.Marshal(map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": msg}},
+		"isError": true,
+	})
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// Entries returns the tools/list entries of the local tools in name order.
+func (r *Registry) Entries() []map[string]any {
+	if r == nil {
+		return nil
+	}
+	out := make([]map[string]any, 0, len(r.names))
+	for _, name := range r.names {
+		t := r.tools[name]
+		schema := t.inputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		entry := map[string]any{"name": name, "inputSchema": schema}
+		if t.description != "" {
+			entry["description"] = t.description
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Names lists the local tools in name order.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	return append([]string(nil), r.names...)
+}
+
+// MergeList appends the local tools to the result of a tools/list response.
+// Responses without a result.tools array are returned unchanged.
+func (r *Registry) MergeList(raw json.RawMessage) json.RawMessage {
+	if r == nil {
+		return raw
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw
+	}
+	result := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg["result"], &result); err != nil {
+		return raw
+	}
+	var tools []any
+	if err := json.Unmarshal(result["tools"], &tools); err != nil {
+		return raw
+	}
+	for _, e := range r.Entries() {
+		tools = append(tools, e)
+	}
+	var err error
+	if result["tools"], err = json.Marshal(tools); err != nil {
+		return raw
+	}
+	if msg["result"], err = json.Marshal(result); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return raw
+	}
+	return json.RawMessage(out)
+}
@@ -0,0 +1,97 @@
+package localtool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func TestStaticEchoAndStatus(t *testing.T) {
+	r, err := New(map[string]config.LocalTool{
+		"ping":   {Kind: KindStatic, Result: map[string]any{"content": []any{map[string]any{"type": "text", "text": "pong"}}}},
+		"echo":   {Kind: KindEcho},
+		"status": {Kind: KindStatus},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	status := func() map[string]any { return map[string]any{"ok": true} }
+
+	out, err := r.Call(context.Background(), "ping", nil, status)
+	if err != nil || string(out) != `{"content":[{"text":"pong","type":"text"}]}` {
+		t.Fatalf("static: out=%s err=%v", out, err)
+	}
+	out, err = r.Call(context.Background(), "echo", json.RawMessage(`{"a":1}`), status)
+	if err != nil || !strings.Contains(string(out), `"structuredContent":{"a":1}`) {
+		t.Fatalf("echo: out=%s err=%v", out, err)
+	}
+	out, err = r.Call(context.Background(), "status", nil, status)
+	if err != nil || !strings.Contains(string(out), `"structuredContent":{"ok":true}`) {
+		t.Fatalf("status: out=%s err=%v", out, err)
+	}
+	if got := r.Names(); strings.Join(got, ",") != "echo,ping,status" {
+		t.Fatalf("names=%v", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not available")
+	}
+	r, err := New(map[string]config.LocalTool{
+		"say": {
+			Kind:        KindCommand,
+			Command:     []string{"echo", "hello {{args.name}}"},
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	out, err := r.Call(context.Background(), "say", json.RawMessage(`{"name":"world; rm -rf /"}`), nil)
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	var result struct {
+		IsError           bool `json:"isError"`
+		StructuredContent struct {
+			ExitCode int    `json:"exit_code"`
+			Stdout   string `json:"stdout"`
+		} `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if result.IsError || result.StructuredContent.Stdout != "hello world; rm -rf /\n" {
+		t.Fatalf("unexpected result: %s", out)
+	}
+
+	for _, args := range []string{`{"name":"-n"}`, `{"name":{"x":1}}`, `{}`} {
+		if _, err := r.Call(context.Background(), "say", json.RawMessage(args), nil); !errors.Is(err, ErrInvalidArguments) {
+			t.Fatalf("args %s: expected ErrInvalidArguments, got %v", args, err)
+		}
+	}
+}
+
+func TestNewRejectsUndeclaredPlaceholder(t *testing.T) {
+	_, err := New(map[string]config.LocalTool{
+		"bad": {Kind: KindCommand, Command: []string{"echo", "{{args.path}}"}},
+	})
+	if err == nil {
+		t.Fatalf("expected error for placeholder without input_schema property")
+	}
+}
+
+func TestMergeList(t *testing.T) {
+	r, _ := New(map[string]config.LocalTool{"echo": {Kind: KindEcho, Description: "Echo arguments"}})
+	out := r.MergeList(json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"upstream"}]}}`))
+	if !strings.Contains(string(out), `{"name":"upstream"},{"description":"Echo arguments","inputSchema":{"type":"object"},"name":"echo"}`) {
+		t.Fatalf("unexpected merge: %s", out)
+	}
+}
@@ -0,0 +1,238 @@
+package progress
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+// DefaultInterval is the throttle interval when the policy sets none.
+const DefaultInterval = time.Second
+
+const method = "notifications/progress"
+
+// Stats counts progress notifications by outcome and streamed results
+// answered as plain JSON.
+type Stats struct {
+	Forwarded uint64 `json:"forwarded_total"`
+	Throttled uint64 `json:"throttled_total"`
+	Dropped   uint64 `json:"dropped_total"`
+	Invalid   uint64 `json:"invalid_token_total"`
+	Buffered  uint64 `json:"buffered_streams_total"`
+}
+
+// Limiter applies the progress policy to the events of upstream streams.
+type Limiter struct {
+	mode     string
+	interval time.Duration
+	validate bool
+	buffer   bool
+	now      func() time.Time
+
+	forwarded, throttled, dropped, invalid, buffered atomic.Uint64
+}
+
+// New builds a limiter. It returns nil when the policy changes nothing.
+func New(policy config.ProgressPolicy) *Limiter {
+	if (policy.Mode == "" || policy.Mode == "pass") && !policy.ValidateTokens && !policy.BufferStreams {
+		return nil
+	}
+	interval := DefaultInterval
+	if policy.MinInterval != "" {
+		if d, err := time.ParseDuration(policy.MinInterval); err == nil && d > 0 {
+			interval = d
+		}
+	}
+	return &Limiter{
+		mode:     policy.Mode,
+		interval: interval,
+		validate: policy.ValidateTokens,
+		buffer:   policy.BufferStreams,
+		now:      time.Now,
+	}
+}
+
+// BuffersStreams reports whether streamed results are converted to plain
+// JSON for clients that did not ask for SSE.
+func (l *Limiter) BuffersStreams() bool {
+	return l != nil && l.buffer
+}
+
+// CountBuffered counts a streamed result answered as plain JSON.
+func (l *Limiter) CountBuffered() {
+	if l != nil {
+		l.buffered.Add(1)
+	}
+}
+
+// Token returns the progressToken of a request's params._meta, or nil.
+func Token(params json.RawMessage) json.RawMessage {
+	var p struct {
+		Meta struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	return p.Meta.ProgressToken
+}
+
+// Stream holds the throttle state of one request's stream.
+type Stream struct {
+	l     *Limiter
+	token string
+
+	mu      sync.Mutex
+	last    time.Time
+	held    string
+	timer   *time.Timer
+	onTimer func()
+}
+
+// Stream starts filtering the stream of a request that carried token (nil
+// when the request asked for no progress).
+func (l *Limiter) Stream(token json.RawMessage) *Stream {
+	if l == nil {
+		return nil
+	}
+	return &Stream{l: l, token: compact(token)}
+}
+
+// OnExpire registers fn to be called when the interval of a held
+// notification expires; fn should send what Expired returns.
+func (s *Stream) OnExpire(fn func()) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.onTimer = fn
+	s.mu.Unlock()
+}
+
+// Filter returns the event data to forward in place of data, in order. Only
+// notifications/progress are ever held back: notifications for another
+// token, when validated, and in drop mode all of them. In throttle mode at
+// most one per interval passes; the latest one suppressed is held and sent
+// when the interval expires (see OnExpire), before the next event after
+// that, before any response, or by Flush at the end of the stream, so the
+// final progress is not lost.
+func (s *Stream) Filter(data string) []string {
+	if s == nil {
+		return []string{data}
+	}
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg.Method != method || len(msg.ID) > 0 {
+		response := err == nil && msg.Method == "" && len(msg.ID) > 0
+		return append(s.release(response), data)
+	}
+	l := s.l
+	if l.validate && (s.token == "" || compact(msg.Params.ProgressToken) != s.token) {
+		l.invalid.Add(1)
+		return s.release(false)
+	}
+	switch l.mode {
+	case "drop":
+		l.dropped.Add(1)
+		return nil
+	case "throttle":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := l.now()
+		if s.last.IsZero() || now.Sub(s.last) >= l.interval {
+			s.last = now
+			if s.held != "" {
+				// Superseded by this newer notification.
+				s.stopTimer()
+				s.held = ""
+				l.throttled.Add(1)
+			}
+		} else {
+			if s.held != "" {
+				l.throttled.Add(1)
+			}
+			s.held = data
+			if s.timer == nil && s.onTimer != nil {
+				s.timer = time.AfterFunc(s.last.Add(l.interval).Sub(now), s.onTimer)
+			}
+			return nil
+		}
+	}
+	l.forwarded.Add(1)
+	return []string{data}
+}
+
+// Expired returns the held notification when its timer has fired.
+func (s *Stream) Expired() []string {
+	return s.release(true)
+}
+
+// Flush returns the held notification at the end of the stream and stops
+// the expiry timer.
+func (s *Stream) Flush() []string {
+	out := s.release(true)
+	if s != nil {
+		s.mu.Lock()
+		s.onTimer = nil
+		s.mu.Unlock()
+	}
+	return out
+}
+
+// release returns the held notification when force is set or the interval
+// since the last forwarded one has expired.
+func (s *Stream) release(force bool) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held == "" {
+		return nil
+	}
+	now := s.l.now()
+	if !force && now.Sub(s.last) < s.l.interval {
+		return nil
+	}
+	s.stopTimer()
+	held := s.held
+	s.held, s.last = "", now
+	s.l.forwarded.Add(1)
+	return []string{held}
+}
+
+func (s *Stream) stopTimer() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// Stats returns the limiter counters.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Forwarded: l.forwarded.Load(),
+		Throttled: l.throttled.Load(),
+		Dropped:   l.dropped.Load(),
+		Invalid:   l.invalid.Load(),
+		Buffered:  l.buffered.Load(),
+	}
+}
+
+func compact(raw json.RawMessage) string {
+	var v any
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil || v == nil {
+		return ""
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
@@ -0,0 +1,98 @@
+package progress
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func notification(token string, n int) string {
+	return `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":` + token + `,"progress":` + strconv.Itoa(n) + `}}`
+}
+
+func TestNewReturnsNilForPassthrough(t *testing.T) {
+	if New(config.ProgressPolicy{}) != nil || New(config.ProgressPolicy{Mode: "pass"}) != nil {
+		t.Fatalf("expected nil limiter for an empty policy")
+	}
+	var l *Limiter
+	if got := l.Stream(nil).Filter(notification(`"t"`, 1)); len(got) != 1 || l.BuffersStreams() {
+		t.Fatalf("nil limiter must pass everything")
+	}
+}
+
+func TestThrottleHoldsLatestSuppressedNotification(t *testing.T) {
+	l := New(config.ProgressPolicy{Mode: "throttle", MinInterval: "100ms"})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	s := l.Stream(json.RawMessage(`"t"`))
+
+	if got := s.Filter(notification(`"t"`, 1)); len(got) != 1 {
+		t.Fatalf("first notification must pass: %q", got)
+	}
+	if got := append(s.Filter(notification(`"t"`, 2)), s.Filter(notification(`"t"`, 3))...); len(got) != 0 {
+		t.Fatalf("notifications within the interval must be held: %q", got)
+	}
+	if got := s.Filter("not json"); len(got) != 1 || got[0] != "not json" {
+		t.Fatalf("other events pass without releasing before the interval: %q", got)
+	}
+	result := `{"jsonrpc":"2.0","id":1,"result":{}}`
+	if got := s.Filter(result); len(got) != 2 || got[0] != notification(`"t"`, 3) || got[1] != result {
+		t.Fatalf("latest held notification must precede the response: %q", got)
+	}
+	if st := l.Stats(); st.Forwarded != 2 || st.Throttled != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+
+	_ = s.Filter(notification(`"t"`, 4))
+	now = now.Add(100 * time.Millisecond)
+	if got := s.Filter("not json"); len(got) != 2 || got[0] != notification(`"t"`, 4) {
+		t.Fatalf("held notification must be released once the interval expired: %q", got)
+	}
+	_ = s.Filter(notification(`"t"`, 5))
+	if got := s.Flush(); len(got) != 1 || got[0] != notification(`"t"`, 5) {
+		t.Fatalf("flush must release the held notification: %q", got)
+	}
+}
+
+func TestThrottleTimerReleasesHeldNotification(t *testing.T) {
+	l := New(config.ProgressPolicy{Mode: "throttle", MinInterval: "10ms"})
+	s := l.Stream(nil)
+	fired := make(chan []string, 1)
+	s.OnExpire(func() { fired <- s.Expired() })
+
+	_ = s.Filter(notification(`"t"`, 1))
+	_ = s.Filter(notification(`"t"`, 2))
+	select {
+	case got := <-fired:
+		if len(got) != 1 || got[0] != notification(`"t"`, 2) {
+			t.Fatalf("unexpected expired notifications: %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("held notification not released by the timer")
+	}
+}
+
+func TestDropAndTokenValidation(t *testing.T) {
+	l := New(config.ProgressPolicy{ValidateTokens: true})
+	s := l.Stream(Token(json.RawMessage(`{"name":"slow","_meta":{"progressToken":7}}`)))
+	if len(s.Filter(notification(`7`, 1))) != 1 || len(s.Filter(notification(`8`, 1))) != 0 {
+		t.Fatalf("expected only the request's token to pass")
+	}
+	if len(l.Stream(nil).Filter(notification(`7`, 1))) != 0 {
+		t.Fatalf("progress for a request without a token must be dropped")
+	}
+
+	drop := New(config.ProgressPolicy{Mode: "drop"})
+	if len(drop.Stream(nil).Filter(notification(`7`, 1))) != 0 {
+		t.Fatalf("drop mode must drop progress")
+	}
+	if st := l.Stats(); st.Invalid != 2 || st.Forwarded != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if st := drop.Stats(); st.Dropped != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
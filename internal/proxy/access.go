@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// checkAccess enforces the resource and prompt policies on resources/read,
+// resources/subscribe, and prompts/get. It returns the JSON-RPC error for a
+// rejected request, or nil when the request may proceed.
+func (s *Server) checkAccess(req *jsonrpc.Request) *jsonrpc.ErrorObject {
+	if s.validator == nil {
+		return nil
+	}
+	switch req.Method {
+	case "resources/read", "resources/subscribe":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+			s.metrics.incValidationReject()
+			return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "invalid " + req.Method + " params"}
+		}
+		decision := s.validator.ValidateResource(params.URI)
+		if len(decision.Violations) > 0 && decision.Allowed {
+			s.logger.Printf("validation audit: resource=%s violations=%v", params.URI, decision.Violations)
+		}
+		if !decision.Allowed {
+			s.metrics.incValidationReject()
+			return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "resource access rejected", Data: decision.Violations}
+		}
+	case "prompts/get":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			s.metrics.incValidationReject()
+			return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "invalid prompts/get params"}
+		}
+		decision, err := s.validator.ValidatePrompt(params.Name, params.Arguments)
+		if err != nil {
+			return &jsonrpc.ErrorObject{Code: jsonrpc.ErrServer, Message: "validation error"}
+		}
+		if len(decision.Violations) > 0 && decision.Allowed {
+			s.logger.Printf("validation audit: prompt=%s violations=%v", params.Name, decision.Violations)
+		}
+		if !decision.Allowed {
+			s.metrics.incValidationReject()
+			return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "prompt request rejected", Data: decision.Violations}
+		}
+	}
+	return nil
+}
+
+// catalogFilterActive reports whether list results of req are filtered by
+// the resource and prompt policies.
+func (s *Server) catalogFilterActive(req *jsonrpc.Request) bool {
+	if req == nil || !s.validator.FiltersLists() {
+		return false
+	}
+	switch req.Method {
+	case "resources/list", "resources/templates/list", "prompts/list":
+		return true
+	}
+	return false
+}
+
+// filterCatalog drops the resources, resource templates, and prompts the
+// policies would reject from list results.
+func (s *Server) filterCatalog(req *jsonrpc.Request, raw json.RawMessage) json.RawMessage {
+	if !s.catalogFilterActive(req) {
+		return raw
+	}
+	var key, field string
+	var visible func(string) bool
+	switch req.Method {
+	case "resources/list":
+		key, field, visible = "resources", "uri", s.validator.ResourceVisible
+	case "resources/templates/list":
+		key, field, visible = "resourceTemplates", "uriTemplate", s.validator.ResourceTemplateVisible
+	default:
+		key, field, visible = "prompts", "name", s.validator.PromptVisible
+	}
+
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw
+	}
+	result := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg["result"], &result); err != nil {
+		return raw
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(result[key], &entries); err != nil {
+		return raw
+	}
+	kept := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		fields := map[string]json.RawMessage{}
+		var value string
+		if err := json.Unmarshal(entry, &fields); err == nil {
+			_ = json.Unmarshal(fields[field], &value)
+		}
+		if visible(value) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) {
+		return raw
+	}
+	var err error
+	if result[key], err = json.Marshal(kept); err != nil {
+		return raw
+	}
+	if msg["result"], err = json.Marshal(result); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return raw
+	}
+	return json.RawMessage(out)
+}
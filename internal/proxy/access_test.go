@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+func TestResourceAndPromptPolicies(t *testing.T) {
+	var upstreamBodies []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBodies = append(upstreamBodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(string(body), `"resources/list"`):
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":3,"result":{"resources":[{"uri":"file:///repo/a.go","name":"a"},{"uri":"file:///etc/passwd","name":"passwd"}]}}`)
+		case strings.Contains(string(body), `"prompts/list"`):
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":4,"result":{"prompts":[{"name":"review"},{"name":"jailbreak"}]}}`)
+		default:
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"contents":[]}}`)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	validator, err := validate.New(&config.Policy{
+		Mode:      "enforce",
+		Resources: config.ResourcePolicy{Allow: []config.URIRule{{Prefix: "file:///repo/"}}},
+		Prompts:   config.PromptPolicy{Deny: []string{"jailbreak"}},
+	})
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	srv := NewServer(mustParseURL(t, upstream.URL), validator, nil, nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"file:///etc/passwd"}}`)))
+	if got := w.Body.String(); !strings.Contains(got, `"resource access rejected"`) {
+		t.Fatalf("expected rejected resource read, got=%s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[
+		{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"file:///repo/a.go"}},
+		{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"jailbreak"}}]`)))
+	if got := w.Body.String(); !strings.Contains(got, `"contents":[]`) || !strings.Contains(got, `"prompt request rejected"`) {
+		t.Fatalf("unexpected batch response: %s", got)
+	}
+	if len(upstreamBodies) != 1 {
+		t.Fatalf("rejected requests must not reach upstream, got=%v", upstreamBodies)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)))
+	if got := w.Body.String(); !strings.Contains(got, "a.go") || strings.Contains(got, "passwd") {
+		t.Fatalf("expected filtered resources/list, got=%s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":4,"method":"prompts/list"}`)))
+	if got := w.Body.String(); !strings.Contains(got, "review") || strings.Contains(got, "jailbreak") {
+		t.Fatalf("expected filtered prompts/list, got=%s", got)
+	}
+}
+
+func TestAccessPoliciesApplyToReplay(t *testing.T) {
+	read := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"file:///etc/passwd"}}`)
+	prompt := json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"jailbreak"}}`)
+	store := mustReplayStoreMatch(t, record.ReplayMatchSignature, []replayPair{
+		{req: read, resp: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"contents":[{"text":"root:x:0:0"}]}}`)},
+		{req: prompt, resp: json.RawMessage(`{"jsonrpc":"2.0","id":2,"result":{"messages":[]}}`)},
+	})
+	validator, err := validate.New(&config.Policy{
+		Mode:      "enforce",
+		Resources: config.ResourcePolicy{Deny: []config.URIRule{{Prefix: "file:///etc/"}}},
+		Prompts:   config.PromptPolicy{Deny: []string{"jailbreak"}},
+	})
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	srv := NewServer(nil, validator, nil, store, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(read)))
+	if got := w.Body.String(); !strings.Contains(got, `"resource access rejected"`) || strings.Contains(got, "root:x") {
+		t.Fatalf("denied resource served from replay: %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader([]byte("["+string(read)+","+string(prompt)+"]"))))
+	if got := w.Body.String(); strings.Count(got, "rejected") != 2 || strings.Contains(got, "root:x") {
+		t.Fatalf("denied batch items served from replay: %s", got)
+	}
+}
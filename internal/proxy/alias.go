@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// SetAliases enables client-visible tool aliases. A nil table disables them.
+func (s *Server) SetAliases(a *alias.Aliases) {
+	s.aliases = a
+}
+
+// aliasBody rewrites a tools/call body addressed to an alias so it calls the
+// upstream tool.
+func (s *Server) aliasBody(req *jsonrpc.Request, body []byte) ([]byte, error) {
+	if s.aliases == nil || req.Method != "tools/call" {
+		return body, nil
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	params, ok, err := s.aliases.RewriteCall(msg["params"])
+	if err != nil || !ok {
+		return body, err
+	}
+	msg["params"] = params
+	return json.Marshal(msg)
+}
+
+// checkAliasCall rejects tools/call requests that name a hidden upstream
+// tool directly, bypassing its aliases, and validates calls rewritten by an
+// alias against the schema of the tool they resolve to, so an alias cannot
+// skip the input_schema of a local tool or a tools entry for the target. It
+// returns the JSON-RPC error for a rejected call, or nil.
+func (s *Server) checkAliasCall(req *jsonrpc.Request, forward []byte) *jsonrpc.ErrorObject {
+	if s.aliases == nil || req.Method != "tools/call" {
+		return nil
+	}
+	tool, _, err := parseToolCall(req.Params)
+	if err != nil {
+		return nil
+	}
+	if s.aliases.Hidden(tool) {
+		s.metrics.incValidationReject()
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "tool call rejected", Data: []string{"tool is only available through its aliases"}}
+	}
+	if s.validator == nil {
+		return nil
+	}
+	var call jsonrpc.Request
+	if err := json.Unmarshal(forward, &call); err != nil {
+		return nil
+	}
+	target, args, err := parseToolCall(call.Params)
+	if err != nil {
+		return nil
+	}
+	if tool == target {
+		return nil
+	}
+	decision, err := s.validator.ValidateTarget(target, args)
+	if err != nil {
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrServer, Message: "validation error"}
+	}
+	if len(decision.Violations) > 0 && decision.Allowed {
+		s.logger.Printf("validation audit: tool=%s violations=%v", target, decision.Violations)
+	}
+	if !decision.Allowed {
+		s.metrics.incValidationReject()
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "tool call rejected", Data: decision.Violations}
+	}
+	return nil
+}
+
+// rewriteToolList replaces aliased upstream tools in tools/list results.
+func (s *Server) rewriteToolList(req *jsonrpc.Request, raw json.RawMessage) json.RawMessage {
+	if s.aliases == nil || req == nil || req.Method != "tools/list" {
+		return raw
+	}
+	return s.aliases.RewriteList(raw)
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func TestAliasesRewriteCallsListsAndRecordClientName(t *testing.T) {
+	var upstreamBodies []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBodies = append(upstreamBodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(string(body), `"tools/list"`) {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"filesystem_read_file","inputSchema":{"type":"object"}}]}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	a, err := alias.New(map[string]config.ToolAlias{"fs.read": {Tool: "filesystem_read_file"}})
+	if err != nil {
+		t.Fatalf("aliases: %v", err)
+	}
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetAliases(a)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"fs.read","arguments":{"path":"a"}}}`)))
+	if len(upstreamBodies) != 1 || !strings.Contains(upstreamBodies[0], `"tool":"filesystem_read_file"`) {
+		t.Fatalf("expected upstream call to the aliased tool, got=%v", upstreamBodies)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","id":2,"method":"tools/list"}]`)))
+	if got := w.Body.String(); !strings.Contains(got, `"name":"fs.read"`) || strings.Contains(got, "filesystem_read_file") {
+		t.Fatalf("expected aliased tools/list, got=%s", got)
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	var entry record.Entry
+	if err := json.Unmarshal([]byte(strings.Split(string(data), "\n")[0]), &entry); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !strings.Contains(string(entry.Request), `"tool":"fs.read"`) || !strings.Contains(string(entry.Forwarded), `"tool":"filesystem_read_file"`) {
+		t.Fatalf("recording should use the client-visible name, got request=%s forwarded=%s", entry.Request, entry.Forwarded)
+	}
+}
+
+func TestAliasesRejectHiddenUpstreamName(t *testing.T) {
+	hits := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	a, err := alias.New(map[string]config.ToolAlias{
+		"fs.read": {Tool: "filesystem_read_file"},
+		"search":  {Tool: "web_search", KeepUpstream: true},
+	})
+	if err != nil {
+		t.Fatalf("aliases: %v", err)
+	}
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetAliases(a)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"filesystem_read_file","arguments":{"path":"a"}}}`)))
+	if got := w.Body.String(); !strings.Contains(got, "tool is only available through its aliases") {
+		t.Fatalf("expected hidden tool rejection, got=%s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"filesystem_read_file","arguments":{}}},{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"web_search","arguments":{}}}]`)))
+	if got := w.Body.String(); !strings.Contains(got, "tool is only available through its aliases") || !strings.Contains(got, `"result"`) {
+		t.Fatalf("expected hidden tool rejection and kept upstream call, got=%s", got)
+	}
+	if hits != 1 {
+		t.Fatalf("expected only the kept upstream tool to reach upstream, hits=%d", hits)
+	}
+}
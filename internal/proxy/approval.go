@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/approval"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+// approvalsPath is the admin API for pending approvals:
+//
+//	GET  /admin/approvals              list pending calls
+//	POST /admin/approvals/{id}/approve resume the call
+//	POST /admin/approvals/{id}/deny    fail the call; optional {"reason": "..."}
+const approvalsPath = "/admin/approvals"
+
+// SetApprovals enables human approval of tools/call requests matched by the
+// queue's rules. A nil queue disables it.
+func (s *Server) SetApprovals(q *approval.Queue) {
+	s.approvals = q
+}
+
+// awaitApproval parks a tools/call request that requires approval until it
+// is decided. Rules and approvers see forward, the call as it will run: the
+// resolved tool name and the arguments after PII masking, transforms, and
+// alias fixed arguments. It returns nil when no approval is required.
+func (s *Server) awaitApproval(r *http.Request, req *jsonrpc.Request, forward []byte) *record.Approval {
+	if s.approvals == nil || req.Method != "tools/call" {
+		return nil
+	}
+	var call jsonrpc.Request
+	if err := json.Unmarshal(forward, &call); err != nil {
+		return nil
+	}
+	tool, args, err := parseToolCall(call.Params)
+	if err != nil {
+		return nil
+	}
+	return s.approvals.Await(r.Context(), tool, args)
+}
+
+// approvalError is the JSON-RPC error returned for a call that was not
+// approved.
+func approvalError(id json.RawMessage, a *record.Approval) json.RawMessage {
+	msg := "tool call denied"
+	if a.Outcome == record.ApprovalTimedOut {
+		msg = "tool call approval timed out"
+	} else if a.Outcome == record.ApprovalCanceled {
+		msg = "tool call approval canceled"
+	}
+	payload, _ := json.Marshal(jsonrpc.ErrorResponse(id, jsonrpc.ErrServer, msg, a))
+	return payload
+}
+
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if !s.approvals.Authorized(r.Header.Get("Authorization")) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, approvalsPath), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload, _ := json.Marshal(map[string]any{"pending": s.approvals.Pending()})
+		s.writeRawJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	id, verb, ok := strings.Cut(rest, "/")
+	if !ok || (verb != "approve" && verb != "deny") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, s.maxBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := s.approvals.Resolve(id, verb == "approve", body.Reason); err != nil {
+		if errors.Is(err, approval.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	outcome := record.ApprovalDenied
+	if verb == "approve" {
+		outcome = record.ApprovalApproved
+	}
+	payload, _ := json.Marshal(map[string]string{"id": id, "outcome": outcome})
+	s.writeRawJSON(w, http.StatusOK, payload)
+}
+
+func (s *Server) writeApprovalProm(buf *bytes.Buffer) {
+	st := s.approvals.Stats()
+	buf.WriteString("# HELP mcp_proxy_gateway_approvals_pending Tool calls currently waiting for approval.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_approvals_pending gauge\n")
+	buf.WriteString("mcp_proxy_gateway_approvals_pending ")
+	buf.WriteString(formatUint(uint64(st.Pending)))
+	buf.WriteString("\n")
+
+	writeLabeledCounters(buf, "mcp_proxy_gateway_approvals_total", "Resolved approval requests by outcome.", "outcome", map[string]uint64{
+		record.ApprovalApproved: st.ApprovedTotal,
+		record.ApprovalDenied:   st.DeniedTotal,
+		record.ApprovalTimedOut: st.TimedOutTotal,
+		record.ApprovalCanceled: st.CanceledTotal,
+	})
+}
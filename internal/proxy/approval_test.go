@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/approval"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func TestApprovalParksCallsUntilResolvedViaAdminAPI(t *testing.T) {
+	var upstreamCalls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		upstreamCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"deleted":true}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	t.Setenv("APPROVAL_TOKEN", "s3cret")
+	q, err := approval.New(config.ApprovalPolicy{AdminTokenEnv: "APPROVAL_TOKEN", Rules: []config.ApprovalRule{{Name: "destructive", Tools: []string{"files.delete"}}}}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("new queue: %v", err)
+	}
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetApprovals(q)
+
+	call := func() <-chan string {
+		out := make(chan string, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"files.delete","arguments":{"path":"/tmp/x"}}}`)))
+			out <- w.Body.String()
+		}()
+		return out
+	}
+	pendingID := func() string {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin/approvals", nil)
+			req.Header.Set("Authorization", "Bearer s3cret")
+			srv.ServeHTTP(w, req)
+			var list struct {
+				Pending []approval.Pending `json:"pending"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+				t.Fatalf("list: %v body=%s", err, w.Body.String())
+			}
+			if len(list.Pending) == 1 {
+				return list.Pending[0].ID
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("call was not queued")
+		return ""
+	}
+	resolve := func(id, verb, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/approvals/"+id+"/"+verb, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	done := call()
+	id := pendingID()
+	if upstreamCalls != 0 {
+		t.Fatal("pending call reached upstream")
+	}
+	// A client on the MCP listener cannot approve its own call.
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/approvals/"+id+"/approve", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated approve status=%d want 401", w.Code)
+	}
+	if code := resolve(id, "approve", ""); code != http.StatusOK {
+		t.Fatalf("approve status=%d", code)
+	}
+	if got := <-done; !strings.Contains(got, `"deleted":true`) {
+		t.Fatalf("expected upstream result, got=%s", got)
+	}
+
+	done = call()
+	id = pendingID()
+	if code := resolve(id, "deny", `{"reason":"wrong path"}`); code != http.StatusOK {
+		t.Fatalf("deny status=%d", code)
+	}
+	if got := <-done; !strings.Contains(got, "tool call denied") || !strings.Contains(got, "wrong path") {
+		t.Fatalf("expected denial, got=%s", got)
+	}
+	if upstreamCalls != 1 {
+		t.Fatalf("denied call reached upstream: calls=%d", upstreamCalls)
+	}
+	if code := resolve(id, "approve", ""); code != http.StatusNotFound {
+		t.Fatalf("resolving a decided call status=%d want 404", code)
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(lines))
+	}
+	for i, want := range []string{record.ApprovalApproved, record.ApprovalDenied} {
+		var entry record.Entry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if entry.Approval == nil || entry.Approval.Outcome != want || entry.Approval.Rule != "destructive" {
+			t.Fatalf("entry %d approval=%+v want %s", i, entry.Approval, want)
+		}
+	}
+
+	stats, _ := readMetrics(t, srv)["approvals"].(map[string]any)
+	if got := metricValue(t, stats, "denied_total"); got != 1 {
+		t.Fatalf("denied_total=%d want=1", got)
+	}
+}
+
+func TestApprovalSeesForwardedCall(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	t.Setenv("APPROVAL_TOKEN", "s3cret")
+	q, err := approval.New(config.ApprovalPolicy{
+		AdminTokenEnv: "APPROVAL_TOKEN",
+		Timeout:       "50ms",
+		Rules: []config.ApprovalRule{{
+			Name:      "prod",
+			Tools:     []string{"deploy"},
+			Arguments: map[string]string{"$.params.arguments.env": "^prod$"},
+		}},
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("new queue: %v", err)
+	}
+	a, err := alias.New(map[string]config.ToolAlias{"ship": {Tool: "deploy", Arguments: map[string]any{"env": "prod"}}})
+	if err != nil {
+		t.Fatalf("new aliases: %v", err)
+	}
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetApprovals(q)
+	srv.SetAliases(a)
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"ship","arguments":{}}}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"ship","arguments":{"env":"dev"}}}]`,
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		if !strings.Contains(w.Body.String(), "tool call approval timed out") {
+			t.Fatalf("alias fixed argument bypassed approval: %s", w.Body.String())
+		}
+	}
+}
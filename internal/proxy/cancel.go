@@ -0,0 +1,177 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// Cancellation outcomes reported in metrics.
+const (
+	cancelForwarded  = "forwarded"
+	cancelIgnored    = "ignored"
+	cancelDisconnect = "disconnect"
+	cancelUpstream   = "upstream"
+)
+
+// cancelTimeout bounds the upstream POST announcing a disconnect.
+const cancelTimeout = 5 * time.Second
+
+// inflightKey identifies a forwarded request: client request ids are only
+// unique within the client's session, or its connection and credentials
+// when it has no session.
+type inflightKey struct {
+	session string
+	id      string
+}
+
+type inflightCall struct {
+	// cancelled is set once a notifications/cancelled went upstream, so a
+	// later disconnect does not announce it twice.
+	cancelled bool
+}
+
+// inflightTracker holds the requests waiting on the upstream. The zero value
+// is ready to use.
+type inflightTracker struct {
+	mu    sync.Mutex
+	calls map[inflightKey]*inflightCall
+}
+
+func (t *inflightTracker) add(key inflightKey) *inflightCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.calls == nil {
+		t.calls = map[inflightKey]*inflightCall{}
+	}
+	call := &inflightCall{}
+	t.calls[key] = call
+	return call
+}
+
+func (t *inflightTracker) remove(key inflightKey, call *inflightCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.calls[key] == call {
+		delete(t.calls, key)
+	}
+}
+
+// markCancelled flags the call as cancelled and reports whether it was in
+// flight and not cancelled before.
+func (t *inflightTracker) markCancelled(key inflightKey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	call, ok := t.calls[key]
+	if !ok || call.cancelled {
+		return false
+	}
+	call.cancelled = true
+	return true
+}
+
+func (t *inflightTracker) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.calls)
+}
+
+func inflightKeyFor(r *http.Request, id json.RawMessage) inflightKey {
+	return inflightKey{session: clientIdentity(r), id: compactID(id)}
+}
+
+// clientIdentity scopes request ids to a client: its session id, otherwise
+// its connection and a digest of its Authorization header. Sessionless
+// clients can only cancel requests sent on the same connection.
+func clientIdentity(r *http.Request) string {
+	if session := r.Header.Get(sessionHeader); session != "" {
+		return "session:" + session
+	}
+	auth := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return "conn:" + r.RemoteAddr + ":" + hex.EncodeToString(auth[:8])
+}
+
+func compactID(id json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// trackInflight registers a request forwarded upstream. Until the returned
+// stop is called, a client disconnect is announced to the upstream with
+// notifications/cancelled. Notifications and initialize, which must not be
+// cancelled, are not tracked.
+func (s *Server) trackInflight(r *http.Request, req *jsonrpc.Request) (stop func()) {
+	if isNotification(req) || req.Method == "initialize" || s.upstream == nil {
+		return func() {}
+	}
+	key := inflightKeyFor(r, req.ID)
+	call := s.inflight.add(key)
+	header := s.clientRouteHeader(r)
+	id := req.ID
+	ctx := r.Context()
+	stopAfter := context.AfterFunc(ctx, func() {
+		if !s.inflight.markCancelled(key) {
+			return
+		}
+		s.metrics.incCancel(cancelDisconnect)
+		payload, err := json.Marshal(cancelledNotification(id, "client disconnected"))
+		if err != nil {
+			return
+		}
+		// The client context is done; keep its values but not its cancellation.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+		defer cancel()
+		if err := s.postUpstream(ctx, header, payload); err != nil {
+			s.metrics.incUpstreamError()
+			s.logger.Printf("cancellation of request %s not delivered: %v", id, err)
+		}
+	})
+	return func() {
+		stopAfter()
+		s.inflight.remove(key, call)
+	}
+}
+
+// checkCancellation decides whether a client's notifications/cancelled is
+// forwarded. Only cancellations of requests in flight for the same client
+// (see clientIdentity) reach the upstream; the rest are dropped, as the spec allows for unknown or
+// completed requests.
+func (s *Server) checkCancellation(r *http.Request, req *jsonrpc.Request) bool {
+	if req.Method != "notifications/cancelled" || !isNotification(req) {
+		return true
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params.RequestID) == 0 {
+		s.metrics.incCancel(cancelIgnored)
+		return false
+	}
+	if !s.inflight.markCancelled(inflightKeyFor(r, params.RequestID)) {
+		s.metrics.incCancel(cancelIgnored)
+		return false
+	}
+	s.metrics.incCancel(cancelForwarded)
+	return true
+}
+
+func cancelledNotification(id json.RawMessage, reason string) map[string]any {
+	return map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params": map[string]any{
+			"requestId": id,
+			"reason":    reason,
+		},
+	}
+}
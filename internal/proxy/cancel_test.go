@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingUpstream holds tools/call requests until release is closed and
+// reports every cancellation it receives.
+func blockingUpstream(t *testing.T) (*httptest.Server, chan string, chan struct{}) {
+	t.Helper()
+	cancels := make(chan string, 4)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "notifications/cancelled") {
+			cancels <- string(body) + " session=" + r.Header.Get("Mcp-Session-Id")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`))
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream, cancels, release
+}
+
+func waitInflight(t *testing.T, srv *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for srv.inflight.len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d requests in flight, got %d", n, srv.inflight.len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClientCancellationForwardedOnlyForInflightRequests(t *testing.T) {
+	upstream, cancels, release := blockingUpstream(t)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"slow"}}`))
+		req.Header.Set("Mcp-Session-Id", "a")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	waitInflight(t, srv, 1)
+
+	cancel := func(session, id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":`+id+`}}`))
+		req.Header.Set("Mcp-Session-Id", session)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := cancel("b", "1"); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	if code := cancel("a", "99"); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	select {
+	case body := <-cancels:
+		t.Fatalf("cancellation of unknown request forwarded: %s", body)
+	default:
+	}
+
+	if code := cancel("a", "1"); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	select {
+	case body := <-cancels:
+		if !strings.Contains(body, `"requestId":1`) {
+			t.Fatalf("unexpected cancellation upstream: %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("cancellation not forwarded")
+	}
+	close(release)
+	<-done
+	waitInflight(t, srv, 0)
+
+	counts, _ := readMetrics(t, srv)["cancellations_total"].(map[string]any)
+	if counts["forwarded"] != float64(1) || counts["ignored"] != float64(2) || counts["disconnect"] != float64(0) {
+		t.Fatalf("unexpected cancellation metrics: %v", counts)
+	}
+}
+
+func TestClientDisconnectCancelsUpstreamRequest(t *testing.T) {
+	upstream, cancels, _ := blockingUpstream(t)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":"call-7","method":"tools/call","params":{"tool":"slow"}}`)).WithContext(ctx)
+		req.Header.Set("Mcp-Session-Id", "a")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	waitInflight(t, srv, 1)
+	disconnect()
+	<-done
+
+	select {
+	case body := <-cancels:
+		if !strings.Contains(body, `"requestId":"call-7"`) || !strings.Contains(body, "client disconnected") || !strings.HasSuffix(body, "session=a") {
+			t.Fatalf("unexpected cancellation upstream: %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("disconnect not announced upstream")
+	}
+	waitInflight(t, srv, 0)
+
+	counts, _ := readMetrics(t, srv)["cancellations_total"].(map[string]any)
+	if counts["disconnect"] != float64(1) {
+		t.Fatalf("unexpected cancellation metrics: %v", counts)
+	}
+}
+
+func TestCompletedRequestIsNotCancelled(t *testing.T) {
+	upstream, cancels, release := blockingUpstream(t)
+	close(release)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"fast"}}`)).WithContext(ctx)
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+	disconnect()
+
+	select {
+	case body := <-cancels:
+		t.Fatalf("completed request cancelled: %s", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSessionlessClientsCannotCancelEachOther(t *testing.T) {
+	upstream, cancels, release := blockingUpstream(t)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"slow"}}`))
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set("Authorization", "Bearer alice")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	waitInflight(t, srv, 1)
+
+	cancel := func(addr, auth string) {
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`))
+		req.RemoteAddr = addr
+		req.Header.Set("Authorization", auth)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	cancel("10.0.0.2:6000", "Bearer alice")
+	cancel("10.0.0.1:5000", "Bearer bob")
+	select {
+	case body := <-cancels:
+		t.Fatalf("another client's cancellation forwarded: %s", body)
+	default:
+	}
+
+	cancel("10.0.0.1:5000", "Bearer alice")
+	select {
+	case <-cancels:
+	case <-time.After(time.Second):
+		t.Fatalf("cancellation from the same connection not forwarded")
+	}
+	close(release)
+	<-done
+
+	counts, _ := readMetrics(t, srv)["cancellations_total"].(map[string]any)
+	if counts["forwarded"] != float64(1) || counts["ignored"] != float64(2) {
+		t.Fatalf("unexpected cancellation metrics: %v", counts)
+	}
+}
@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/fault"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// SetFaultInjector enables fault injection for live upstream traffic. A nil
+// injector disables it.
+func (s *Server) SetFaultInjector(inj *fault.Injector) {
+	s.faults = inj
+}
+
+// pickFault selects a fault for req, counting and logging every injection.
+func (s *Server) pickFault(req *jsonrpc.Request) *fault.Fault {
+	if s.faults == nil || req == nil {
+		return nil
+	}
+	tool := requestTool(req)
+	injected := s.faults.Pick(req.Method, tool)
+	if injected == nil {
+		return nil
+	}
+	s.metrics.incFaultInjected()
+	s.logger.Printf("fault injected: method=%s tool=%s %s", req.Method, tool, injected)
+	return injected
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/fault"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func mustFaultInjector(t *testing.T, rules ...config.FaultRule) *fault.Injector {
+	t.Helper()
+	inj, err := fault.New(config.FaultPolicy{Rules: rules})
+	if err != nil {
+		t.Fatalf("new injector: %v", err)
+	}
+	return inj
+}
+
+func TestFaultBeforePhaseSkipsUpstreamAndRecordsMarker(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	rec := record.NewRecorder(recordPath, nil, 0, 0)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, rec, nil, false, nil, nil, false, 1024, time.Second, nil)
+	srv.SetFaultInjector(mustFaultInjector(t, config.FaultRule{Name: "outage", Phase: "before", HTTPStatus: http.StatusBadGateway}))
+
+	req := []byte(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(req))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"injected_fault":"outage"`) {
+		t.Fatalf("expected injected fault marker, got=%s", w.Body.String())
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("expected upstream to be skipped, calls=%d", calls.Load())
+	}
+	if got := metricValue(t, readMetrics(t, srv), "faults_injected_total"); got != 1 {
+		t.Fatalf("faults_injected_total=%d want=1", got)
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read record: %v", err)
+	}
+	entry := record.Entry{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("unmarshal record: %v", err)
+	}
+	if entry.Fault != "outage" {
+		t.Fatalf("expected recorded fault marker, got=%q", entry.Fault)
+	}
+}
+
+func TestFaultAfterPhaseReplacesBatchItem(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 4096, time.Second, nil)
+	srv.SetFaultInjector(mustFaultInjector(t, config.FaultRule{
+		Name:      "search-broken",
+		Tools:     []string{"web.search"},
+		Phase:     "after",
+		ErrorCode: -32042,
+	}))
+
+	batch := []byte(`[
+		{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"web.search","arguments":{"query":"a"}}},
+		{"jsonrpc":"2.0","id":2,"method":"ping"}
+	]`)
+	r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(batch))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	var out []struct {
+		ID    int `json:"id"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("unmarshal: %v body=%s", err, w.Body.String())
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 responses, got=%s", w.Body.String())
+	}
+	if out[0].Error == nil || out[0].Error.Code != -32042 {
+		t.Fatalf("expected injected error for first item, got=%s", w.Body.String())
+	}
+	if out[1].Error != nil {
+		t.Fatalf("expected second item to pass through, got=%s", w.Body.String())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected after-phase fault to still call upstream, calls=%d", calls.Load())
+	}
+}
+
+func TestFaultTruncatesSSEStream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "data: first\n\n")
+		_, _ = fmt.Fprint(w, "data: second\n\n")
+	}))
+	t.Cleanup(upstream.Close)
+
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1024, time.Second, nil)
+	srv.SetFaultInjector(mustFaultInjector(t, config.FaultRule{Name: "cut", Phase: "after", TruncateSSEBytes: 12}))
+
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(req))
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	if got := w.Body.String(); got != "data: first\n" {
+		t.Fatalf("expected truncated stream, got=%q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/handshake"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+// protocolVersionHeader carries the negotiated version on requests after
+// initialize.
+const protocolVersionHeader = "MCP-Protocol-Version"
+
+// SetHandshake enables initialize rewriting. A nil handshake disables it.
+func (s *Server) SetHandshake(h *handshake.Handshake) {
+	s.handshake = h
+}
+
+// initializeBody negotiates the protocol version and rewrites client
+// capabilities of an initialize request before it is forwarded.
+func (s *Server) initializeBody(req *jsonrpc.Request, body []byte) ([]byte, error) {
+	if s.handshake == nil || req.Method != "initialize" {
+		return body, nil
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	params, changes, err := s.handshake.RewriteRequest(msg["params"])
+	if err != nil || len(changes) == 0 {
+		return body, err
+	}
+	s.logger.Printf("initialize: changes=%v", changes)
+	msg["params"] = params
+	return json.Marshal(msg)
+}
+
+// rewriteInitialize applies the server-side initialize policy to responses.
+func (s *Server) rewriteInitialize(req *jsonrpc.Request, raw json.RawMessage) json.RawMessage {
+	if s.handshake == nil || req == nil || req.Method != "initialize" {
+		return raw
+	}
+	return s.handshake.RewriteResponse(raw)
+}
+
+// requestedProtocolVersion is the protocol version a request runs under: the
+// negotiated request version for initialize, otherwise the
+// MCP-Protocol-Version header.
+func (s *Server) requestedProtocolVersion(r *http.Request, req *jsonrpc.Request) string {
+	if req.Method != "initialize" {
+		return r.Header.Get(protocolVersionHeader)
+	}
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(req.Params, &params)
+	return s.handshake.Negotiate(params.ProtocolVersion)
+}
+
+// recordedProtocolVersion is the version stored with a recording: the
+// version in the initialize result, otherwise the request header.
+func recordedProtocolVersion(r *http.Request, entry *record.Entry) string {
+	var req jsonrpc.Request
+	if err := json.Unmarshal(entry.Request, &req); err != nil || req.Method != "initialize" {
+		return r.Header.Get(protocolVersionHeader)
+	}
+	var resp struct {
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	_ = json.Unmarshal(entry.Response, &resp)
+	return resp.Result.ProtocolVersion
+}
+
+// replayVersionMismatch refuses replayed entries recorded under another
+// protocol version than the request's. Entries or requests without a version
+// are not checked.
+func (s *Server) replayVersionMismatch(r *http.Request, req *jsonrpc.Request, sig string) *jsonrpc.ErrorObject {
+	recorded := s.replay.ProtocolVersionScenario(requestScenario(r), req, sig)
+	requested := s.requestedProtocolVersion(r, req)
+	if recorded == "" || requested == "" || recorded == requested {
+		return nil
+	}
+	return &jsonrpc.ErrorObject{
+		Code:    jsonrpc.ErrServer,
+		Message: "replay protocol version mismatch",
+		Data:    map[string]string{"recorded": recorded, "requested": requested},
+	}
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/handshake"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func TestInitializeRewriteRecordsAndReplaysNegotiatedVersion(t *testing.T) {
+	var upstreamBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","capabilities":{"resources":{"subscribe":true}},"serverInfo":{"name":"upstream"}}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	h := handshake.New(config.InitializePolicy{
+		ProtocolVersions:   []string{"2025-06-18"},
+		ServerCapabilities: config.CapabilityRewrite{Strip: []string{"resources.subscribe"}},
+		ServerInfo:         config.ServerInfo{Name: "gateway"},
+	})
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetHandshake(h)
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(initialize)))
+	if !strings.Contains(upstreamBody, `"protocolVersion":"2025-06-18"`) {
+		t.Fatalf("expected negotiated version upstream, got=%s", upstreamBody)
+	}
+	if got := w.Body.String(); strings.Contains(got, "subscribe") || !strings.Contains(got, `"name":"gateway"`) {
+		t.Fatalf("expected rewritten initialize result, got=%s", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+	req.Header.Set("MCP-Protocol-Version", "2025-06-18")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i, line := range lines {
+		var entry record.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if entry.ProtocolVersion != "2025-06-18" {
+			t.Fatalf("entry %d: protocol_version=%q", i, entry.ProtocolVersion)
+		}
+	}
+
+	replay, err := record.LoadReplay(recordPath, record.ReplayMatchMethod)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	replaySrv := NewServer(nil, nil, nil, replay, true, nil, nil, true, 1<<16, 5*time.Second, nil)
+
+	req = httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`))
+	req.Header.Set("MCP-Protocol-Version", "2025-03-26")
+	w = httptest.NewRecorder()
+	replaySrv.ServeHTTP(w, req)
+	if got := w.Body.String(); !strings.Contains(got, "replay protocol version mismatch") {
+		t.Fatalf("expected version mismatch, got=%s", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":4,"method":"tools/list"}`))
+	req.Header.Set("MCP-Protocol-Version", "2025-06-18")
+	w = httptest.NewRecorder()
+	replaySrv.ServeHTTP(w, req)
+	if got := w.Body.String(); strings.Contains(got, "error") {
+		t.Fatalf("expected replay hit, got=%s", got)
+	}
+}
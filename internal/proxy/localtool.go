@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/localtool"
+)
+
+// SetLocalTools enables tools answered by the gateway. A nil registry
+// disables them.
+func (s *Server) SetLocalTools(r *localtool.Registry) {
+	s.localTools = r
+}
+
+// answerLocally returns the response for requests the gateway answers
+// itself: tools/call for a local tool (addressed by the forwarded body, so
+// aliases may point at local tools) and, without an upstream, tools/list.
+// The response is the raw result before client-facing stages, as an upstream
+// response would be.
+func (s *Server) answerLocally(ctx context.Context, req *jsonrpc.Request, forward []byte) (json.RawMessage, bool) {
+	if s.localTools == nil {
+		return nil, false
+	}
+	if req.Method == "tools/list" && s.upstream == nil {
+		// The local entries are merged in by clientResponse.
+		payload, _ := json.Marshal(jsonrpc.Response{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{"tools": []any{}}})
+		return payload, true
+	}
+	if req.Method != "tools/call" {
+		return nil, false
+	}
+	var msg struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(forward, &msg); err != nil {
+		return nil, false
+	}
+	tool, args, err := parseToolCall(msg.Params)
+	if err != nil || !s.localTools.Has(tool) {
+		return nil, false
+	}
+
+	result, err := s.localTools.Call(ctx, tool, args, s.gatewayStatus)
+	if err != nil {
+		s.logger.Printf("local tool failed: tool=%s: %v", tool, err)
+		resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrServer, "local tool error", nil)
+		if errors.Is(err, localtool.ErrInvalidArguments) {
+			resp = jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrInvalidParams, "invalid local tool arguments", err.Error())
+		}
+		payload, _ := json.Marshal(resp)
+		return payload, true
+	}
+	payload, _ := json.Marshal(jsonrpc.Response{JSONRPC: "2.0", ID: req.ID, Result: result})
+	return payload, true
+}
+
+// mergeLocalTools adds the local tools to the first page of tools/list
+// results.
+func (s *Server) mergeLocalTools(req *jsonrpc.Request, raw json.RawMessage) json.RawMessage {
+	if s.localTools == nil || req == nil || req.Method != "tools/list" {
+		return raw
+	}
+	var params struct {
+		Cursor string `json:"cursor"`
+	}
+	if len(req.Params) > 0 {
+		_ = json.Unmarshal(req.Params, &params)
+	}
+	if params.Cursor != "" {
+		return raw
+	}
+	return s.localTools.MergeList(raw)
+}
+
+// gatewayStatus is the payload of status local tools.
+func (s *Server) gatewayStatus() map[string]any {
+	return map[string]any{
+		"upstream_configured": s.upstream != nil,
+		"record_enabled":      s.recorder != nil,
+		"replay_enabled":      s.replay != nil,
+		"validation_mode":     s.validator.Mode(),
+		"local_tools":         s.localTools.Names(),
+		"metrics":             s.metrics.snapshot(),
+	}
+}
@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/localtool"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+func TestLocalToolsAnswerWithoutUpstream(t *testing.T) {
+	policy := &config.Policy{
+		Mode: "enforce",
+		LocalTools: map[string]config.LocalTool{
+			"echo": {Kind: "echo", InputSchema: map[string]any{"type": "object", "required": []any{"msg"}}},
+		},
+	}
+	validator, err := validate.New(policy)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	tools, err := localtool.New(policy.LocalTools)
+	if err != nil {
+		t.Fatalf("local tools: %v", err)
+	}
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(nil, validator, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetLocalTools(tools)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"echo","arguments":{"msg":"hi"}}}`)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"structuredContent":{"msg":"hi"}`) {
+		t.Fatalf("unexpected echo response: code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"echo","arguments":{}}}`)))
+	if !strings.Contains(w.Body.String(), `"code":-32602`) {
+		t.Fatalf("expected local tool arguments to be validated, got=%s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","id":3,"method":"tools/list"}]`)))
+	if got := w.Body.String(); !strings.Contains(got, `"tools":[{"inputSchema"`) || !strings.Contains(got, `"name":"echo"`) {
+		t.Fatalf("expected local tools/list, got=%s", got)
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	var entry record.Entry
+	if err := json.Unmarshal([]byte(strings.Split(string(data), "\n")[0]), &entry); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !strings.Contains(string(entry.Response), `"msg":"hi"`) {
+		t.Fatalf("expected local tool call to be recorded, got=%s", entry.Response)
+	}
+}
+
+func TestLocalToolsMergeIntoUpstreamList(t *testing.T) {
+	var upstreamBodies []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBodies = append(upstreamBodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"search","inputSchema":{"type":"object"}}]}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	tools, err := localtool.New(map[string]config.LocalTool{"gateway.status": {Kind: "status"}})
+	if err != nil {
+		t.Fatalf("local tools: %v", err)
+	}
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetLocalTools(tools)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+	if got := w.Body.String(); !strings.Contains(got, `"name":"search"`) || !strings.Contains(got, `"name":"gateway.status"`) {
+		t.Fatalf("expected merged tools/list, got=%s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"gateway.status"}}`)))
+	if got := w.Body.String(); !strings.Contains(got, `"upstream_configured":true`) {
+		t.Fatalf("unexpected status result: %s", got)
+	}
+	if len(upstreamBodies) != 1 {
+		t.Fatalf("local tool call should not reach upstream, got=%v", upstreamBodies)
+	}
+}
+
+func TestAliasToLocalToolEnforcesTargetSchema(t *testing.T) {
+	policy := &config.Policy{
+		Mode: "enforce",
+		LocalTools: map[string]config.LocalTool{
+			"echo": {Kind: "echo", InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"msg": map[string]any{"type": "string", "maxLength": 5}},
+				"required":   []any{"msg"},
+			}},
+		},
+	}
+	validator, err := validate.New(policy)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	tools, err := localtool.New(policy.LocalTools)
+	if err != nil {
+		t.Fatalf("local tools: %v", err)
+	}
+	a, err := alias.New(map[string]config.ToolAlias{"say": {Tool: "echo"}})
+	if err != nil {
+		t.Fatalf("aliases: %v", err)
+	}
+	srv := NewServer(nil, validator, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetLocalTools(tools)
+	srv.SetAliases(a)
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"say","arguments":{"msg":"far too long"}}}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"say","arguments":{}}}]`,
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		if got := w.Body.String(); !strings.Contains(got, "tool call rejected") || strings.Contains(got, "structuredContent") {
+			t.Fatalf("expected the target schema to reject the call, got=%s", got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"say","arguments":{"msg":"hi"}}}`)))
+	if !strings.Contains(w.Body.String(), `"structuredContent":{"msg":"hi"}`) {
+		t.Fatalf("expected valid alias call to reach the local tool, got=%s", w.Body.String())
+	}
+}
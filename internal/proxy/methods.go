@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// checkMethod enforces the method policy before replay and forwarding.
+// Blocked methods are answered as not found; params that fail the method's
+// schema are invalid params. It returns nil when the request may proceed.
+func (s *Server) checkMethod(req *jsonrpc.Request) *jsonrpc.ErrorObject {
+	if s.validator == nil {
+		return nil
+	}
+	decision := s.validator.ValidateMethod(req.Method)
+	if len(decision.Violations) > 0 && decision.Allowed {
+		s.logger.Printf("validation audit: method=%s violations=%v", req.Method, decision.Violations)
+	}
+	if !decision.Allowed {
+		s.metrics.incValidationReject()
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrMethodNotFound, Message: "Method not found", Data: req.Method}
+	}
+
+	decision, err := s.validator.ValidateMethodParams(req.Method, req.Params)
+	if err != nil {
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrServer, Message: "validation error"}
+	}
+	if len(decision.Violations) > 0 && decision.Allowed {
+		s.logger.Printf("validation audit: method=%s violations=%v", req.Method, decision.Violations)
+	}
+	if !decision.Allowed {
+		s.metrics.incValidationReject()
+		return &jsonrpc.ErrorObject{Code: jsonrpc.ErrInvalidParams, Message: "invalid params", Data: decision.Violations}
+	}
+	return nil
+}
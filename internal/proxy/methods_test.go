@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+func TestMethodPolicyBlocksReplayAndBatch(t *testing.T) {
+	validator, err := validate.New(&config.Policy{
+		Mode: "enforce",
+		Methods: config.MethodPolicy{
+			DefaultDeny: true,
+			Allow:       []string{"tools/*", "logging/setLevel"},
+			Params: map[string]map[string]any{
+				"logging/setLevel": {"type": "object", "required": []any{"level"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	sampling := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage","params":{}}`)
+	replay := mustReplayStore(t, map[string]json.RawMessage{
+		mustSig(t, sampling): json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{}}`),
+	})
+	srv := NewServer(nil, validator, nil, replay, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(string(sampling))))
+	if got := w.Body.String(); !strings.Contains(got, `"code":-32601`) || !strings.Contains(got, `"Method not found"`) {
+		t.Fatalf("expected blocked replayed method, got=%s", got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/message"}`)))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("blocked notification should get no response, code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[
+		{"jsonrpc":"2.0","id":1,"method":"completion/complete"},
+		{"jsonrpc":"2.0","method":"notifications/message"},
+		{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{}}]`)))
+	var responses []struct {
+		ID    int `json:"id"`
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("unmarshal batch: %v body=%s", err, w.Body.String())
+	}
+	if len(responses) != 2 || responses[0].Error.Code != -32601 || responses[1].Error.Code != -32602 {
+		t.Fatalf("unexpected batch responses: %s", w.Body.String())
+	}
+}
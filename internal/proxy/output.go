@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// checkToolResult validates the result of a live tools/call response against
+// the tool's output schema, counting responses with violations. In enforce
+// mode an invalid result is replaced by a JSON-RPC error listing the
+// violations; in audit mode they are only logged.
+func (s *Server) checkToolResult(req *jsonrpc.Request, resp json.RawMessage) json.RawMessage {
+	tool := requestTool(req)
+	if !s.validator.HasOutputSchema(tool) {
+		return resp
+	}
+	msg := struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+	}{}
+	if err := json.Unmarshal(resp, &msg); err != nil || len(msg.Result) == 0 {
+		return resp
+	}
+	id := msg.ID
+	if len(id) == 0 {
+		id = req.ID
+	}
+
+	decision, err := s.validator.ValidateToolResult(tool, msg.Result)
+	if err != nil {
+		s.logger.Printf("output validation failed: tool=%s err=%v", tool, err)
+		payload, _ := json.Marshal(jsonrpc.ErrorResponse(id, jsonrpc.ErrServer, "validation error", nil))
+		return payload
+	}
+	if len(decision.Violations) == 0 {
+		return resp
+	}
+	s.metrics.incOutputViolation()
+	if decision.Allowed {
+		s.logger.Printf("output validation audit: tool=%s violations=%v", tool, decision.Violations)
+		return resp
+	}
+	payload, _ := json.Marshal(jsonrpc.ErrorResponse(id, jsonrpc.ErrServer, "tool result rejected", decision.Violations))
+	return payload
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+func newOutputSchemaServer(t *testing.T, mode string, upstream http.HandlerFunc) *Server {
+	t.Helper()
+	up := httptest.NewServer(upstream)
+	t.Cleanup(up.Close)
+	v, err := validate.New(&config.Policy{
+		Mode: mode,
+		Tools: map[string]config.ToolEntry{
+			"weather.get": {OutputSchema: map[string]any{
+				"type":     "object",
+				"required": []any{"temp"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	return NewServer(mustParseURL(t, up.URL), v, nil, nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+}
+
+func TestOutputSchemaEnforceReplacesInvalidResults(t *testing.T) {
+	srv := newOutputSchemaServer(t, "enforce", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":3,"result":{"structuredContent":{"humidity":40}}}`)
+	})
+
+	reqBody := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"tool":"weather.get","arguments":{}}}`
+	for _, body := range []string{reqBody, "[" + reqBody + "]"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		got := w.Body.String()
+		if !strings.Contains(got, `"message":"tool result rejected"`) || !strings.Contains(got, `"id":3`) || strings.Contains(got, "humidity") {
+			t.Fatalf("expected rejected result, got=%s", got)
+		}
+	}
+	metrics := readMetrics(t, srv)
+	if got := metricValue(t, metrics, "output_schema_violations_total"); got != 2 {
+		t.Fatalf("output_schema_violations_total=%d want=2", got)
+	}
+}
+
+func TestOutputSchemaAuditPassesResultsThrough(t *testing.T) {
+	srv := newOutputSchemaServer(t, "audit", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"structuredContent":{"humidity":40}}}`)
+	})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"weather.get","arguments":{}}}`)))
+	if !strings.Contains(w.Body.String(), "humidity") {
+		t.Fatalf("audit mode must not rewrite results, got=%s", w.Body.String())
+	}
+	if got := metricValue(t, readMetrics(t, srv), "output_schema_violations_total"); got != 1 {
+		t.Fatalf("output_schema_violations_total=%d want=1", got)
+	}
+}
+
+func TestOutputSchemaValidatesFinalSSEResult(t *testing.T) {
+	srv := newOutputSchemaServer(t, "enforce", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{\"progress\":1}}\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":9,\"result\":{\"structuredContent\":{\"humidity\":40}}}\n\n")
+	})
+	r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"tool":"weather.get","arguments":{}}}`))
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "notifications/progress") || !strings.Contains(body, "tool result rejected") || strings.Contains(body, "humidity") {
+		t.Fatalf("expected rejected final event, got=%q", body)
+	}
+}
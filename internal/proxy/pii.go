@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+// forwardBody logs PII found in an allowed tools/call and returns the body to
+// send upstream: body itself, or body with the masked arguments.
+func (s *Server) forwardBody(tool string, body []byte, decision validate.Decision) ([]byte, error) {
+	if len(decision.PII) > 0 {
+		s.logger.Printf("pii %s: tool=%s findings=%v", s.validator.PIIAction(tool), tool, decision.PII)
+	}
+	if decision.Arguments == nil {
+		return body, nil
+	}
+	return withToolArguments(body, decision.Arguments)
+}
+
+// withToolArguments returns a tools/call request body with params.arguments
+// replaced by args. Other members are kept as-is.
+func withToolArguments(body []byte, args json.RawMessage) ([]byte, error) {
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		return nil, err
+	}
+	params["arguments"] = args
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	msg["params"] = rawParams
+	return json.Marshal(msg)
+}
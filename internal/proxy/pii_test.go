@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
+)
+
+func TestPIIMaskRewritesUpstreamArgumentsAndRejectBlocks(t *testing.T) {
+	var upstreamBodies []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBodies = append(upstreamBodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	v, err := validate.New(&config.Policy{
+		Mode: "enforce",
+		PII: config.PIIPolicy{
+			Action: validate.PIIMask,
+			Tools:  map[string]string{"crm.export": validate.PIIReject},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), v, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"mail.send","arguments":{"to":"jane@example.com","body":"hi"}}}`)))
+	if !strings.Contains(w.Body.String(), `"ok":true`) {
+		t.Fatalf("expected upstream result, got=%s", w.Body.String())
+	}
+	if len(upstreamBodies) != 1 || !strings.Contains(upstreamBodies[0], "[PII:email]") || strings.Contains(upstreamBodies[0], "jane@example.com") {
+		t.Fatalf("expected masked upstream body, got=%v", upstreamBodies)
+	}
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	if !strings.Contains(string(data), "jane@example.com") {
+		t.Fatalf("recording should keep the client request, got=%s", data)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"crm.export","arguments":{"ssn":"123-45-6789"}}}]`)))
+	got := w.Body.String()
+	if !strings.Contains(got, "tool call rejected") || !strings.Contains(got, "pii detected: national_id at $.params.arguments.ssn") {
+		t.Fatalf("expected pii rejection, got=%s", got)
+	}
+	if len(upstreamBodies) != 1 {
+		t.Fatalf("rejected call reached upstream: %v", upstreamBodies)
+	}
+}
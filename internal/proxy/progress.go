@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/progress"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/serverreq"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/sse"
+)
+
+// SetProgress enables the progress notification policy. A nil limiter
+// passes progress notifications through and refuses streamed results for
+// clients that did not ask for SSE.
+func (s *Server) SetProgress(l *progress.Limiter) {
+	s.progress = l
+}
+
+// progressEvents puts the progress policy behind the event rewrite of a
+// passthrough stream. It returns a nil rewrite when the stream can be copied
+// untouched, and the progress stream whose held notifications the event
+// rewriter must send.
+func (s *Server) progressEvents(req *jsonrpc.Request, rewrite func(string) (string, bool)) (func(string) []string, *progress.Stream) {
+	if s.progress == nil && rewrite == nil {
+		return nil, nil
+	}
+	stream := s.progress.Stream(progress.Token(req.Params))
+	return func(data string) []string {
+		// Rewrite first so notifications the throttle holds back and
+		// releases later are already filtered.
+		if rewrite != nil {
+			d, keep := rewrite(data)
+			if !keep {
+				return nil
+			}
+			data = d
+		}
+		return stream.Filter(data)
+	}, stream
+}
+
+// errStreamNeedsClient is returned when a stream to be buffered carries a
+// server-to-client request, which only an SSE client can answer.
+var errStreamNeedsClient = errors.New("upstream stream carries a server request")
+
+// readUpstreamResult reads the JSON-RPC response of an upstream reply. For a
+// streamed reply that is the last response event; progress notifications
+// are discarded and other discarded events are logged. Streams carrying a
+// server-to-client request are refused as soon as the request is seen, so
+// the upstream does not wait for an answer that cannot come.
+func (s *Server) readUpstreamResult(resp *http.Response) (json.RawMessage, error) {
+	if !isEventStreamContentType(resp.Header.Get("Content-Type")) {
+		return s.readUpstreamJSON(resp)
+	}
+	var final json.RawMessage
+	var discarded []string
+	buffer := &streamBuffer{}
+	buffer.splitter = sse.NewSplitter(func(ev sse.Event) {
+		if buffer.err != nil {
+			return
+		}
+		switch {
+		case serverreq.IsRequest([]byte(ev.Data)):
+			buffer.err = errStreamNeedsClient
+		case isJSONRPCResponse([]byte(ev.Data)):
+			if final != nil {
+				discarded = append(discarded, "response")
+			}
+			final = json.RawMessage(ev.Data)
+		default:
+			if method := eventMethod(ev.Data); method != "notifications/progress" {
+				discarded = append(discarded, method)
+			}
+		}
+	})
+	n, err := io.Copy(buffer, io.LimitReader(resp.Body, s.maxBody+1))
+	if err == nil && n > s.maxBody {
+		return nil, errUpstreamResponseTooLarge
+	}
+	if err == nil {
+		buffer.splitter.Flush()
+		err = buffer.err
+	}
+	if errors.Is(err, errStreamNeedsClient) {
+		s.logger.Printf("buffered stream refused: upstream sent a server request")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(discarded) > 0 {
+		s.logger.Printf("buffered stream: discarded events=%v", discarded)
+	}
+	if final == nil {
+		return nil, errors.New("upstream stream ended without a response")
+	}
+	s.progress.CountBuffered()
+	return final, nil
+}
+
+// streamBuffer feeds stream bytes to a splitter and stops the copy once an
+// event handler has set err.
+type streamBuffer struct {
+	splitter *sse.Splitter
+	err      error
+}
+
+func (b *streamBuffer) Write(p []byte) (int, error) {
+	n, _ := b.splitter.Write(p)
+	if b.err != nil {
+		return 0, b.err
+	}
+	return n, nil
+}
+
+// eventMethod names an event for logs: its JSON-RPC method, or "data" for
+// payloads that are not JSON-RPC messages.
+func eventMethod(data string) string {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg.Method == "" {
+		return "data"
+	}
+	return msg.Method
+}
+
+func (s *Server) writeProgressProm(buf *bytes.Buffer) {
+	st := s.progress.Stats()
+	writeLabeledCounters(buf, "mcp_proxy_gateway_progress_notifications_total", "Progress notifications by outcome.", "outcome", map[string]uint64{
+		"forwarded":     st.Forwarded,
+		"throttled":     st.Throttled,
+		"dropped":       st.Dropped,
+		"invalid_token": st.Invalid,
+	})
+	buf.WriteString("# HELP mcp_proxy_gateway_buffered_streams_total Streamed results answered as plain JSON.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_buffered_streams_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_buffered_streams_total ")
+	buf.WriteString(formatUint(st.Buffered))
+	buf.WriteString("\n")
+}
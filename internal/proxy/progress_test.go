@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/progress"
+)
+
+func progressUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, `data: {"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p1","progress":%d}}`+"\n\n", i)
+		}
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"other","progress":1}}`+"\n\n")
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"done"}]}}`+"\n\n")
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+const progressCall = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow","_meta":{"progressToken":"p1"}}}`
+
+func TestProgressNotificationsThrottledInPassthrough(t *testing.T) {
+	srv := NewServer(mustParseURL(t, progressUpstream(t).URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetProgress(progress.New(config.ProgressPolicy{Mode: "throttle", MinInterval: "1h", ValidateTokens: true}))
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(progressCall))
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	stream := w.Body.String()
+	// The first notification passes; the latest throttled one is sent before
+	// the result.
+	first, last, result := strings.Index(stream, `"progress":1}`), strings.Index(stream, `"progress":3}`), strings.Index(stream, `"text":"done"`)
+	if strings.Count(stream, "notifications/progress") != 2 || first < 0 || last < first || result < last {
+		t.Fatalf("expected first and latest progress before the result, got=%s", stream)
+	}
+	st := srv.progress.Stats()
+	if st.Forwarded != 2 || st.Throttled != 1 || st.Invalid != 1 {
+		t.Fatalf("unexpected progress stats: %+v", st)
+	}
+}
+
+func TestBufferedStreamAnswersJSONClient(t *testing.T) {
+	srv := NewServer(mustParseURL(t, progressUpstream(t).URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetProgress(progress.New(config.ProgressPolicy{BufferStreams: true}))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(progressCall)))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status=%d content-type=%q body=%s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, "progress") || !strings.Contains(body, `"text":"done"`) {
+		t.Fatalf("expected the final response only, got=%s", body)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader("["+progressCall+"]")))
+	if body := w.Body.String(); !strings.HasPrefix(body, "[") || !strings.Contains(body, `"text":"done"`) {
+		t.Fatalf("expected buffered batch item, got=%s", body)
+	}
+	if st := srv.progress.Stats(); st.Buffered != 2 {
+		t.Fatalf("unexpected progress stats: %+v", st)
+	}
+}
+
+func TestBufferedStreamRefusesServerRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{"messages":[]}}`+"\n\n")
+		w.(http.Flusher).Flush()
+		// The upstream waits for the sampling answer before finishing.
+		<-r.Context().Done()
+	}))
+	t.Cleanup(upstream.Close)
+
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetProgress(progress.New(config.ProgressPolicy{BufferStreams: true}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(progressCall)))
+		done <- w
+	}()
+	select {
+	case w := <-done:
+		if !strings.Contains(w.Body.String(), "requires Accept: text/event-stream") {
+			t.Fatalf("expected refusal, got=%s", w.Body.String())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("buffered stream with a server request hung")
+	}
+	if st := srv.progress.Stats(); st.Buffered != 0 {
+		t.Fatalf("unexpected progress stats: %+v", st)
+	}
+}
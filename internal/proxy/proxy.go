@@ -9,19 +9,35 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/alias"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/approval"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/fault"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/handshake"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/localtool"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/progress"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/respfilter"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/screen"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/serverreq"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/shadow"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/signature"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/transform"
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/validate"
 )
 
 var errUpstreamResponseTooLarge = errors.New("upstream response too large")
 
+// scenarioHeader selects the replay scenario for a request and tags
+// recordings made while it is set.
+const scenarioHeader = "X-MCP-Scenario"
+
 type Server struct {
 	upstream        *url.URL
 	client          *http.Client
@@ -29,12 +45,25 @@ type Server struct {
 	recorder        *record.Recorder
 	replay          *record.ReplayStore
 	replayStrict    bool
+	replayTiming    bool
 	promMetrics     bool
 	maxBody         int64
 	originAllowlist map[string]struct{}
 	forwardHeaders  map[string]struct{}
 	logger          *log.Logger
 	metrics         *proxyMetrics
+	faults          *fault.Injector
+	shadow          *shadow.Comparer
+	respFilter      *respfilter.Filter
+	screener        *screen.Screener
+	approvals       *approval.Queue
+	transformer     *transform.Transformer
+	aliases         *alias.Aliases
+	localTools      *localtool.Registry
+	handshake       *handshake.Handshake
+	serverRequests  *serverreq.Relay
+	progress        *progress.Limiter
+	inflight        inflightTracker
 }
 
 type proxyMetrics struct {
@@ -43,7 +72,13 @@ type proxyMetrics struct {
 	replayHitsTotal        atomic.Uint64
 	replayMissesTotal      atomic.Uint64
 	validationRejectsTotal atomic.Uint64
+	outputViolationsTotal  atomic.Uint64
 	upstreamErrorsTotal    atomic.Uint64
+	faultsInjectedTotal    atomic.Uint64
+	cancelForwardedTotal   atomic.Uint64
+	cancelIgnoredTotal     atomic.Uint64
+	cancelDisconnectTotal  atomic.Uint64
+	cancelUpstreamTotal    atomic.Uint64
 	latencyCount           atomic.Uint64
 	latencySumMs           atomic.Uint64
 	latencyLE5ms           atomic.Uint64
@@ -93,6 +128,13 @@ func (m *proxyMetrics) incValidationReject() {
 	m.validationRejectsTotal.Add(1)
 }
 
+func (m *proxyMetrics) incOutputViolation() {
+	if m == nil {
+		return
+	}
+	m.outputViolationsTotal.Add(1)
+}
+
 func (m *proxyMetrics) incUpstreamError() {
 	if m == nil {
 		return
@@ -100,6 +142,41 @@ func (m *proxyMetrics) incUpstreamError() {
 	m.upstreamErrorsTotal.Add(1)
 }
 
+func (m *proxyMetrics) incFaultInjected() {
+	if m == nil {
+		return
+	}
+	m.faultsInjectedTotal.Add(1)
+}
+
+// incCancel counts a cancellation by outcome: forwarded and ignored client
+// notifications, disconnects turned into notifications, and upstream
+// notifications for relayed server requests.
+func (m *proxyMetrics) incCancel(outcome string) {
+	if m == nil {
+		return
+	}
+	switch outcome {
+	case cancelForwarded:
+		m.cancelForwardedTotal.Add(1)
+	case cancelIgnored:
+		m.cancelIgnoredTotal.Add(1)
+	case cancelDisconnect:
+		m.cancelDisconnectTotal.Add(1)
+	case cancelUpstream:
+		m.cancelUpstreamTotal.Add(1)
+	}
+}
+
+func (m *proxyMetrics) cancellations() map[string]uint64 {
+	return map[string]uint64{
+		cancelForwarded:  m.cancelForwardedTotal.Load(),
+		cancelIgnored:    m.cancelIgnoredTotal.Load(),
+		cancelDisconnect: m.cancelDisconnectTotal.Load(),
+		cancelUpstream:   m.cancelUpstreamTotal.Load(),
+	}
+}
+
 func (m *proxyMetrics) observeLatency(d time.Duration) {
 	if m == nil {
 		return
@@ -130,14 +207,17 @@ func (m *proxyMetrics) snapshot() map[string]any {
 		return map[string]any{}
 	}
 	return map[string]any{
-		"requests_total":           m.requestsTotal.Load(),
-		"batch_items_total":        m.batchItemsTotal.Load(),
-		"replay_hits_total":        m.replayHitsTotal.Load(),
-		"replay_misses_total":      m.replayMissesTotal.Load(),
-		"validation_rejects_total": m.validationRejectsTotal.Load(),
-		"upstream_errors_total":    m.upstreamErrorsTotal.Load(),
-		"latency_count":            m.latencyCount.Load(),
-		"latency_sum_ms":           m.latencySumMs.Load(),
+		"requests_total":                 m.requestsTotal.Load(),
+		"batch_items_total":              m.batchItemsTotal.Load(),
+		"replay_hits_total":              m.replayHitsTotal.Load(),
+		"replay_misses_total":            m.replayMissesTotal.Load(),
+		"validation_rejects_total":       m.validationRejectsTotal.Load(),
+		"output_schema_violations_total": m.outputViolationsTotal.Load(),
+		"upstream_errors_total":          m.upstreamErrorsTotal.Load(),
+		"faults_injected_total":          m.faultsInjectedTotal.Load(),
+		"cancellations_total":            m.cancellations(),
+		"latency_count":                  m.latencyCount.Load(),
+		"latency_sum_ms":                 m.latencySumMs.Load(),
 		"latency_buckets_ms": map[string]uint64{
 			"le_5":    m.latencyLE5ms.Load(),
 			"le_20":   m.latencyLE20ms.Load(),
@@ -228,6 +308,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "/rpc", "/mcp":
 		// JSON-RPC endpoints; continue below.
 	default:
+		if r.URL.Path == approvalsPath || strings.HasPrefix(r.URL.Path, approvalsPath+"/") {
+			s.handleApprovals(w, r)
+			return
+		}
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
@@ -266,6 +350,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.handleBatch(w, r, trimmed)
 		return
 	}
+	if isJSONRPCResponse(trimmed) {
+		s.handleClientResponse(w, r, trimmed)
+		return
+	}
 	s.handleSingle(w, r, trimmed)
 }
 
@@ -286,6 +374,16 @@ func parseToolCall(raw json.RawMessage) (string, json.RawMessage, error) {
 	return params.Tool, params.Arguments, nil
 }
 
+// requestTool returns the tool name of a tools/call request, or "" for other
+// methods.
+func requestTool(req *jsonrpc.Request) string {
+	if req == nil || req.Method != "tools/call" {
+		return ""
+	}
+	tool, _, _ := parseToolCall(req.Params)
+	return tool
+}
+
 func isNotification(req *jsonrpc.Request) bool {
 	if req == nil {
 		return false
@@ -309,6 +407,13 @@ func withResponseID(rawResp, id json.RawMessage) (json.RawMessage, error) {
 	return json.RawMessage(out), nil
 }
 
+func requestScenario(r *http.Request) string {
+	if r == nil {
+		return record.DefaultScenario
+	}
+	return strings.TrimSpace(r.Header.Get(scenarioHeader))
+}
+
 func wantsEventStream(r *http.Request) bool {
 	if r == nil {
 		return false
@@ -407,6 +512,30 @@ func (s *Server) writeRawJSON(w http.ResponseWriter, status int, payload []byte)
 	_, _ = w.Write(payload)
 }
 
+// clientResponse applies the client-facing stages to a response for req:
+// initialize rewriting, local tool and alias listing, resource and prompt
+// list filtering, content screening, then the response filter. Screening
+// findings are returned for recording.
+func (s *Server) clientResponse(req *jsonrpc.Request, raw json.RawMessage) (json.RawMessage, []record.Finding) {
+	raw = s.rewriteInitialize(req, raw)
+	listed := s.filterCatalog(req, s.rewriteToolList(req, s.mergeLocalTools(req, raw)))
+	out, findings := s.screenToolResult(req, listed)
+	return s.filterResponse(req, out), findings
+}
+
+func (s *Server) appendRecord(r *http.Request, entry record.Entry, injected *fault.Fault) {
+	entry.Scenario = requestScenario(r)
+	if injected != nil {
+		entry.Fault = injected.Rule
+	}
+	if s.recorder != nil {
+		entry.ProtocolVersion = recordedProtocolVersion(r, &entry)
+	}
+	if err := s.recorder.AppendEntry(entry); err != nil {
+		s.logger.Printf("record append failed: %v", err)
+	}
+}
+
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -418,12 +547,16 @@ func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payload, _ := json.Marshal(map[string]any{
+	health := map[string]any{
 		"ok":                  true,
 		"upstream_configured": s.upstream != nil,
 		"record_enabled":      s.recorder != nil,
 		"replay_enabled":      s.replay != nil,
-	})
+	}
+	if s.replay != nil {
+		health["replay"] = s.replay.Stats()
+	}
+	payload, _ := json.Marshal(health)
 	s.writeRawJSON(w, http.StatusOK, payload)
 }
 
@@ -437,7 +570,30 @@ func (s *Server) handleMetricsz(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	payload, _ := json.Marshal(s.metrics.snapshot())
+	snapshot := s.metrics.snapshot()
+	snapshot["inflight_requests"] = s.inflight.len()
+	if s.shadow != nil {
+		snapshot["shadow"] = s.shadow.Stats()
+	}
+	if counts := s.recorder.DetectorCounts(); counts != nil {
+		snapshot["redaction_detections"] = counts
+	}
+	if s.respFilter != nil {
+		snapshot["response_filter"] = s.respFilter.Stats()
+	}
+	if s.screener != nil {
+		snapshot["screening"] = s.screener.Stats()
+	}
+	if s.approvals != nil {
+		snapshot["approvals"] = s.approvals.Stats()
+	}
+	if s.serverRequests != nil {
+		snapshot["server_requests"] = s.serverRequests.Stats()
+	}
+	if s.progress != nil {
+		snapshot["progress"] = s.progress.Stats()
+	}
+	payload, _ := json.Marshal(snapshot)
 	s.writeRawJSON(w, http.StatusOK, payload)
 }
 
@@ -514,12 +670,54 @@ func (s *Server) handleMetricsProm(w http.ResponseWriter, r *http.Request) {
 	buf.WriteString(formatUint(validationRejects))
 	buf.WriteString("\n")
 
+	buf.WriteString("# HELP mcp_proxy_gateway_output_schema_violations_total Total tool results that failed output schema validation.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_output_schema_violations_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_output_schema_violations_total ")
+	buf.WriteString(formatUint(m.outputViolationsTotal.Load()))
+	buf.WriteString("\n")
+
 	buf.WriteString("# HELP mcp_proxy_gateway_upstream_errors_total Total upstream errors (connection, read, or size limit).\n")
 	buf.WriteString("# TYPE mcp_proxy_gateway_upstream_errors_total counter\n")
 	buf.WriteString("mcp_proxy_gateway_upstream_errors_total ")
 	buf.WriteString(formatUint(upstreamErrors))
 	buf.WriteString("\n")
 
+	buf.WriteString("# HELP mcp_proxy_gateway_faults_injected_total Total faults injected by fault injection rules.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_faults_injected_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_faults_injected_total ")
+	buf.WriteString(formatUint(m.faultsInjectedTotal.Load()))
+	buf.WriteString("\n")
+
+	buf.WriteString("# HELP mcp_proxy_gateway_inflight_requests Requests forwarded upstream and awaiting a response.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_inflight_requests gauge\n")
+	buf.WriteString("mcp_proxy_gateway_inflight_requests ")
+	buf.WriteString(formatUint(uint64(s.inflight.len())))
+	buf.WriteString("\n")
+
+	writeLabeledCounters(&buf, "mcp_proxy_gateway_cancellations_total", "Request cancellations by outcome.", "outcome", m.cancellations())
+
+	if s.shadow != nil {
+		s.writeShadowProm(&buf)
+	}
+	if counts := s.recorder.DetectorCounts(); counts != nil {
+		writeLabeledCounters(&buf, "mcp_proxy_gateway_redaction_detections_total", "Secrets masked in recordings by value detector.", "detector", counts)
+	}
+	if s.respFilter != nil {
+		s.writeResponseFilterProm(&buf)
+	}
+	if s.screener != nil {
+		s.writeScreeningProm(&buf)
+	}
+	if s.approvals != nil {
+		s.writeApprovalProm(&buf)
+	}
+	if s.serverRequests != nil {
+		s.writeServerRequestProm(&buf)
+	}
+	if s.progress != nil {
+		s.writeProgressProm(&buf)
+	}
+
 	buf.WriteString("# HELP mcp_proxy_gateway_latency_ms Upstream and validation latency histogram in milliseconds.\n")
 	buf.WriteString("# TYPE mcp_proxy_gateway_latency_ms histogram\n")
 	buf.WriteString("mcp_proxy_gateway_latency_ms_bucket{le=\"5\"} ")
@@ -551,6 +749,27 @@ func (s *Server) handleMetricsProm(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(buf.Bytes())
 }
 
+// writeLabeledCounters writes a counter family with one series per label
+// value, sorted for stable output.
+func writeLabeledCounters(buf *bytes.Buffer, name, help, label string, values map[string]uint64) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " counter\n")
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteString(name + "{" + label + "=\"" + escapeLabelValue(k) + "\"} ")
+		buf.WriteString(formatUint(values[k]))
+		buf.WriteString("\n")
+	}
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
 func formatUint(v uint64) string {
 	// Avoid fmt for hot paths; this is debug/ops only, but keep deps minimal.
 	return strconv.FormatUint(v, 10)
@@ -579,8 +798,46 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 		return
 	}
 
+	if rejected := s.checkMethod(&req); rejected != nil {
+		// Notifications are dropped without a response.
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, rejected.Code, rejected.Message, rejected.Data)
+		return
+	}
+	// Access policies apply to replayed responses too.
+	if rejected := s.checkAccess(&req); rejected != nil {
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, rejected.Code, rejected.Message, rejected.Data)
+		return
+	}
+	if !s.checkCancellation(r, &req) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	if s.replay != nil {
-		if resp, ok := s.replay.Lookup(&req, sig); ok {
+		if rejected := s.replayVersionMismatch(r, &req, sig); rejected != nil {
+			if notification {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			s.writeJSONRPCError(w, req.ID, rejected.Code, rejected.Message, rejected.Data)
+			return
+		}
+		if !notification && wantsEventStream(r) {
+			if events, ok := s.replay.LookupStreamScenario(requestScenario(r), &req, sig); ok {
+				s.metrics.incReplayHit()
+				s.writeReplayStream(w, r, events, &req)
+				return
+			}
+		}
+		if resp, ok := s.replay.LookupScenario(requestScenario(r), &req, sig); ok {
 			s.metrics.incReplayHit()
 			if notification {
 				w.WriteHeader(http.StatusNoContent)
@@ -591,7 +848,8 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 				s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "invalid replay response", nil)
 				return
 			}
-			s.writeRawJSON(w, http.StatusOK, replayResp)
+			clientResp, _ := s.clientResponse(&req, replayResp)
+			s.writeRawJSON(w, http.StatusOK, clientResp)
 			return
 		}
 		s.metrics.incReplayMiss()
@@ -605,6 +863,7 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 		}
 	}
 
+	forward := body
 	if req.Method == "tools/call" && s.validator != nil {
 		tool, args, err := parseToolCall(req.Params)
 		if err != nil {
@@ -637,6 +896,71 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 			s.writeJSONRPCError(w, req.ID, jsonrpc.ErrInvalidParams, "tool call rejected", decision.Violations)
 			return
 		}
+		if forward, err = s.forwardBody(tool, body, decision); err != nil {
+			if notification {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "validation error", nil)
+			return
+		}
+	}
+
+	if forward, err = s.transformBody(&req, forward); err != nil {
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "transform error", nil)
+		return
+	}
+	if forward, err = s.aliasBody(&req, forward); err != nil {
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, jsonrpc.ErrInvalidParams, "invalid tools/call params", nil)
+		return
+	}
+	if rejected := s.checkAliasCall(&req, forward); rejected != nil {
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, rejected.Code, rejected.Message, rejected.Data)
+		return
+	}
+	if forward, err = s.initializeBody(&req, forward); err != nil {
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeJSONRPCError(w, req.ID, jsonrpc.ErrInvalidParams, "invalid initialize params", nil)
+		return
+	}
+	forwarded := forwardedRequest(body, forward)
+
+	approval := s.awaitApproval(r, &req, forward)
+	if approval.Denied() {
+		payload := approvalError(req.ID, approval)
+		s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(body), Forwarded: forwarded, Response: payload, Approval: approval}, nil)
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeRawJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	if localResp, ok := s.answerLocally(r.Context(), &req, forward); ok {
+		clientResp, findings := s.clientResponse(&req, s.checkToolResult(&req, localResp))
+		s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(body), Forwarded: forwarded, Response: localResp, Findings: findings, Approval: approval}, nil)
+		if notification {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeRawJSON(w, http.StatusOK, clientResp)
+		return
 	}
 
 	if s.upstream == nil {
@@ -648,8 +972,26 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 		return
 	}
 
+	injected := s.pickFault(&req)
+	if injected != nil && injected.Phase == fault.PhaseBefore {
+		if err := injected.Delay(r.Context()); err != nil {
+			return
+		}
+		if injected.Replaces() {
+			status, payload := injected.Response(req.ID)
+			s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(body), Forwarded: forwarded, Response: payload, Approval: approval}, injected)
+			if notification {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			s.writeRawJSON(w, status, payload)
+			return
+		}
+	}
+
 	wantsSSE := wantsEventStream(r)
-	upstreamHTTPResp, err := s.doUpstream(r.Context(), r, body, wantsSSE)
+	defer s.trackInflight(r, &req)()
+	upstreamHTTPResp, err := s.doUpstream(r.Context(), r, forward, wantsSSE)
 	if err != nil {
 		s.metrics.incUpstreamError()
 		if notification {
@@ -661,8 +1003,9 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 	}
 	defer upstreamHTTPResp.Body.Close()
 
-	// Only stream passthrough when the client explicitly requested SSE.
-	if isEventStreamContentType(upstreamHTTPResp.Header.Get("Content-Type")) {
+	// Only stream passthrough when the client explicitly requested SSE. Other
+	// clients get the final response when the progress policy buffers streams.
+	if isEventStreamContentType(upstreamHTTPResp.Header.Get("Content-Type")) && (wantsSSE || !s.progress.BuffersStreams()) {
 		if !wantsSSE {
 			s.metrics.incUpstreamError()
 			if notification {
@@ -677,7 +1020,21 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		// Recording/replay of streamed responses is intentionally skipped.
+		limit := s.maxBody + 1
+		if injected != nil && injected.Phase == fault.PhaseAfter {
+			if err := injected.Delay(r.Context()); err != nil {
+				return
+			}
+			if injected.Replaces() {
+				status, payload := injected.Response(req.ID)
+				s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(body), Forwarded: forwarded, Response: payload, Approval: approval}, injected)
+				s.writeRawJSON(w, status, payload)
+				return
+			}
+		}
+		if injected != nil && injected.TruncateSSEBytes > 0 && injected.TruncateSSEBytes < limit {
+			limit = injected.TruncateSSEBytes
+		}
 		ct := upstreamHTTPResp.Header.Get("Content-Type")
 		if ct == "" {
 			ct = "text/event-stream"
@@ -690,7 +1047,24 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 		}
 		w.WriteHeader(upstreamHTTPResp.StatusCode)
 
-		n, copyErr := io.Copy(flushingResponseWriter{w: w}, io.LimitReader(upstreamHTTPResp.Body, s.maxBody+1))
+		var out io.Writer = flushingResponseWriter{w: w}
+		var findings []record.Finding
+		var rewriter *eventRewriter
+		if rewrite, held := s.progressEvents(&req, s.relayEvents(r, upstreamHTTPResp, s.streamRewrite(&req, &findings))); rewrite != nil {
+			rewriter = newEventRewriter(out, rewrite, held)
+			out = rewriter
+		}
+		var capture *streamCapture
+		if s.recorder != nil {
+			capture = newStreamCapture()
+			out = io.MultiWriter(out, capture)
+		}
+		n, copyErr := io.Copy(out, io.LimitReader(upstreamHTTPResp.Body, limit))
+		if rewriter != nil {
+			if err := rewriter.Close(); err != nil && copyErr == nil {
+				copyErr = err
+			}
+		}
 		if copyErr != nil {
 			s.metrics.incUpstreamError()
 			s.logger.Printf("upstream stream copy failed: %v", copyErr)
@@ -698,11 +1072,25 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 		if n > s.maxBody {
 			s.metrics.incUpstreamError()
 			s.logger.Printf("upstream stream truncated at max-body=%d bytes", s.maxBody)
+		} else if limit <= s.maxBody && n == limit {
+			s.logger.Printf("upstream stream truncated by fault rule=%s at %d bytes", injected.Rule, limit)
+		}
+		if capture != nil && len(capture.events) > 0 {
+			s.appendRecord(r, record.Entry{
+				Kind:      record.EntryKindSSE,
+				Signature: sig,
+				Request:   json.RawMessage(body),
+				Forwarded: forwarded,
+				Response:  record.FinalResponse(capture.events),
+				Events:    capture.events,
+				Findings:  findings,
+				Approval:  approval,
+			}, injected)
 		}
 		return
 	}
 
-	upstreamResp, err := s.readUpstreamJSON(upstreamHTTPResp)
+	upstreamResp, err := s.readUpstreamResult(upstreamHTTPResp)
 	status := upstreamHTTPResp.StatusCode
 	if err != nil {
 		s.metrics.incUpstreamError()
@@ -714,19 +1102,33 @@ func (s *Server) handleSingle(w http.ResponseWriter, r *http.Request, body []byt
 			s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "upstream response too large", nil)
 			return
 		}
+		if errors.Is(err, errStreamNeedsClient) {
+			s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "upstream streaming response requires Accept: text/event-stream", nil)
+			return
+		}
 		s.writeJSONRPCError(w, req.ID, jsonrpc.ErrServer, "upstream error", nil)
 		return
 	}
 
-	if err := s.recorder.Append(sig, json.RawMessage(body), upstreamResp); err != nil {
-		s.logger.Printf("record append failed: %v", err)
+	s.observeShadow(r, &req, sig, forward, upstreamResp)
+
+	if injected != nil && injected.Phase == fault.PhaseAfter {
+		if err := injected.Delay(r.Context()); err != nil {
+			return
+		}
+		if injected.Replaces() {
+			status, upstreamResp = injected.Response(req.ID)
+		}
 	}
 
+	clientResp, findings := s.clientResponse(&req, s.checkToolResult(&req, upstreamResp))
+	s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(body), Forwarded: forwarded, Response: upstreamResp, Findings: findings, Approval: approval}, injected)
+
 	if notification {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	s.writeRawJSON(w, status, upstreamResp)
+	s.writeRawJSON(w, status, clientResp)
 }
 
 func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
@@ -756,6 +1158,14 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 				responses = append(responses, json.RawMessage(payload))
 				return
 			}
+			if isJSONRPCResponse(itemTrimmed) {
+				// Client answers to server requests get no response.
+				if err := s.routeClientResponse(r, itemTrimmed); err != nil {
+					s.metrics.incUpstreamError()
+					s.logger.Printf("client response routing failed: %v", err)
+				}
+				return
+			}
 
 			req := jsonrpc.Request{}
 			if err := json.Unmarshal(itemTrimmed, &req); err != nil {
@@ -780,8 +1190,36 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 				return
 			}
 
+			if rejected := s.checkMethod(&req); rejected != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, rejected.Code, rejected.Message, rejected.Data)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			if rejected := s.checkAccess(&req); rejected != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, rejected.Code, rejected.Message, rejected.Data)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			if !s.checkCancellation(r, &req) {
+				return
+			}
+
 			if s.replay != nil {
-				if resp, ok := s.replay.Lookup(&req, sig); ok {
+				if rejected := s.replayVersionMismatch(r, &req, sig); rejected != nil {
+					if len(req.ID) > 0 {
+						resp := jsonrpc.ErrorResponse(req.ID, rejected.Code, rejected.Message, rejected.Data)
+						payload, _ := json.Marshal(resp)
+						responses = append(responses, json.RawMessage(payload))
+					}
+					return
+				}
+				if resp, ok := s.replay.LookupScenario(requestScenario(r), &req, sig); ok {
 					s.metrics.incReplayHit()
 					if len(req.ID) > 0 {
 						replayResp, err := withResponseID(resp, req.ID)
@@ -790,7 +1228,8 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 							payload, _ := json.Marshal(resp)
 							responses = append(responses, json.RawMessage(payload))
 						} else {
-							responses = append(responses, replayResp)
+							clientResp, _ := s.clientResponse(&req, replayResp)
+							responses = append(responses, clientResp)
 						}
 					}
 					return
@@ -804,6 +1243,7 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 				}
 			}
 
+			forward := itemTrimmed
 			if req.Method == "tools/call" && s.validator != nil {
 				tool, args, err := parseToolCall(req.Params)
 				if err != nil {
@@ -836,6 +1276,68 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 					}
 					return
 				}
+				if forward, err = s.forwardBody(tool, itemTrimmed, decision); err != nil {
+					if len(req.ID) > 0 {
+						resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrServer, "validation error", nil)
+						payload, _ := json.Marshal(resp)
+						responses = append(responses, json.RawMessage(payload))
+					}
+					return
+				}
+			}
+
+			if forward, err = s.transformBody(&req, forward); err != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrServer, "transform error", nil)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			if forward, err = s.aliasBody(&req, forward); err != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrInvalidParams, "invalid tools/call params", nil)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			if rejected := s.checkAliasCall(&req, forward); rejected != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, rejected.Code, rejected.Message, rejected.Data)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			if forward, err = s.initializeBody(&req, forward); err != nil {
+				if len(req.ID) > 0 {
+					resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrInvalidParams, "invalid initialize params", nil)
+					payload, _ := json.Marshal(resp)
+					responses = append(responses, json.RawMessage(payload))
+				}
+				return
+			}
+			forwarded := forwardedRequest(itemTrimmed, forward)
+
+			// Items waiting for approval hold up the rest of the batch.
+			approval := s.awaitApproval(r, &req, forward)
+			if approval.Denied() {
+				payload := approvalError(req.ID, approval)
+				s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(itemTrimmed), Forwarded: forwarded, Response: payload, Approval: approval}, nil)
+				if len(req.ID) > 0 {
+					responses = append(responses, payload)
+				}
+				return
+			}
+
+			if localResp, ok := s.answerLocally(r.Context(), &req, forward); ok {
+				clientResp, findings := s.clientResponse(&req, s.checkToolResult(&req, localResp))
+				s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(itemTrimmed), Forwarded: forwarded, Response: localResp, Findings: findings, Approval: approval}, nil)
+				if len(req.ID) > 0 {
+					responses = append(responses, clientResp)
+				}
+				return
 			}
 
 			if s.upstream == nil {
@@ -847,7 +1349,25 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 				return
 			}
 
-			upstreamHTTPResp, err := s.doUpstream(r.Context(), r, itemTrimmed, false)
+			// Injected HTTP statuses cannot be expressed per batch item, so they
+			// surface as the injected JSON-RPC error payload only.
+			injected := s.pickFault(&req)
+			if injected != nil && injected.Phase == fault.PhaseBefore {
+				if err := injected.Delay(r.Context()); err != nil {
+					return
+				}
+				if injected.Replaces() {
+					_, payload := injected.Response(req.ID)
+					s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(itemTrimmed), Forwarded: forwarded, Response: payload, Approval: approval}, injected)
+					if len(req.ID) > 0 {
+						responses = append(responses, payload)
+					}
+					return
+				}
+			}
+
+			defer s.trackInflight(r, &req)()
+			upstreamHTTPResp, err := s.doUpstream(r.Context(), r, forward, false)
 			if err != nil {
 				s.metrics.incUpstreamError()
 				if len(req.ID) > 0 {
@@ -859,10 +1379,11 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 			}
 			defer upstreamHTTPResp.Body.Close()
 
-			// Streaming is intentionally unsupported for batch items. Treat any
-			// upstream streaming response as an upstream error to avoid returning
-			// non-JSON payloads to the batch client.
-			if isEventStreamContentType(upstreamHTTPResp.Header.Get("Content-Type")) {
+			// Streaming is intentionally unsupported for batch items. Unless the
+			// progress policy buffers streams into their final response, treat
+			// any upstream streaming response as an upstream error to avoid
+			// returning non-JSON payloads to the batch client.
+			if isEventStreamContentType(upstreamHTTPResp.Header.Get("Content-Type")) && !s.progress.BuffersStreams() {
 				s.metrics.incUpstreamError()
 				if len(req.ID) > 0 {
 					resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrServer, "upstream streaming not supported for batch", nil)
@@ -872,7 +1393,7 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 				return
 			}
 
-			upstreamResp, err := s.readUpstreamJSON(upstreamHTTPResp)
+			upstreamResp, err := s.readUpstreamResult(upstreamHTTPResp)
 			if err != nil {
 				s.metrics.incUpstreamError()
 				if len(req.ID) > 0 {
@@ -880,6 +1401,9 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 					if errors.Is(err, errUpstreamResponseTooLarge) {
 						msg = "upstream response too large"
 					}
+					if errors.Is(err, errStreamNeedsClient) {
+						msg = "upstream streaming not supported for batch"
+					}
 					resp := jsonrpc.ErrorResponse(req.ID, jsonrpc.ErrServer, msg, nil)
 					payload, _ := json.Marshal(resp)
 					responses = append(responses, json.RawMessage(payload))
@@ -888,11 +1412,19 @@ func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte
 			}
 
 			if len(upstreamResp) > 0 {
-				if err := s.recorder.Append(sig, json.RawMessage(itemTrimmed), upstreamResp); err != nil {
-					s.logger.Printf("record append failed: %v", err)
+				s.observeShadow(r, &req, sig, forward, upstreamResp)
+				if injected != nil && injected.Phase == fault.PhaseAfter {
+					if err := injected.Delay(r.Context()); err != nil {
+						return
+					}
+					if injected.Replaces() {
+						_, upstreamResp = injected.Response(req.ID)
+					}
 				}
+				clientResp, findings := s.clientResponse(&req, s.checkToolResult(&req, upstreamResp))
+				s.appendRecord(r, record.Entry{Signature: sig, Request: json.RawMessage(itemTrimmed), Forwarded: forwarded, Response: upstreamResp, Findings: findings, Approval: approval}, injected)
 				if len(req.ID) > 0 {
-					responses = append(responses, upstreamResp)
+					responses = append(responses, clientResp)
 				}
 				return
 			}
@@ -48,6 +48,27 @@ func TestHealthz(t *testing.T) {
 	}
 }
 
+func TestHealthzReportsReplayStats(t *testing.T) {
+	replay := mustReplayStore(t, map[string]json.RawMessage{
+		"sig-a": json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{}}`),
+	})
+	srv := NewServer(nil, nil, nil, replay, false, nil, nil, false, 1024, time.Second, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+
+	var body struct {
+		Replay *record.ReplayStats `json:"replay"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if body.Replay == nil || body.Replay.Files != 1 || body.Replay.Entries != 1 {
+		t.Fatalf("unexpected replay stats: %s", w.Body.String())
+	}
+}
+
 func TestMetricsz(t *testing.T) {
 	srv := NewServer(nil, nil, nil, nil, false, nil, nil, false, 1024, time.Second, nil)
 
@@ -171,6 +192,44 @@ func TestMetricsPromEnabledReturnsText(t *testing.T) {
 	}
 }
 
+func TestMetricsReportRedactionDetections(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"note":"use Bearer abcdefgh12345678"}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	redactor, err := record.NewRedactorFromPolicy(config.RecordPolicy{RedactDetectors: []string{"bearer_token"}})
+	if err != nil {
+		t.Fatalf("redactor: %v", err)
+	}
+	recordPath := t.TempDir() + "/records.ndjson"
+	rec := record.NewRecorder(recordPath, redactor, 0, 0)
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, rec, nil, false, nil, nil, true, 1024, time.Second, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	srv.ServeHTTP(httptest.NewRecorder(), r)
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read record: %v", err)
+	}
+	if strings.Contains(string(data), "abcdefgh12345678") {
+		t.Fatalf("token recorded verbatim: %s", data)
+	}
+	detections, _ := readMetrics(t, srv)["redaction_detections"].(map[string]any)
+	if got := metricValue(t, detections, "bearer_token"); got != 1 {
+		t.Fatalf("bearer_token detections=%d want=1", got)
+	}
+
+	pr := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	pw := httptest.NewRecorder()
+	srv.ServeHTTP(pw, pr)
+	if !strings.Contains(pw.Body.String(), `mcp_proxy_gateway_redaction_detections_total{detector="bearer_token"} 1`) {
+		t.Fatalf("missing detector series: %s", pw.Body.String())
+	}
+}
+
 func TestMetricsReplayHitAndMissCounters(t *testing.T) {
 	hitRequest := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping","params":{"q":"hit"}}`)
 	missRequest := json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"ping","params":{"q":"miss"}}`)
@@ -881,3 +940,50 @@ func metricMap(t *testing.T, m map[string]any, key string) map[string]any {
 	}
 	return val
 }
+
+func TestReplayScenarioSelectedByHeader(t *testing.T) {
+	dir := t.TempDir()
+	recordedReq := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping","params":{}}`)
+	sig := mustSig(t, recordedReq)
+
+	// Record the same request once without and once with a scenario header.
+	upstreamResult := "default"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"`+upstreamResult+`"}`)
+	}))
+	t.Cleanup(upstream.Close)
+	rec := record.NewRecorder(dir+"/records.ndjson", nil, 0, 0)
+	recSrv := NewServer(mustParseURL(t, upstream.URL), nil, rec, nil, false, nil, nil, false, 1024, time.Second, nil)
+	for _, scenario := range []string{"", "ci-job-2"} {
+		if scenario != "" {
+			upstreamResult = scenario
+		}
+		r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(recordedReq))
+		if scenario != "" {
+			r.Header.Set("X-MCP-Scenario", scenario)
+		}
+		recSrv.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	replay, err := record.LoadReplay(dir, record.ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	if _, ok := replay.LookupScenario("ci-job-2", nil, sig); !ok {
+		t.Fatalf("expected scenario-tagged recording")
+	}
+	srv := NewServer(nil, nil, nil, replay, true, nil, nil, false, 1024, time.Second, nil)
+
+	for scenario, want := range map[string]string{"": `"result":"default"`, "ci-job-2": `"result":"ci-job-2"`, "other": `"result":"default"`} {
+		r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(recordedReq))
+		if scenario != "" {
+			r.Header.Set("X-MCP-Scenario", scenario)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		if !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("scenario=%q expected %s, got=%s", scenario, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/respfilter"
+)
+
+// SetResponseFilter enables redaction of results returned to clients. A nil
+// filter disables it.
+func (s *Server) SetResponseFilter(f *respfilter.Filter) {
+	s.respFilter = f
+}
+
+// filterResponse applies the response filter to a JSON-RPC response about to
+// be returned for req, logging every match.
+func (s *Server) filterResponse(req *jsonrpc.Request, raw json.RawMessage) json.RawMessage {
+	if s.respFilter == nil || req == nil {
+		return raw
+	}
+	tool := requestTool(req)
+	out, res := s.respFilter.Apply(req.Method, tool, raw)
+	if res.Matched() {
+		s.logger.Printf("response filter %s: method=%s tool=%s matches=%v", res.Mode, req.Method, tool, res.Matches)
+	}
+	return out
+}
+
+// streamFilterActive reports whether the response filter applies to streams
+// for req.
+func (s *Server) streamFilterActive(req *jsonrpc.Request) bool {
+	return s.respFilter != nil && s.respFilter.Mode(req.Method, requestTool(req)) != respfilter.ModeOff
+}
+
+func (s *Server) writeResponseFilterProm(buf *bytes.Buffer) {
+	st := s.respFilter.Stats()
+	buf.WriteString("# HELP mcp_proxy_gateway_response_filter_total Total client responses with redaction matches by filter mode.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_response_filter_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_response_filter_total{mode=\"" + respfilter.ModeEnforce + "\"} ")
+	buf.WriteString(formatUint(st.MaskedTotal))
+	buf.WriteString("\n")
+	buf.WriteString("mcp_proxy_gateway_response_filter_total{mode=\"" + respfilter.ModeAudit + "\"} ")
+	buf.WriteString(formatUint(st.AuditedTotal))
+	buf.WriteString("\n")
+
+	writeLabeledCounters(buf, "mcp_proxy_gateway_response_filter_matches_total", "Client responses with redaction matches by tool (method for non tools/call requests).", "tool", st.MatchesByTool)
+}
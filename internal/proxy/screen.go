@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/screen"
+)
+
+// SetScreener enables content screening of tools/call results. A nil
+// screener disables it.
+func (s *Server) SetScreener(sc *screen.Screener) {
+	s.screener = sc
+}
+
+func (s *Server) screeningActive(req *jsonrpc.Request) bool {
+	return s.screener != nil && req.Method == "tools/call" && s.screener.Action(requestTool(req)) != screen.ActionOff
+}
+
+// screenToolResult screens the result of a tools/call response, logging
+// findings for the audit trail. The findings are returned for recording.
+func (s *Server) screenToolResult(req *jsonrpc.Request, raw json.RawMessage) (json.RawMessage, []record.Finding) {
+	if req == nil || !s.screeningActive(req) {
+		return raw, nil
+	}
+	tool := requestTool(req)
+	out, res := s.screener.Screen(tool, raw)
+	if len(res.Findings) > 0 {
+		parts := make([]string, 0, len(res.Findings))
+		for _, f := range res.Findings {
+			parts = append(parts, f.Detector+"@"+f.Path)
+		}
+		s.logger.Printf("content screening %s: tool=%s findings=[%s]", res.Action, tool, strings.Join(parts, " "))
+	}
+	return out, res.Findings
+}
+
+func (s *Server) writeScreeningProm(buf *bytes.Buffer) {
+	st := s.screener.Stats()
+	buf.WriteString("# HELP mcp_proxy_gateway_screening_flagged_total Total tool results with content screening findings.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_screening_flagged_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_screening_flagged_total ")
+	buf.WriteString(formatUint(st.FlaggedTotal))
+	buf.WriteString("\n")
+	buf.WriteString("# HELP mcp_proxy_gateway_screening_blocked_total Total tool results blocked by content screening.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_screening_blocked_total counter\n")
+	buf.WriteString("mcp_proxy_gateway_screening_blocked_total ")
+	buf.WriteString(formatUint(st.BlockedTotal))
+	buf.WriteString("\n")
+
+	writeLabeledCounters(buf, "mcp_proxy_gateway_screening_findings_total", "Content screening findings by detector.", "detector", st.FindingsByDetector)
+}
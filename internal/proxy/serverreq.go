@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/serverreq"
+)
+
+// sessionHeader identifies the upstream session of streamable HTTP servers.
+const sessionHeader = "Mcp-Session-Id"
+
+// SetServerRequests enables relaying of server-to-client requests in
+// passthrough streams. A nil relay copies them through untouched.
+func (s *Server) SetServerRequests(r *serverreq.Relay) {
+	s.serverRequests = r
+}
+
+// relayEvents combines the response rewrite of a passthrough stream with
+// relaying of server-to-client requests. It returns nil when the stream can
+// be copied untouched.
+func (s *Server) relayEvents(r *http.Request, upstream *http.Response, rewrite func(string) string) func(string) (string, bool) {
+	if s.serverRequests == nil {
+		if rewrite == nil {
+			return nil
+		}
+		return func(data string) (string, bool) {
+			return rewrite(data), true
+		}
+	}
+	route := serverreq.Route{Header: s.routeHeader(r, upstream), Client: clientIdentity(r)}
+	return func(data string) (string, bool) {
+		if cancelled, ok := s.serverRequests.Cancel(json.RawMessage(data), route); ok {
+			s.metrics.incCancel(cancelUpstream)
+			return string(cancelled), true
+		}
+		if !serverreq.IsRequest([]byte(data)) {
+			if rewrite != nil {
+				data = rewrite(data)
+			}
+			return data, true
+		}
+		deliver, denial, err := s.serverRequests.Intercept(json.RawMessage(data), route)
+		if err != nil {
+			s.logger.Printf("server request relay failed: %v", err)
+			return data, true
+		}
+		if denial == nil {
+			return string(deliver), true
+		}
+		s.logger.Printf("server request denied: %s", denial)
+		s.appendRecord(r, record.Entry{Kind: record.EntryKindServerRequest, Request: json.RawMessage(data), Response: denial}, nil)
+		if err := s.postUpstream(r.Context(), route.Header, denial); err != nil {
+			s.metrics.incUpstreamError()
+			s.logger.Printf("server request denial not delivered: %v", err)
+		}
+		return "", false
+	}
+}
+
+// routeHeader returns the headers for POSTs answering server requests of a
+// stream: the forwarded client headers plus the upstream session id.
+func (s *Server) routeHeader(in *http.Request, upstream *http.Response) http.Header {
+	h := s.clientRouteHeader(in)
+	if id := upstream.Header.Get(sessionHeader); id != "" {
+		h.Set(sessionHeader, id)
+	}
+	return h
+}
+
+// clientRouteHeader returns the headers for gateway-initiated POSTs on behalf
+// of a client: its credentials, session, protocol version, and the forwarded
+// headers.
+func (s *Server) clientRouteHeader(in *http.Request) http.Header {
+	h := http.Header{}
+	keys := []string{"Authorization", sessionHeader, protocolVersionHeader}
+	for k := range s.forwardHeaders {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		for _, v := range in.Header.Values(k) {
+			h.Add(k, v)
+		}
+	}
+	return h
+}
+
+// postUpstream sends a client-side JSON-RPC response to the upstream.
+func (s *Server) postUpstream(ctx context.Context, header http.Header, body []byte) error {
+	if s.upstream == nil {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.upstream.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vals := range header {
+		for _, v := range vals {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, s.maxBody))
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("upstream status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// handleClientResponse accepts a client's answer to a server request.
+func (s *Server) handleClientResponse(w http.ResponseWriter, r *http.Request, body []byte) {
+	if err := s.routeClientResponse(r, body); err != nil {
+		s.metrics.incUpstreamError()
+		s.logger.Printf("client response routing failed: %v", err)
+		http.Error(w, "upstream error", http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// routeClientResponse forwards a client response upstream. Answers to
+// requests relayed to this client get their upstream id back, go to the
+// session that asked, and are recorded with the request; others are
+// forwarded as sent with the client's own headers.
+func (s *Server) routeClientResponse(r *http.Request, body []byte) error {
+	header := s.clientRouteHeader(r)
+	if restored, p, ok := s.serverRequests.Resolve(body, clientIdentity(r)); ok {
+		body, header = restored, p.Header
+		s.appendRecord(r, record.Entry{
+			Kind:      record.EntryKindServerRequest,
+			Request:   p.Request,
+			Forwarded: p.Delivered,
+			Response:  restored,
+		}, nil)
+	}
+	return s.postUpstream(r.Context(), header, body)
+}
+
+func (s *Server) writeServerRequestProm(buf *bytes.Buffer) {
+	st := s.serverRequests.Stats()
+	buf.WriteString("# HELP mcp_proxy_gateway_server_requests_pending Relayed server requests waiting for a client response.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_server_requests_pending gauge\n")
+	buf.WriteString("mcp_proxy_gateway_server_requests_pending ")
+	buf.WriteString(formatUint(uint64(st.Pending)))
+	buf.WriteString("\n")
+
+	writeLabeledCounters(buf, "mcp_proxy_gateway_server_requests_total", "Server-to-client requests by outcome.", "outcome", map[string]uint64{
+		"relayed":   st.Relayed,
+		"denied":    st.Denied,
+		"capped":    st.Capped,
+		"routed":    st.Routed,
+		"expired":   st.Expired,
+		"cancelled": st.Cancelled,
+	})
+}
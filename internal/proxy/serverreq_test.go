@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/serverreq"
+)
+
+type upstreamPost struct {
+	body    string
+	session string
+}
+
+func TestServerRequestsAreRelayedAndRoutedBack(t *testing.T) {
+	posts := make(chan upstreamPost, 4)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"method"`) {
+			posts <- upstreamPost{body: string(body), session: r.Header.Get("Mcp-Session-Id")}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Mcp-Session-Id", "sess-1")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{"messages":[],"maxTokens":5000}}`+"\n\n")
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":8,"method":"elicitation/create","params":{"message":"password?"}}`+"\n\n")
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":1,"result":{"content":[]}}`+"\n\n")
+	}))
+	t.Cleanup(upstream.Close)
+
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetServerRequests(serverreq.New(config.ServerRequestPolicy{Deny: []string{"elicitation/*"}, MaxTokens: 256}))
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"think"}}`))
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	stream := w.Body.String()
+	var gatewayID string
+	for _, line := range strings.Split(stream, "\n") {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && json.Unmarshal([]byte(data), &msg) == nil && msg.Method == "sampling/createMessage" {
+			gatewayID = string(msg.ID)
+		}
+	}
+	if !strings.HasPrefix(gatewayID, `"mcpgw-`) || !strings.Contains(stream, `"maxTokens":256`) {
+		t.Fatalf("expected relayed and capped sampling request, got=%s", stream)
+	}
+	if strings.Contains(stream, "elicitation") {
+		t.Fatalf("denied request reached the client: %s", stream)
+	}
+	select {
+	case p := <-posts:
+		if !strings.Contains(p.body, `"id":8`) || !strings.Contains(p.body, `"code":-32601`) || p.session != "sess-1" {
+			t.Fatalf("unexpected denial posted upstream: %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("denial not posted upstream")
+	}
+
+	answer := []byte(`{"jsonrpc":"2.0","id":` + gatewayID + `,"result":{"role":"assistant","content":{"type":"text","text":"hi"}}}`)
+	// Another client cannot answer the request or borrow its session.
+	other := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(answer))
+	other.Header.Set("Authorization", "Bearer other")
+	srv.ServeHTTP(httptest.NewRecorder(), other)
+	select {
+	case p := <-posts:
+		if !strings.Contains(p.body, gatewayID) || p.session != "" {
+			t.Fatalf("foreign client response was routed to the session: %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("foreign client response not forwarded as sent")
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(answer)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for client response, got %d body=%s", w.Code, w.Body.String())
+	}
+	select {
+	case p := <-posts:
+		if !strings.Contains(p.body, `"id":7`) || p.session != "sess-1" {
+			t.Fatalf("client response not routed to the upstream session: %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("client response not posted upstream")
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	var kinds []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry record.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		kinds = append(kinds, entry.Kind)
+		if entry.Kind == record.EntryKindServerRequest && strings.Contains(string(entry.Request), "sampling") {
+			if !strings.Contains(string(entry.Response), `"id":7`) || !strings.Contains(string(entry.Forwarded), `"maxTokens":256`) {
+				t.Fatalf("unexpected server request entry: %s", line)
+			}
+		}
+	}
+	if strings.Join(kinds, ",") != "server_request,sse,server_request" {
+		t.Fatalf("unexpected recorded kinds: %v", kinds)
+	}
+}
+
+func TestKeepaliveCommentsSurviveRelayedPassthrough(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, ": ping\n\n")
+		_, _ = fmt.Fprint(w, `data: {"jsonrpc":"2.0","id":1,"result":{"content":[]}}`+"\n\n")
+	}))
+	t.Cleanup(upstream.Close)
+
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, false, 1<<16, 5*time.Second, nil)
+	srv.SetServerRequests(serverreq.New(config.ServerRequestPolicy{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"slow"}}`))
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if stream := w.Body.String(); !strings.HasPrefix(stream, ": ping\n\n") || !strings.Contains(stream, `"result"`) {
+		t.Fatalf("expected keepalive before the result, got=%q", stream)
+	}
+}
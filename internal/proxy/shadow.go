@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/shadow"
+)
+
+// SetShadow enables shadow comparisons of live upstream responses. A nil
+// comparer disables them.
+func (s *Server) SetShadow(c *shadow.Comparer) {
+	s.shadow = c
+}
+
+// observeShadow hands a live upstream response to the shadow comparer. It
+// must be called with the unmodified upstream payload, before any fault or
+// response rewriting.
+func (s *Server) observeShadow(r *http.Request, req *jsonrpc.Request, sig string, body []byte, live json.RawMessage) {
+	if s.shadow == nil || isNotification(req) {
+		return
+	}
+	s.shadow.Observe(shadow.Observation{
+		Request:   req,
+		Signature: sig,
+		Scenario:  requestScenario(r),
+		Body:      body,
+		Header:    s.forwardedHeaders(r),
+		Live:      live,
+	})
+}
+
+// forwardedHeaders returns the subset of in's headers that doUpstream would
+// forward, so mirrored requests authenticate the same way.
+func (s *Server) forwardedHeaders(in *http.Request) http.Header {
+	out := http.Header{}
+	if in == nil {
+		return out
+	}
+	if vals := in.Header.Values("Authorization"); len(vals) > 0 {
+		out["Authorization"] = append([]string(nil), vals...)
+	}
+	for h := range s.forwardHeaders {
+		if vals := in.Header.Values(h); len(vals) > 0 {
+			out[h] = append([]string(nil), vals...)
+		}
+	}
+	return out
+}
+
+func (s *Server) writeShadowProm(buf *bytes.Buffer) {
+	st := s.shadow.Stats()
+	buf.WriteString("# HELP mcp_proxy_gateway_shadow_comparisons_total Total shadow comparisons by result.\n")
+	buf.WriteString("# TYPE mcp_proxy_gateway_shadow_comparisons_total counter\n")
+	for _, row := range []struct {
+		result string
+		value  uint64
+	}{
+		{shadow.StatusMatch, st.MatchedTotal},
+		{shadow.StatusDiverged, st.DivergedTotal},
+		{shadow.StatusMissing, st.MissingTotal},
+		{shadow.StatusError, st.ErrorsTotal},
+		{shadow.StatusSkipped, st.SkippedTotal},
+	} {
+		buf.WriteString("mcp_proxy_gateway_shadow_comparisons_total{result=\"" + row.result + "\"} ")
+		buf.WriteString(formatUint(row.value))
+		buf.WriteString("\n")
+	}
+
+	writeLabeledCounters(buf, "mcp_proxy_gateway_shadow_divergences_total", "Shadow divergences by tool (method for non tools/call requests).", "tool", st.DivergedByTool)
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/shadow"
+)
+
+func TestShadowComparesLiveResponseAgainstRecordings(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":7,"result":{"hits":3}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	reqBody := json.RawMessage(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"tool":"search","arguments":{"q":"x"}}}`)
+	baseline := mustReplayStore(t, map[string]json.RawMessage{
+		mustSig(t, reqBody): json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"hits":2}}`),
+	})
+	cmp, err := shadow.New(shadow.Options{Replay: baseline})
+	if err != nil {
+		t.Fatalf("new comparer: %v", err)
+	}
+
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, nil, nil, false, nil, nil, true, 1024, time.Second, nil)
+	srv.SetShadow(cmp)
+
+	for _, body := range [][]byte{reqBody, []byte("[" + string(reqBody) + "]")} {
+		r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		if !strings.Contains(w.Body.String(), `"hits":3`) {
+			t.Fatalf("expected live response to reach client, got=%s", w.Body.String())
+		}
+	}
+
+	metrics := readMetrics(t, srv)
+	stats, ok := metrics["shadow"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing shadow metrics: %v", metrics)
+	}
+	if got := metricValue(t, stats, "diverged_total"); got != 2 {
+		t.Fatalf("diverged_total=%d want=2", got)
+	}
+	byTool, _ := stats["diverged_by_tool"].(map[string]any)
+	if got := metricValue(t, byTool, "search"); got != 2 {
+		t.Fatalf("diverged_by_tool.search=%d want=2", got)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), `mcp_proxy_gateway_shadow_divergences_total{tool="search"} 2`) {
+		t.Fatalf("missing shadow divergence series: %s", w.Body.String())
+	}
+}
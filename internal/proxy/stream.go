@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/progress"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/sse"
+)
+
+// SetReplayStreamTiming makes stream replay honor the recorded inter-event
+// offsets instead of emitting all events immediately.
+func (s *Server) SetReplayStreamTiming(enabled bool) {
+	s.replayTiming = enabled
+}
+
+// streamCapture observes passthrough stream bytes and collects the parsed
+// events with their offsets from the start of the stream.
+type streamCapture struct {
+	start    time.Time
+	splitter *sse.Splitter
+	events   []record.StreamEvent
+}
+
+func newStreamCapture() *streamCapture {
+	c := &streamCapture{start: time.Now()}
+	c.splitter = sse.NewSplitter(func(ev sse.Event) {
+		c.events = append(c.events, record.StreamEvent{
+			OffsetMs: time.Since(c.start).Milliseconds(),
+			Event:    ev.Event,
+			ID:       ev.ID,
+			Data:     ev.Data,
+		})
+	})
+	return c
+}
+
+func (c *streamCapture) Write(p []byte) (int, error) {
+	return c.splitter.Write(p)
+}
+
+// streamRewrite returns the rewrite applied to the data of events in live
+// passthrough streams for req, or nil when the stream can be copied
+// untouched. JSON-RPC responses get the full client response pipeline;
+// notifications only go through the response filter. Screening findings are
+// appended to findings.
+func (s *Server) streamRewrite(req *jsonrpc.Request, findings *[]record.Finding) func(string) string {
+	checkOutput := s.validator.HasOutputSchema(requestTool(req))
+	rewriteList := (s.aliases != nil || s.localTools != nil) && req.Method == "tools/list"
+	rewriteInit := s.handshake != nil && req.Method == "initialize"
+	filter := s.streamFilterActive(req)
+	if !checkOutput && !rewriteList && !rewriteInit && !s.catalogFilterActive(req) && !s.screeningActive(req) && !filter {
+		return nil
+	}
+	return func(data string) string {
+		if !isJSONRPCResponse([]byte(data)) {
+			if filter {
+				return string(s.filterResponse(req, json.RawMessage(data)))
+			}
+			return data
+		}
+		resp := json.RawMessage(data)
+		if checkOutput {
+			resp = s.checkToolResult(req, resp)
+		}
+		resp, found := s.clientResponse(req, resp)
+		*findings = append(*findings, found...)
+		return string(resp)
+	}
+}
+
+// eventRewriter parses passthrough stream bytes into events and writes the
+// events their rewritten data expands to: none drops the event, and earlier
+// entries are written as plain data events before the event itself. Comments,
+// including keepalives, are passed through so idle streams stay open across
+// intermediaries. Progress notifications held by the throttle are written
+// when their interval expires and on Close.
+type eventRewriter struct {
+	w        io.Writer
+	splitter *sse.Splitter
+	held     *progress.Stream
+
+	mu     sync.Mutex
+	err    error
+	closed bool
+}
+
+func newEventRewriter(w io.Writer, rewrite func(string) []string, held *progress.Stream) *eventRewriter {
+	e := &eventRewriter{w: w, held: held}
+	e.splitter = sse.NewSplitter(func(ev sse.Event) {
+		out := rewrite(ev.Data)
+		if len(out) == 0 {
+			return
+		}
+		e.writeData(out[:len(out)-1])
+		ev.Data = out[len(out)-1]
+		e.write(ev.Bytes())
+	})
+	e.splitter.OnComment(func(comment string) {
+		e.write([]byte(":" + comment + "\n\n"))
+	})
+	held.OnExpire(func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if !e.closed {
+			e.writeData(held.Expired())
+		}
+	})
+	return e
+}
+
+func (e *eventRewriter) write(p []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(p)
+	}
+}
+
+func (e *eventRewriter) writeData(data []string) {
+	for _, d := range data {
+		e.write(sse.Event{Data: d}.Bytes())
+	}
+}
+
+func (e *eventRewriter) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, _ := e.splitter.Write(p)
+	return n, e.err
+}
+
+// Close emits a trailing event that was not terminated by a blank line and
+// any held progress notification.
+func (e *eventRewriter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.splitter.Flush()
+	e.writeData(e.held.Flush())
+	e.closed = true
+	return e.err
+}
+
+func (s *Server) writeReplayStream(w http.ResponseWriter, r *http.Request, events []record.StreamEvent, req *jsonrpc.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	out := flushingResponseWriter{w: w}
+	var last int64
+	for _, ev := range events {
+		if s.replayTiming && ev.OffsetMs > last {
+			timer := time.NewTimer(time.Duration(ev.OffsetMs-last) * time.Millisecond)
+			select {
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		last = ev.OffsetMs
+
+		data := ev.Data
+		if isJSONRPCResponse([]byte(data)) {
+			if rewritten, err := withResponseID(json.RawMessage(data), req.ID); err == nil {
+				data = string(rewritten)
+			}
+			clientResp, _ := s.clientResponse(req, json.RawMessage(data))
+			data = string(clientResp)
+		} else {
+			data = string(s.filterResponse(req, json.RawMessage(data)))
+		}
+		if _, err := out.Write(sse.Event{ID: ev.ID, Event: ev.Event, Data: data}.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+// isJSONRPCResponse reports whether raw is a JSON-RPC response object (as
+// opposed to a request or notification sharing the same stream).
+func isJSONRPCResponse(raw []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return false
+	}
+	return msg.Method == "" && len(msg.ID) > 0 && (len(msg.Result) > 0 || len(msg.Error) > 0)
+}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,7 +16,7 @@ import (
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
 )
 
-func TestSSEPassthroughStreamsAndSkipsRecord(t *testing.T) {
+func TestSSEPassthroughStreamsAndRecordsEvents(t *testing.T) {
 	t.Parallel()
 
 	upstreamSawAuth := make(chan string, 1)
@@ -83,13 +84,84 @@ func TestSSEPassthroughStreamsAndSkipsRecord(t *testing.T) {
 		t.Fatalf("upstream Authorization=%q", got)
 	}
 
-	if _, err := os.Stat(recordPath); err == nil {
-		t.Fatalf("expected streamed request to skip recorder append, but record file exists at %s", recordPath)
-	} else if !os.IsNotExist(err) {
-		t.Fatalf("stat recordPath: %v", err)
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read record: %v", err)
+	}
+	entry := record.Entry{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("unmarshal record: %v", err)
+	}
+	if entry.Kind != record.EntryKindSSE {
+		t.Fatalf("expected sse entry kind, got=%q", entry.Kind)
+	}
+	if len(entry.Events) != 2 || entry.Events[0].Data != "hello" || entry.Events[1].Data != "done" {
+		t.Fatalf("unexpected recorded events: %+v", entry.Events)
+	}
+}
+
+func TestReplayStreamReemitsRecordedEvents(t *testing.T) {
+	t.Parallel()
+
+	recordedReq := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"web.search","arguments":{"query":"hello"}}}`)
+	file := filepath.Join(t.TempDir(), "records.ndjson")
+	rec := record.NewRecorder(file, nil, 0, 0)
+	events := []record.StreamEvent{
+		{OffsetMs: 0, Data: `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`},
+		{OffsetMs: 30, Event: "message", Data: `{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`},
+	}
+	if err := rec.AppendEntry(record.Entry{
+		Kind:      record.EntryKindSSE,
+		Signature: mustSig(t, recordedReq),
+		Request:   recordedReq,
+		Response:  record.FinalResponse(events),
+		Events:    events,
+	}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	replay, err := record.LoadReplay(file, record.ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+
+	srv := NewServer(nil, nil, nil, replay, true, nil, nil, false, 4096, time.Second, nil)
+	srv.SetReplayStreamTiming(true)
+
+	liveReq := []byte(`{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"tool":"web.search","arguments":{"query":"hello"}}}`)
+	r := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(liveReq))
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	srv.ServeHTTP(w, r)
+
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected recorded timing to be honored, elapsed=%s", elapsed)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content-type=%q", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"method":"notifications/progress"`) {
+		t.Fatalf("expected progress event, got=%q", body)
+	}
+	if !strings.Contains(body, "event: message\n") || !strings.Contains(body, `"id":9`) {
+		t.Fatalf("expected final event with rewritten id, got=%q", body)
+	}
+
+	// Clients that do not request SSE get the final response as plain JSON.
+	r = httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(liveReq))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content-type=%q body=%s", ct, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"result":{"ok":true}`) {
+		t.Fatalf("expected final response, got=%s", w.Body.String())
 	}
 }
 
+// Without progress.buffer_streams the gateway refuses to turn a stream into a
+// JSON response; see TestBufferedStreamAnswersJSONClient for the opt-in.
 func TestUnexpectedSSEWithoutClientAcceptReturnsJSONRPCError(t *testing.T) {
 	t.Parallel()
 
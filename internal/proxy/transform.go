@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/transform"
+)
+
+// SetTransformer enables per-tool argument rewriting before calls are
+// forwarded upstream. A nil transformer disables it.
+func (s *Server) SetTransformer(t *transform.Transformer) {
+	s.transformer = t
+}
+
+// transformBody applies argument transforms to the body about to be
+// forwarded for req, which may already differ from the client's request
+// (e.g. masked PII), and logs the changes.
+func (s *Server) transformBody(req *jsonrpc.Request, body []byte) ([]byte, error) {
+	if s.transformer == nil || req.Method != "tools/call" {
+		return body, nil
+	}
+	var msg struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	tool, args, err := parseToolCall(msg.Params)
+	if err != nil {
+		return body, nil
+	}
+	out, changes, err := s.transformer.Apply(tool, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return body, nil
+	}
+	s.logger.Printf("transform: tool=%s changes=%v", tool, changes)
+	return withToolArguments(body, out)
+}
+
+// forwardedRequest returns the upstream request for recording when it
+// differs from the client's request, or nil.
+func forwardedRequest(body, forward []byte) json.RawMessage {
+	if bytes.Equal(body, forward) {
+		return nil
+	}
+	return json.RawMessage(forward)
+}
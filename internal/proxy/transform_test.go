@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/signature"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/transform"
+)
+
+func TestTransformRewritesForwardedArgumentsAndRecordsBoth(t *testing.T) {
+	var upstreamBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	max := 10.0
+	tr, err := transform.New(map[string]config.ToolEntry{
+		"docs.search": {Transform: config.TransformPolicy{Ops: []config.TransformOp{
+			{Op: transform.OpClamp, Path: "$.params.arguments.max_results", Max: &max},
+			{Op: transform.OpSet, Path: "$.params.arguments.workspace_root", Value: "/srv/ws"},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("transformer: %v", err)
+	}
+	recordPath := filepath.Join(t.TempDir(), "records.ndjson")
+	srv := NewServer(mustParseURL(t, upstream.URL), nil, record.NewRecorder(recordPath, nil, 0, 0), nil, false, nil, nil, true, 1<<16, 5*time.Second, nil)
+	srv.SetTransformer(tr)
+
+	reqBody := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"docs.search","arguments":{"query":"go","max_results":50,"workspace_root":"/"}}}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(reqBody)))
+	if !strings.Contains(w.Body.String(), `"ok":true`) {
+		t.Fatalf("expected upstream result, got=%s", w.Body.String())
+	}
+	if !strings.Contains(upstreamBody, `"max_results":10`) || !strings.Contains(upstreamBody, `"workspace_root":"/srv/ws"`) {
+		t.Fatalf("expected rewritten upstream body, got=%s", upstreamBody)
+	}
+
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		t.Fatalf("read records: %v", err)
+	}
+	var entry record.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !strings.Contains(string(entry.Request), `"max_results":50`) || !strings.Contains(string(entry.Forwarded), `"max_results":10`) {
+		t.Fatalf("expected original request and forwarded request, got request=%s forwarded=%s", entry.Request, entry.Forwarded)
+	}
+	var req jsonrpc.Request
+	_ = json.Unmarshal([]byte(reqBody), &req)
+	if sig, _ := signature.FromRequest(&req); entry.Signature != sig {
+		t.Fatalf("signature should be computed from the client request")
+	}
+}
@@ -0,0 +1,187 @@
+package record
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+// valueDetector masks secrets found inside string values. Patterns may mark
+// the sensitive part with a capture group named "secret"; otherwise the whole
+// match is masked. keepPrefix leading characters of the masked part are kept
+// so redacted values stay recognizable (e.g. "AKIA[REDACTED]").
+type valueDetector struct {
+	name       string
+	patterns   []*regexp.Regexp
+	keepPrefix int
+	hits       atomic.Uint64
+}
+
+type builtinDetector struct {
+	patterns   []string
+	keepPrefix int
+}
+
+var builtinDetectors = map[string]builtinDetector{
+	"jwt": {
+		patterns:   []string{`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`},
+		keepPrefix: 3,
+	},
+	"aws_access_key": {
+		patterns:   []string{`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`},
+		keepPrefix: 4,
+	},
+	"aws_secret_key": {
+		patterns: []string{`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`},
+	},
+	"pem_private_key": {
+		patterns: []string{`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?P<secret>[\s\S]+?)-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`},
+	},
+	"bearer_token": {
+		patterns:   []string{`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{8,}=*)`},
+		keepPrefix: 3,
+	},
+	"url_credentials": {
+		patterns: []string{
+			`(?i)\b[a-z][a-z0-9+.-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`,
+			`(?i)[?&](?:api_?key|access_?token|auth_?token|token|secret|password|passwd|sig|signature)=(?P<secret>[^&#\s"']+)`,
+		},
+	},
+}
+
+// BuiltinDetectorNames lists the detectors accepted by record.redact_detectors.
+func BuiltinDetectorNames() []string {
+	names := make([]string, 0, len(builtinDetectors))
+	for name := range builtinDetectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newValueDetectors(builtins []string, custom []config.ValuePatternPolicy) ([]*valueDetector, error) {
+	var out []*valueDetector
+	seen := map[string]struct{}{}
+	add := func(name string, patterns []string, keepPrefix int) error {
+		if _, dup := seen[name]; dup {
+			return nil
+		}
+		seen[name] = struct{}{}
+		d := &valueDetector{name: name, keepPrefix: keepPrefix}
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+			}
+			d.patterns = append(d.patterns, re)
+		}
+		out = append(out, d)
+		return nil
+	}
+
+	for _, name := range builtins {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		names := []string{name}
+		if name == "all" {
+			names = BuiltinDetectorNames()
+		} else if _, ok := builtinDetectors[name]; !ok {
+			return nil, fmt.Errorf("unknown redact detector %q (known: %s)", name, strings.Join(BuiltinDetectorNames(), ", "))
+		}
+		for _, n := range names {
+			b := builtinDetectors[n]
+			if err := add(n, b.patterns, b.keepPrefix); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, p := range custom {
+		if err := add(p.Name, []string{p.Regex}, p.KeepPrefix); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// mask returns s with every detection masked and the number of detections.
+func (d *valueDetector) mask(s, replacement string) (string, int) {
+	found := 0
+	for _, re := range d.patterns {
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		group := re.SubexpIndex("secret")
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if group > 0 && m[2*group] >= 0 {
+				start, end = m[2*group], m[2*group+1]
+			}
+			secret := s[start:end]
+			if strings.HasSuffix(secret, replacement) {
+				// Already masked by an earlier pass.
+				continue
+			}
+			keep := d.keepPrefix
+			if keep >= len(secret) {
+				keep = 0
+			}
+			b.WriteString(s[last : start+keep])
+			b.WriteString(replacement)
+			last = end
+			found++
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s, found
+}
+
+// maskString runs every detector over s, counting detections per detector
+// on the redactor and, when counts is non-nil, under "detector:<name>".
+func (r *Redactor) maskString(s string, counts map[string]int) string {
+	for _, d := range r.detectors {
+		masked, n := d.mask(s, r.replacement)
+		if n == 0 {
+			continue
+		}
+		d.hits.Add(uint64(n))
+		if counts != nil {
+			counts["detector:"+d.name] += n
+		}
+		s = masked
+	}
+	return s
+}
+
+// detectedIn returns the names of detectors that match s without masking it.
+func (r *Redactor) detectedIn(s string) []string {
+	var names []string
+	for _, d := range r.detectors {
+		if _, n := d.mask(s, r.replacement); n > 0 {
+			names = append(names, d.name)
+		}
+	}
+	return names
+}
+
+// DetectorCounts returns how many values each value detector has masked
+// since the redactor was created.
+func (r *Redactor) DetectorCounts() map[string]uint64 {
+	if r == nil || len(r.detectors) == 0 {
+		return nil
+	}
+	out := make(map[string]uint64, len(r.detectors))
+	for _, d := range r.detectors {
+		out[d.name] = d.hits.Load()
+	}
+	return out
+}
@@ -0,0 +1,382 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// ReadEntries parses every entry in source (a file, directory, or glob,
+// resolved like --replay) in replay precedence order. Untagged entries in
+// "<scenario>@<label>.ndjson" files get the file's scenario, as on replay.
+func ReadEntries(source string) ([]Entry, error) {
+	files, err := resolveReplayFiles(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found at %q", source)
+	}
+	var out []Entry
+	for _, f := range files {
+		fileScenario := ScenarioFromFileName(f)
+		err := scanLines(f, func(lineNo int, line []byte) error {
+			entry := Entry{}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if entry.Scenario == "" {
+				entry.Scenario = fileScenario
+			}
+			out = append(out, entry)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	return out, nil
+}
+
+// WriteEntries writes entries as NDJSON without redacting or re-stamping
+// them.
+func WriteEntries(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEntriesFile atomically replaces path with entries (see
+// writeFileAtomic).
+func WriteEntriesFile(path string, entries []Entry) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return WriteEntries(w, entries)
+	})
+}
+
+// entryKey identifies the replay slot an entry occupies. Entries without a
+// signature (method/tool templates) have no key and are never considered
+// duplicates.
+func entryKey(e Entry) (string, bool) {
+	if e.Signature == "" {
+		return "", false
+	}
+	return e.Scenario + "\x00" + e.Signature, true
+}
+
+// sameEntryPayload reports whether two entries would replay identically.
+func sameEntryPayload(a, b Entry) bool {
+	if !sameJSON(a.Response, b.Response) || len(a.Events) != len(b.Events) {
+		return false
+	}
+	for i := range a.Events {
+		if a.Events[i].Data != b.Events[i].Data {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// EntryDiff describes how one signature differs between two recordings.
+type EntryDiff struct {
+	Status      string                `json:"status"`
+	Signature   string                `json:"signature"`
+	Scenario    string                `json:"scenario,omitempty"`
+	Method      string                `json:"method,omitempty"`
+	Tool        string                `json:"tool,omitempty"`
+	Differences []jsondiff.Difference `json:"differences,omitempty"`
+}
+
+// DiffEntries compares two recordings by scenario and signature. Only the
+// entry replay would serve (the first per key) is compared on each side.
+// Response differences are structural; ignore skips volatile response paths.
+// Stream entries whose final responses match but whose events differ are
+// reported with a single "$events" difference.
+func DiffEntries(base, next []Entry, ignore []jsondiff.PathPattern) ([]EntryDiff, error) {
+	baseByKey := map[string]Entry{}
+	for _, e := range base {
+		if k, ok := entryKey(e); ok {
+			if _, exists := baseByKey[k]; !exists {
+				baseByKey[k] = e
+			}
+		}
+	}
+
+	var out []EntryDiff
+	seenNext := map[string]struct{}{}
+	for _, e := range next {
+		k, ok := entryKey(e)
+		if !ok {
+			continue
+		}
+		if _, dup := seenNext[k]; dup {
+			continue
+		}
+		seenNext[k] = struct{}{}
+		prev, existed := baseByKey[k]
+		if !existed {
+			out = append(out, newEntryDiff(DiffAdded, e))
+			continue
+		}
+		diffs, err := diffResponses(prev.Response, e.Response, ignore)
+		if err != nil {
+			return nil, fmt.Errorf("signature %s: %w", e.Signature, err)
+		}
+		if len(diffs) == 0 && !sameEventData(prev.Events, e.Events) {
+			diffs = append(diffs, jsondiff.Difference{Path: "$events", Kind: DiffChanged, Baseline: len(prev.Events), Live: len(e.Events)})
+		}
+		if len(diffs) > 0 {
+			d := newEntryDiff(DiffChanged, e)
+			d.Differences = diffs
+			out = append(out, d)
+		}
+	}
+
+	seenBase := map[string]struct{}{}
+	for _, e := range base {
+		k, ok := entryKey(e)
+		if !ok {
+			continue
+		}
+		if _, dup := seenBase[k]; dup {
+			continue
+		}
+		seenBase[k] = struct{}{}
+		if _, kept := seenNext[k]; !kept {
+			out = append(out, newEntryDiff(DiffRemoved, e))
+		}
+	}
+	return out, nil
+}
+
+func diffResponses(base, next json.RawMessage, ignore []jsondiff.PathPattern) ([]jsondiff.Difference, error) {
+	if len(base) == 0 {
+		base = json.RawMessage("null")
+	}
+	if len(next) == 0 {
+		next = json.RawMessage("null")
+	}
+	return jsondiff.Diff(base, next, ignore)
+}
+
+func sameEventData(a, b []StreamEvent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Data != b[i].Data || a[i].Event != b[i].Event {
+			return false
+		}
+	}
+	return true
+}
+
+func newEntryDiff(status string, e Entry) EntryDiff {
+	method, tool := entryMethodTool(e)
+	return EntryDiff{Status: status, Signature: e.Signature, Scenario: e.Scenario, Method: method, Tool: tool}
+}
+
+func entryMethodTool(e Entry) (string, string) {
+	req := jsonrpc.Request{}
+	if len(e.Request) == 0 || json.Unmarshal(e.Request, &req) != nil {
+		return "", ""
+	}
+	if req.Method != "tools/call" {
+		return req.Method, ""
+	}
+	tool, _ := extractToolName(req.Params)
+	return req.Method, tool
+}
+
+// MergeConflict decides what happens when both recordings hold a different
+// response for the same scenario and signature.
+type MergeConflict string
+
+const (
+	// MergeKeepOld keeps the entry from the earlier recording.
+	MergeKeepOld MergeConflict = "keep-old"
+	// MergeKeepNew replaces it with the entry from the later recording.
+	MergeKeepNew MergeConflict = "keep-new"
+	// MergeKeepBoth keeps both as a sequence: the later entry follows the
+	// earlier one and both are numbered (Entry.Sequence), so replay serves
+	// the earlier response first and the later one to the next request.
+	MergeKeepBoth MergeConflict = "keep-both-as-sequence"
+)
+
+func ParseMergeConflict(s string) (MergeConflict, error) {
+	switch c := MergeConflict(s); c {
+	case MergeKeepOld, MergeKeepNew, MergeKeepBoth:
+		return c, nil
+	}
+	return "", fmt.Errorf("conflict policy must be %s, %s, or %s", MergeKeepOld, MergeKeepNew, MergeKeepBoth)
+}
+
+type MergeStats struct {
+	Added      int `json:"added"`
+	Identical  int `json:"identical"`
+	Conflicts  int `json:"conflicts"`
+	Replaced   int `json:"replaced"`
+	Sequenced  int `json:"sequenced"`
+	Unkeyed    int `json:"unkeyed"`
+	TotalAfter int `json:"total_after"`
+}
+
+// MergeEntries merges next into base. Entries new to base are appended in
+// order; identical entries are dropped; conflicts follow policy. Entries
+// without a signature are always appended.
+func MergeEntries(base, next []Entry, policy MergeConflict) ([]Entry, MergeStats) {
+	stats := MergeStats{}
+	out := append([]Entry(nil), base...)
+	// first is the entry replay serves first per key; last is where a
+	// sequence continues, so sequenced entries stay contiguous.
+	first := map[string]int{}
+	last := map[string]int{}
+	for i, e := range out {
+		if k, ok := entryKey(e); ok {
+			if _, exists := first[k]; !exists {
+				first[k] = i
+			}
+			last[k] = i
+		}
+	}
+
+	for _, e := range next {
+		k, ok := entryKey(e)
+		if !ok {
+			stats.Unkeyed++
+			out = append(out, e)
+			continue
+		}
+		i, exists := first[k]
+		if !exists {
+			stats.Added++
+			out = append(out, e)
+			first[k] = len(out) - 1
+			last[k] = len(out) - 1
+			continue
+		}
+		if sameEntryPayload(out[i], e) || sameEntryPayload(out[last[k]], e) {
+			stats.Identical++
+			continue
+		}
+		stats.Conflicts++
+		switch policy {
+		case MergeKeepNew:
+			stats.Replaced++
+			out[i] = e
+		case MergeKeepBoth:
+			stats.Sequenced++
+			if out[i].Sequence == 0 {
+				out[i].Sequence = 1
+			}
+			e.Sequence = out[last[k]].Sequence + 1
+			at := last[k] + 1
+			out = append(out[:at], append([]Entry{e}, out[at:]...)...)
+			for _, idx := range []map[string]int{first, last} {
+				for key, pos := range idx {
+					if pos >= at {
+						idx[key] = pos + 1
+					}
+				}
+			}
+			last[k] = at
+		}
+	}
+	stats.TotalAfter = len(out)
+	return out, stats
+}
+
+// DedupeEntries drops every entry whose scenario, signature, and sequence
+// position appeared earlier, keeping the one replay would serve. conflicts
+// counts dropped entries whose response differed from the kept one.
+func DedupeEntries(entries []Entry) (out []Entry, removed, conflicts int) {
+	first := map[string]Entry{}
+	for _, e := range entries {
+		k, ok := entryKey(e)
+		if !ok {
+			out = append(out, e)
+			continue
+		}
+		k += "\x00" + strconv.Itoa(e.Sequence)
+		if kept, dup := first[k]; dup {
+			removed++
+			if !sameEntryPayload(kept, e) {
+				conflicts++
+			}
+			continue
+		}
+		first[k] = e
+		out = append(out, e)
+	}
+	return out, removed, conflicts
+}
+
+// EntryFilter selects entries; zero fields match everything. Since and Until
+// bound the entry time (inclusive) and exclude entries without a parseable
+// time when set.
+type EntryFilter struct {
+	Methods []string
+	Tools   []string
+	Since   time.Time
+	Until   time.Time
+}
+
+func (f EntryFilter) Match(e Entry) bool {
+	if len(f.Methods) > 0 || len(f.Tools) > 0 {
+		method, tool := entryMethodTool(e)
+		if len(f.Methods) > 0 && !containsString(f.Methods, method) {
+			return false
+		}
+		if len(f.Tools) > 0 && !containsString(f.Tools, tool) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && t.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && t.After(f.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+func FilterEntries(entries []Entry, f EntryFilter) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
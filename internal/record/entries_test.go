@@ -0,0 +1,138 @@
+package record
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+)
+
+func TestDiffEntriesBySignature(t *testing.T) {
+	base := []Entry{
+		mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search","arguments":{"q":"a"}}}`, `{"jsonrpc":"2.0","id":1,"result":{"hits":1,"ts":1}}`),
+		mustEntry(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, `{"jsonrpc":"2.0","id":2,"result":{}}`),
+		mustEntry(t, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, `{"jsonrpc":"2.0","id":3,"result":{"tools":[]}}`),
+	}
+	next := []Entry{
+		mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search","arguments":{"q":"a"}}}`, `{"jsonrpc":"2.0","id":1,"result":{"hits":2,"ts":9}}`),
+		mustEntry(t, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, `{"jsonrpc":"2.0","id":3,"result":{"tools":[]}}`),
+		mustEntry(t, `{"jsonrpc":"2.0","id":4,"method":"resources/list"}`, `{"jsonrpc":"2.0","id":4,"result":{}}`),
+	}
+	ts, _ := jsondiff.ParsePath("$.result.ts")
+
+	diffs, err := DiffEntries(base, next, []jsondiff.PathPattern{ts})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("got %+v", diffs)
+	}
+	if diffs[0].Status != DiffChanged || diffs[0].Tool != "search" || len(diffs[0].Differences) != 1 || diffs[0].Differences[0].Path != "$.result.hits" {
+		t.Fatalf("unexpected changed diff: %+v", diffs[0])
+	}
+	if diffs[1].Status != DiffAdded || diffs[1].Method != "resources/list" {
+		t.Fatalf("unexpected added diff: %+v", diffs[1])
+	}
+	if diffs[2].Status != DiffRemoved || diffs[2].Method != "ping" {
+		t.Fatalf("unexpected removed diff: %+v", diffs[2])
+	}
+}
+
+func TestMergeEntriesConflictPolicies(t *testing.T) {
+	a := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":1,"result":{"v":1}}`)
+	b := mustEntry(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+	aNew := a
+	aNew.Response = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"v":2}}`)
+	c := mustEntry(t, `{"jsonrpc":"2.0","id":3,"method":"resources/list"}`, `{"jsonrpc":"2.0","id":3,"result":{}}`)
+
+	cases := []struct {
+		policy MergeConflict
+		want   []string
+	}{
+		{MergeKeepOld, []string{`{"v":1}`, `{}`, `{}`}},
+		{MergeKeepNew, []string{`{"v":2}`, `{}`, `{}`}},
+		{MergeKeepBoth, []string{`{"v":1}`, `{"v":2}`, `{}`, `{}`}},
+	}
+	for _, tc := range cases {
+		merged, stats := MergeEntries([]Entry{a, b}, []Entry{b, aNew, c}, tc.policy)
+		if stats.Added != 1 || stats.Identical != 1 || stats.Conflicts != 1 {
+			t.Fatalf("%s: unexpected stats %+v", tc.policy, stats)
+		}
+		if len(merged) != len(tc.want) {
+			t.Fatalf("%s: got %d entries", tc.policy, len(merged))
+		}
+		for i, w := range tc.want {
+			var resp struct {
+				Result json.RawMessage `json:"result"`
+			}
+			_ = json.Unmarshal(merged[i].Response, &resp)
+			if string(resp.Result) != w {
+				t.Fatalf("%s: entry %d result=%s want %s", tc.policy, i, resp.Result, w)
+			}
+		}
+	}
+}
+
+func TestDedupeAndFilterEntries(t *testing.T) {
+	a := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search"}}`, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	a.Time = "2026-01-01T00:00:00Z"
+	dupConflict := a
+	dupConflict.Response = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"x":1}}`)
+	b := mustEntry(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+	b.Time = "2026-03-01T00:00:00Z"
+
+	out, removed, conflicts := DedupeEntries([]Entry{a, b, a, dupConflict})
+	if len(out) != 2 || removed != 2 || conflicts != 1 {
+		t.Fatalf("dedupe: len=%d removed=%d conflicts=%d", len(out), removed, conflicts)
+	}
+
+	if got := FilterEntries(out, EntryFilter{Tools: []string{"search"}}); len(got) != 1 || got[0].Signature != a.Signature {
+		t.Fatalf("tool filter: %+v", got)
+	}
+	if got := FilterEntries(out, EntryFilter{Methods: []string{"ping"}}); len(got) != 1 || got[0].Signature != b.Signature {
+		t.Fatalf("method filter: %+v", got)
+	}
+	since := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := FilterEntries(out, EntryFilter{Since: since}); len(got) != 1 || got[0].Signature != b.Signature {
+		t.Fatalf("time filter: %+v", got)
+	}
+}
+
+func TestReadAndWriteEntriesFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flaky@records.ndjson")
+	e := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":1,"result":{"u":"<a&b>"}}`)
+	writeFixture(t, path, e)
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	entries, err := ReadEntries(dir)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Scenario != "flaky" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if err := WriteEntriesFile(path, entries); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Mode().Perm() != 0o600 {
+		t.Fatalf("mode=%v want 0600", st.Mode().Perm())
+	}
+	again, err := ReadEntries(path)
+	if err != nil || len(again) != 1 || !sameJSON(again[0].Response, e.Response) {
+		t.Fatalf("round trip: %+v err=%v", again, err)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("temporary files left behind: %v", files)
+	}
+}
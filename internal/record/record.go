@@ -1,22 +1,121 @@
 package record
 
 import (
-	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
-
-	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
 )
 
+// EntryKindSSE marks entries captured from a streamed (text/event-stream)
+// upstream response. Plain JSON exchanges leave Kind empty.
+const EntryKindSSE = "sse"
+
+// EntryKindServerRequest marks a request the upstream sent to the client
+// (Request) with the client's answer (Response). Replay ignores them.
+const EntryKindServerRequest = "server_request"
+
 type Entry struct {
 	Time      string          `json:"time"`
+	Kind      string          `json:"kind,omitempty"`
 	Signature string          `json:"signature"`
 	Request   json.RawMessage `json:"request"`
 	Response  json.RawMessage `json:"response"`
+
+	// Forwarded is the request as sent upstream when the gateway rewrote it
+	// (PII masking, argument transforms). Signature is always computed from
+	// Request, the request the client sent.
+	Forwarded json.RawMessage `json:"forwarded,omitempty"`
+
+	// Events holds the captured stream for sse entries. Response carries the
+	// final JSON-RPC response found in the stream, if any, so the entry can
+	// also be replayed to clients that do not request SSE.
+	Events []StreamEvent `json:"events,omitempty"`
+
+	// Scenario scopes the entry for replay; see ReplayStore.LookupScenario.
+	Scenario string `json:"scenario,omitempty"`
+
+	// Fault names the fault injection rule that produced or altered the
+	// response. Replay ignores faulted entries.
+	Fault string `json:"fault,omitempty"`
+
+	// Findings lists content screening matches in the upstream result.
+	Findings []Finding `json:"findings,omitempty"`
+
+	// Approval holds the operator decision for calls that required approval.
+	// Replay ignores calls that were not approved.
+	Approval *Approval `json:"approval,omitempty"`
+
+	// ProtocolVersion is the MCP protocol version of the exchange: the
+	// negotiated version for initialize, otherwise the request's
+	// MCP-Protocol-Version header.
+	ProtocolVersion string `json:"protocol_version,omitempty"`
+
+	// Sequence is the 1-based position of the entry among responses
+	// recorded for the same scenario and signature (see MergeKeepBoth).
+	// Replay serves sequenced entries in order, one per matching request.
+	Sequence int `json:"sequence,omitempty"`
+}
+
+// Finding is one content screening match, located by JSON path in the
+// recorded response.
+type Finding struct {
+	Detector string `json:"detector"`
+	Path     string `json:"path"`
+	Excerpt  string `json:"excerpt,omitempty"`
+}
+
+// Approval outcomes.
+const (
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+	ApprovalTimedOut = "timed_out"
+	ApprovalCanceled = "canceled"
+)
+
+// Approval is the audit record of a human approval decision.
+type Approval struct {
+	ID      string `json:"id"`
+	Rule    string `json:"rule"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	WaitMs  int64  `json:"wait_ms"`
+}
+
+// Denied reports whether a call that required approval was not approved.
+func (a *Approval) Denied() bool {
+	return a != nil && a.Outcome != ApprovalApproved
+}
+
+// StreamEvent is one captured server-sent event. OffsetMs is measured from
+// the start of the upstream response so replay can reproduce the original
+// pacing.
+type StreamEvent struct {
+	OffsetMs int64  `json:"offset_ms"`
+	Event    string `json:"event,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Data     string `json:"data"`
+}
+
+// FinalResponse returns the last event payload that looks like a JSON-RPC
+// response (an object with an id and a result or error).
+func FinalResponse(events []StreamEvent) json.RawMessage {
+	for i := len(events) - 1; i >= 0; i-- {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(events[i].Data), &msg); err != nil {
+			continue
+		}
+		if len(msg.ID) > 0 && (len(msg.Result) > 0 || len(msg.Error) > 0) {
+			return json.RawMessage(events[i].Data)
+		}
+	}
+	return nil
 }
 
 type Recorder struct {
@@ -41,31 +140,23 @@ func NewRecorder(path string, redactor *Redactor, maxBytes int64, maxFiles int)
 }
 
 func (r *Recorder) Append(signature string, request, response json.RawMessage) error {
+	return r.AppendEntry(Entry{Signature: signature, Request: request, Response: response})
+}
+
+// AppendEntry redacts and writes a fully populated entry. Time is filled in
+// when empty.
+func (r *Recorder) AppendEntry(entry Entry) error {
 	if r == nil {
 		return nil
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	req := request
-	resp := response
-	if r.redactor != nil {
-		var err error
-		req, err = r.redactor.Apply(request)
-		if err != nil {
-			return err
-		}
-		resp, err = r.redactor.Apply(response)
-		if err != nil {
-			return err
-		}
+	if _, err := r.redactor.redactEntry(&entry, nil); err != nil {
+		return err
 	}
-
-	entry := Entry{
-		Time:      time.Now().UTC().Format(time.RFC3339Nano),
-		Signature: signature,
-		Request:   req,
-		Response:  resp,
+	if entry.Time == "" {
+		entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
 	}
 	data, err := json.Marshal(entry)
 	if err != nil {
@@ -88,6 +179,15 @@ func (r *Recorder) Append(signature string, request, response json.RawMessage) e
 	return err
 }
 
+// DetectorCounts reports per-detector value redaction counts for entries
+// written so far; nil when no value detectors are configured.
+func (r *Recorder) DetectorCounts() map[string]uint64 {
+	if r == nil {
+		return nil
+	}
+	return r.redactor.DetectorCounts()
+}
+
 func (r *Recorder) maybeRotate(nextWriteBytes int64) error {
 	if r == nil || r.maxBytes <= 0 {
 		return nil
@@ -150,130 +250,3 @@ func renameReplace(src, dst string) error {
 	}
 	return nil
 }
-
-type ReplayStore struct {
-	match       ReplayMatch
-	bySignature map[string]json.RawMessage
-	byMethod    map[string]json.RawMessage
-	byTool      map[string]json.RawMessage
-}
-
-type ReplayMatch string
-
-const (
-	ReplayMatchSignature ReplayMatch = "signature"
-	ReplayMatchMethod    ReplayMatch = "method"
-	ReplayMatchTool      ReplayMatch = "tool"
-)
-
-func LoadReplay(path string, match ReplayMatch) (*ReplayStore, error) {
-	if path == "" {
-		return nil, nil
-	}
-	if match == "" {
-		match = ReplayMatchSignature
-	}
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	store := &ReplayStore{
-		match:       match,
-		bySignature: map[string]json.RawMessage{},
-		byMethod:    map[string]json.RawMessage{},
-		byTool:      map[string]json.RawMessage{},
-	}
-	scanner := bufio.NewScanner(file)
-	// Entries can be large (request + response bodies). Increase the scanner limit
-	// to avoid failing on valid recordings.
-	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		entry := Entry{}
-		if err := json.Unmarshal(line, &entry); err != nil {
-			return nil, err
-		}
-		if entry.Signature == "" || len(entry.Response) == 0 {
-			continue
-		}
-		if _, exists := store.bySignature[entry.Signature]; !exists {
-			store.bySignature[entry.Signature] = entry.Response
-		}
-
-		if len(entry.Request) == 0 {
-			continue
-		}
-		req := jsonrpc.Request{}
-		if err := json.Unmarshal(entry.Request, &req); err != nil {
-			continue
-		}
-		if req.Method != "" {
-			if _, exists := store.byMethod[req.Method]; !exists {
-				store.byMethod[req.Method] = entry.Response
-			}
-		}
-		if req.Method == "tools/call" {
-			tool, err := extractToolName(req.Params)
-			if err == nil && tool != "" {
-				if _, exists := store.byTool[tool]; !exists {
-					store.byTool[tool] = entry.Response
-				}
-			}
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-	return store, nil
-}
-
-func (r *ReplayStore) Lookup(req *jsonrpc.Request, signature string) (json.RawMessage, bool) {
-	if r == nil {
-		return nil, false
-	}
-	switch r.match {
-	case ReplayMatchMethod:
-		if req == nil || req.Method == "" {
-			return nil, false
-		}
-		resp, ok := r.byMethod[req.Method]
-		return resp, ok
-	case ReplayMatchTool:
-		if req == nil || req.Method != "tools/call" {
-			return nil, false
-		}
-		tool, err := extractToolName(req.Params)
-		if err != nil || tool == "" {
-			return nil, false
-		}
-		resp, ok := r.byTool[tool]
-		return resp, ok
-	default:
-		if signature == "" {
-			return nil, false
-		}
-		resp, ok := r.bySignature[signature]
-		return resp, ok
-	}
-}
-
-func extractToolName(params json.RawMessage) (string, error) {
-	if len(params) == 0 {
-		return "", errors.New("missing params")
-	}
-	var data struct {
-		Tool string `json:"tool"`
-	}
-	if err := json.Unmarshal(params, &data); err != nil {
-		return "", err
-	}
-	if data.Tool == "" {
-		return "", errors.New("missing tool")
-	}
-	return data.Tool, nil
-}
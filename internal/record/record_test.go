@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
@@ -252,3 +253,42 @@ func TestRecorder_RotationWithZeroBackupsDeletesActiveFile(t *testing.T) {
 		t.Fatalf("did not expect %s.1 to exist", path)
 	}
 }
+
+func TestReplayStoreStreamEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	redactor, err := NewRedactor([]string{"token"}, nil)
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	rec := NewRecorder(path, redactor, 0, 0)
+	if err := rec.AppendEntry(Entry{
+		Kind:      EntryKindSSE,
+		Signature: "stream-only",
+		Request:   json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping"}`),
+		Events: []StreamEvent{
+			{OffsetMs: 0, Data: `{"jsonrpc":"2.0","method":"notifications/progress","params":{"token":"t"}}`},
+			{OffsetMs: 5, Data: "not json"},
+		},
+	}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	store, err := LoadReplay(path, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	req := &jsonrpc.Request{JSONRPC: "2.0", Method: "ping"}
+	if _, ok := store.Lookup(req, "stream-only"); ok {
+		t.Fatalf("expected JSON lookup miss for stream without final response")
+	}
+	events, ok := store.LookupStream(req, "stream-only")
+	if !ok || len(events) != 2 {
+		t.Fatalf("expected stream hit, got=%v ok=%v", events, ok)
+	}
+	if !bytes.Contains([]byte(events[0].Data), []byte(`"token":"[REDACTED]"`)) {
+		t.Fatalf("expected event data to be redacted, got=%s", events[0].Data)
+	}
+	if events[1].Data != "not json" {
+		t.Fatalf("expected non-JSON data to be kept, got=%q", events[1].Data)
+	}
+}
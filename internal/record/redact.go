@@ -4,14 +4,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
 )
 
 const defaultRedactionReplacement = "[REDACTED]"
 
 type Redactor struct {
-	keys        map[string]struct{}
-	keyRegex    []*regexp.Regexp
-	replacement string
+	keys      map[string]struct{}
+	keyRegex  []*regexp.Regexp
+	detectors []*valueDetector
+	rules     []pathRule
+	// pseudonymKey keys the HMAC used by pseudonymize rules.
+	pseudonymKey []byte
+	replacement  string
 }
 
 func NewRedactor(redactKeys, redactKeyRegex []string) (*Redactor, error) {
@@ -45,6 +54,39 @@ func NewRedactor(redactKeys, redactKeyRegex []string) (*Redactor, error) {
 	return r, nil
 }
 
+// NewRedactorFromPolicy builds a redactor from the path rules, key rules, and
+// value detectors in policy. It returns nil when nothing is configured.
+func NewRedactorFromPolicy(policy config.RecordPolicy) (*Redactor, error) {
+	r, err := NewRedactor(policy.RedactKeys, policy.RedactKeyRegex)
+	if err != nil {
+		return nil, err
+	}
+	detectors, err := newValueDetectors(policy.RedactDetectors, policy.RedactValuePatterns)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := newPathRules(policy.RedactRules)
+	if err != nil {
+		return nil, err
+	}
+	key, err := pseudonymKey(policy, rules)
+	if err != nil {
+		return nil, err
+	}
+	if len(detectors) == 0 && len(rules) == 0 {
+		return r, nil
+	}
+	if r == nil {
+		r = &Redactor{keys: map[string]struct{}{}, replacement: defaultRedactionReplacement}
+	}
+	r.detectors = detectors
+	r.rules = rules
+	r.pseudonymKey = key
+	return r, nil
+}
+
+// Apply runs key rules and value detectors over raw. Path rules are scoped to
+// an exchange and only run when whole entries are recorded or rewritten.
 func (r *Redactor) Apply(raw json.RawMessage) (json.RawMessage, error) {
 	if r == nil || len(raw) == 0 {
 		return raw, nil
@@ -53,7 +95,7 @@ func (r *Redactor) Apply(raw json.RawMessage) (json.RawMessage, error) {
 	if err := json.Unmarshal(raw, &v); err != nil {
 		return nil, err
 	}
-	r.redactValue(v)
+	v = r.redactValue(v, nil)
 	out, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
@@ -61,23 +103,33 @@ func (r *Redactor) Apply(raw json.RawMessage) (json.RawMessage, error) {
 	return json.RawMessage(out), nil
 }
 
-func (r *Redactor) redactValue(v any) {
+// redactValue replaces matching keys and masks detected secrets in string
+// values, returning the (possibly replaced) value. When counts is non-nil it
+// is incremented per key for every value that was not already redacted, and
+// per detector for every masked secret.
+func (r *Redactor) redactValue(v any, counts map[string]int) any {
 	switch vv := v.(type) {
 	case map[string]any:
 		for k, child := range vv {
 			if r.matchesKey(k) {
+				if s, ok := child.(string); counts != nil && (!ok || s != r.replacement) {
+					counts[k]++
+				}
 				vv[k] = r.replacement
 				continue
 			}
-			r.redactValue(child)
+			vv[k] = r.redactValue(child, counts)
 		}
 	case []any:
 		for i := range vv {
-			r.redactValue(vv[i])
+			vv[i] = r.redactValue(vv[i], counts)
+		}
+	case string:
+		if len(r.detectors) > 0 {
+			return r.maskString(vv, counts)
 		}
-	default:
-		return
 	}
+	return v
 }
 
 func (r *Redactor) matchesKey(k string) bool {
@@ -91,3 +143,44 @@ func (r *Redactor) matchesKey(k string) bool {
 	}
 	return false
 }
+
+// Unredacted returns the JSON paths of keys in raw that the redactor would
+// redact but whose values are not already the redaction placeholder, and of
+// string values in which a value detector finds a secret.
+func (r *Redactor) Unredacted(raw json.RawMessage) ([]string, error) {
+	if r == nil || len(raw) == 0 {
+		return nil, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	var out []string
+	r.findUnredacted(v, "$", &out)
+	sort.Strings(out)
+	return out, nil
+}
+
+func (r *Redactor) findUnredacted(v any, path string, out *[]string) {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, child := range vv {
+			childPath := path + "." + k
+			if r.matchesKey(k) {
+				if s, ok := child.(string); !ok || s != r.replacement {
+					*out = append(*out, childPath)
+				}
+				continue
+			}
+			r.findUnredacted(child, childPath, out)
+		}
+	case []any:
+		for i := range vv {
+			r.findUnredacted(vv[i], path+"["+strconv.Itoa(i)+"]", out)
+		}
+	case string:
+		if names := r.detectedIn(vv); len(names) > 0 {
+			*out = append(*out, path+" ("+strings.Join(names, ", ")+")")
+		}
+	}
+}
@@ -2,6 +2,10 @@ package record
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -46,3 +50,101 @@ func TestNewRedactor_InvalidRegex(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestRedactFileRewritesOnlyUnredactedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson.1")
+	clean := `{"time":"t","signature":"a","request":{"jsonrpc":"2.0","id":1,"method":"ping"},"response":{"jsonrpc":"2.0","id":1,"result":{}}}`
+	already := `{"time":"t","signature":"b","request":{"jsonrpc":"2.0","id":2,"method":"ping","params":{"token":"[REDACTED]"}},"response":null}`
+	leaky := `{"time":"t","signature":"c","request":{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"tool":"x","arguments":{"token":"abc","nested":[{"api_key":"k1"},{"api_key":"k2"}]}}},"response":{"jsonrpc":"2.0","id":3,"result":{}},"events":[{"offset_ms":0,"data":"{\"token\":\"zzz\"}"}]}`
+	if err := os.WriteFile(path, []byte(clean+"\n"+already+"\n"+leaky+"\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	redactor, err := NewRedactor([]string{"token", "api_key"}, nil)
+	if err != nil {
+		t.Fatalf("redactor: %v", err)
+	}
+
+	stats, err := RedactFile(path, redactor, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if stats.Entries != 3 || stats.EntriesChanged != 1 || stats.Keys["token"] != 2 || stats.Keys["api_key"] != 2 {
+		t.Fatalf("unexpected dry-run stats: %+v", stats)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"abc"`) {
+		t.Fatalf("dry run modified the file")
+	}
+
+	if _, err := RedactFile(path, redactor, false); err != nil {
+		t.Fatalf("redact: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || lines[0] != clean || lines[1] != already {
+		t.Fatalf("untouched lines were rewritten: %q", lines)
+	}
+	for _, secret := range []string{"abc", "k1", "k2", "zzz"} {
+		if strings.Contains(lines[2], secret) {
+			t.Fatalf("secret %q left in %s", secret, lines[2])
+		}
+	}
+	if st, _ := os.Stat(path); st.Mode().Perm() != 0o600 {
+		t.Fatalf("file mode changed to %v", st.Mode().Perm())
+	}
+
+	stats, err = RedactFile(path, redactor, false)
+	if err != nil || stats.Redacted() != 0 {
+		t.Fatalf("second pass should be a no-op: %+v err=%v", stats, err)
+	}
+}
+
+func TestRedactFileMalformedLineLeavesFileUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	original := `{"signature":"a","request":{"token":"abc"}}` + "\n{broken\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	redactor, _ := NewRedactor([]string{"token"}, nil)
+	if _, err := RedactFile(path, redactor, false); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line 2 error, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != original {
+		t.Fatalf("file modified after error: %s", data)
+	}
+}
+
+func TestRedactFileRefusesFileChangedByRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	rec := NewRecorder(path, nil, 0, 0)
+	if err := rec.Append("a", json.RawMessage(`{"token":"abc"}`), json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if err := checkUnchanged(path, before); err != nil {
+		t.Fatalf("untouched file reported as changed: %v", err)
+	}
+	if err := rec.Append("b", json.RawMessage(`{"token":"def"}`), json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := checkUnchanged(path, before); !errors.Is(err, errFileChanged) {
+		t.Fatalf("expected errFileChanged after an append, got %v", err)
+	}
+
+	// A rotation replaces the file even when the new one has the same size.
+	before, _ = os.Stat(path)
+	data, _ := os.ReadFile(path)
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := checkUnchanged(path, before); !errors.Is(err, errFileChanged) {
+		t.Fatalf("expected errFileChanged after a rotation, got %v", err)
+	}
+}
@@ -0,0 +1,622 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// ReplayStore serves recorded responses. Its source may be a single NDJSON
+// file, a directory of fixture files, or a glob; Reload rebuilds the indexes
+// from the current source contents and swaps them in atomically.
+type ReplayStore struct {
+	match  ReplayMatch
+	source string
+	index  atomic.Pointer[replayIndex]
+
+	mu          sync.Mutex
+	fingerprint string
+	reloads     uint64
+	lastErr     string
+
+	// Template render failures turn lookups into misses; they are counted
+	// and the last one kept so they show up in /healthz.
+	templateErrors    atomic.Uint64
+	lastTemplateError atomic.Pointer[string]
+}
+
+type replayIndex struct {
+	scenarios map[string]*scenarioIndex
+
+	files     int
+	entries   int
+	conflicts int
+	loadedAt  time.Time
+}
+
+// scenarioIndex holds the lookup tables for one scenario. Untagged entries
+// live under DefaultScenario.
+type scenarioIndex struct {
+	bySignature map[string]*replayItem
+	byMethod    map[string]*replayItem
+	byTool      map[string]*replayItem
+
+	// Template entries without a signature cover every call to a tool (or
+	// method) and are consulted after the regular match fails.
+	templateByMethod map[string]*replayItem
+	templateByTool   map[string]*replayItem
+}
+
+type replayItem struct {
+	response        json.RawMessage
+	events          []StreamEvent
+	template        templateNode
+	protocolVersion string
+
+	// sequence is the entry's Entry.Sequence. The first sequenced entry for
+	// a key heads seq, the entries in order, and served counts the lookups
+	// it has answered; the last entry repeats once the sequence is used up.
+	sequence int
+	seq      []*replayItem
+	served   atomic.Uint64
+}
+
+// current returns the entry the next lookup serves.
+func (i *replayItem) current() *replayItem {
+	if i == nil || len(i.seq) == 0 {
+		return i
+	}
+	n := i.served.Load()
+	if n >= uint64(len(i.seq)) {
+		n = uint64(len(i.seq) - 1)
+	}
+	return i.seq[n]
+}
+
+// advance moves a sequence on to its next entry after a served lookup.
+func (i *replayItem) advance() {
+	if len(i.seq) > 0 {
+		i.served.Add(1)
+	}
+}
+
+// ReplayStats describes the currently loaded replay index.
+type ReplayStats struct {
+	Files     int    `json:"files"`
+	Entries   int    `json:"entries"`
+	Conflicts int    `json:"conflicts"`
+	Scenarios int    `json:"scenarios"`
+	LoadedAt  string `json:"loaded_at"`
+	Reloads   uint64 `json:"reloads"`
+	LastError string `json:"last_error,omitempty"`
+
+	TemplateErrors    uint64 `json:"template_errors"`
+	LastTemplateError string `json:"last_template_error,omitempty"`
+}
+
+type ReplayMatch string
+
+const (
+	ReplayMatchSignature ReplayMatch = "signature"
+	ReplayMatchMethod    ReplayMatch = "method"
+	ReplayMatchTool      ReplayMatch = "tool"
+)
+
+// DefaultScenario is the scenario of untagged entries. Lookups for any
+// scenario fall through to it when the scenario has no matching entry.
+const DefaultScenario = ""
+
+func newReplayIndex() *replayIndex {
+	return &replayIndex{scenarios: map[string]*scenarioIndex{}}
+}
+
+func (idx *replayIndex) scenario(name string) *scenarioIndex {
+	si, ok := idx.scenarios[name]
+	if !ok {
+		si = &scenarioIndex{
+			bySignature:      map[string]*replayItem{},
+			byMethod:         map[string]*replayItem{},
+			byTool:           map[string]*replayItem{},
+			templateByMethod: map[string]*replayItem{},
+			templateByTool:   map[string]*replayItem{},
+		}
+		idx.scenarios[name] = si
+	}
+	return si
+}
+
+// ScenarioFromFileName derives a scenario tag from a fixture file name of the
+// form "<scenario>@<label>.ndjson". Other names yield DefaultScenario.
+func ScenarioFromFileName(path string) string {
+	base := filepath.Base(path)
+	if at := strings.Index(base, "@"); at > 0 {
+		return base[:at]
+	}
+	return DefaultScenario
+}
+
+// LoadReplay loads replay entries from path, which may name a file, a
+// directory (all *.ndjson and *.jsonl files in it), or a glob pattern.
+// Files are read in lexical path order and the first entry for a given key
+// within a scenario wins, so earlier files take precedence over later ones.
+// Sequenced entries (Entry.Sequence) are the exception: they are served in
+// order, one per lookup, and the last one repeats.
+// Entries without a scenario field inherit one from the file name (see
+// ScenarioFromFileName). A source that matches no recording files is an
+// error, as it is for Verify; later reloads may leave the store empty.
+func LoadReplay(path string, match ReplayMatch) (*ReplayStore, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if match == "" {
+		match = ReplayMatchSignature
+	}
+	store := &ReplayStore{match: match, source: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	if store.index.Load().files == 0 {
+		return nil, fmt.Errorf("no recordings found at %q", path)
+	}
+	return store, nil
+}
+
+// Reload re-reads every source file and atomically replaces the indexes. On
+// failure the previous indexes stay in place.
+func (r *ReplayStore) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files, err := resolveReplayFiles(r.source)
+	if err == nil {
+		var fingerprint string
+		fingerprint, err = fingerprintFiles(files)
+		if err == nil {
+			var idx *replayIndex
+			idx, err = buildReplayIndex(files)
+			if err == nil {
+				r.index.Store(idx)
+				r.fingerprint = fingerprint
+				r.reloads++
+				r.lastErr = ""
+				return nil
+			}
+		}
+	}
+	r.lastErr = err.Error()
+	return err
+}
+
+// Watch polls the replay source every interval and reloads when files are
+// added, removed, or modified. It returns when ctx is done.
+func (r *ReplayStore) Watch(ctx context.Context, interval time.Duration, logf func(format string, args ...any)) {
+	if r == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.changed() {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			if logf != nil {
+				logf("replay reload failed (keeping previous fixtures): %v", err)
+			}
+			continue
+		}
+		if logf != nil {
+			st := r.Stats()
+			logf("replay reloaded: files=%d entries=%d conflicts=%d", st.Files, st.Entries, st.Conflicts)
+		}
+	}
+}
+
+func (r *ReplayStore) changed() bool {
+	files, err := resolveReplayFiles(r.source)
+	if err != nil {
+		return true
+	}
+	fingerprint, err := fingerprintFiles(files)
+	if err != nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fingerprint != r.fingerprint
+}
+
+func (r *ReplayStore) Stats() ReplayStats {
+	if r == nil {
+		return ReplayStats{}
+	}
+	r.mu.Lock()
+	st := ReplayStats{Reloads: r.reloads, LastError: r.lastErr}
+	r.mu.Unlock()
+	st.TemplateErrors = r.templateErrors.Load()
+	if last := r.lastTemplateError.Load(); last != nil {
+		st.LastTemplateError = *last
+	}
+	if idx := r.index.Load(); idx != nil {
+		st.Files = idx.files
+		st.Entries = idx.entries
+		st.Conflicts = idx.conflicts
+		st.Scenarios = len(idx.scenarios)
+		st.LoadedAt = idx.loadedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return st
+}
+
+func resolveReplayFiles(source string) ([]string, error) {
+	if strings.ContainsAny(source, "*?[") {
+		matches, err := filepath.Glob(source)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+	st, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		return []string{source}, nil
+	}
+	dirEntries, err := os.ReadDir(source)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(de.Name())) {
+		case ".ndjson", ".jsonl":
+			files = append(files, filepath.Join(source, de.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func fingerprintFiles(files []string) (string, error) {
+	var b strings.Builder
+	for _, f := range files {
+		st, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", f, st.Size(), st.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+func buildReplayIndex(files []string) (*replayIndex, error) {
+	idx := newReplayIndex()
+	for _, f := range files {
+		if err := idx.loadFile(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		idx.files++
+	}
+	idx.loadedAt = time.Now()
+	return idx, nil
+}
+
+func (idx *replayIndex) loadFile(path string) error {
+	fileScenario := ScenarioFromFileName(path)
+	return scanLines(path, func(lineNo int, line []byte) error {
+		entry := Entry{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if entry.Scenario == "" {
+			entry.Scenario = fileScenario
+		}
+		if err := idx.add(entry); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		return nil
+	})
+}
+
+// scanLines calls fn for every non-empty line of an NDJSON file, stopping at
+// the first error fn returns. Line numbers start at 1.
+func scanLines(path string, fn func(lineNo int, line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// Entries can be large (request + response bodies). Increase the scanner limit
+	// to avoid failing on valid recordings.
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(lineNo, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (idx *replayIndex) add(entry Entry) error {
+	if entry.Kind == EntryKindTemplate {
+		return idx.addTemplate(entry)
+	}
+	if entry.Signature == "" || entry.Kind == EntryKindServerRequest || entry.Fault != "" || entry.Approval.Denied() {
+		return nil
+	}
+	if string(entry.Response) == "null" {
+		// Stream entries without a final response serialize it as null.
+		entry.Response = nil
+	}
+	if len(entry.Response) == 0 && len(entry.Events) == 0 {
+		return nil
+	}
+	idx.entries++
+	si := idx.scenario(entry.Scenario)
+	item := &replayItem{response: entry.Response, events: entry.Events, protocolVersion: entry.ProtocolVersion, sequence: entry.Sequence}
+	if item.sequence > 0 {
+		item.seq = []*replayItem{item}
+	}
+	if existing, exists := si.bySignature[entry.Signature]; exists {
+		switch {
+		case existing.sequence > 0 && item.sequence > 0:
+			existing.seq = append(existing.seq, item)
+			sort.SliceStable(existing.seq, func(a, b int) bool { return existing.seq[a].sequence < existing.seq[b].sequence })
+		case !existing.sameAs(item):
+			idx.conflicts++
+		}
+	} else {
+		si.bySignature[entry.Signature] = item
+	}
+
+	if len(entry.Request) == 0 {
+		return nil
+	}
+	req := jsonrpc.Request{}
+	if err := json.Unmarshal(entry.Request, &req); err != nil {
+		return nil
+	}
+	if req.Method != "" {
+		if _, exists := si.byMethod[req.Method]; !exists {
+			si.byMethod[req.Method] = item
+		}
+	}
+	if req.Method == "tools/call" {
+		tool, err := extractToolName(req.Params)
+		if err == nil && tool != "" {
+			if _, exists := si.byTool[tool]; !exists {
+				si.byTool[tool] = item
+			}
+		}
+	}
+	return nil
+}
+
+func (idx *replayIndex) addTemplate(entry Entry) error {
+	node, err := compileTemplate(entry.Response)
+	if err != nil {
+		return fmt.Errorf("invalid response template: %w", err)
+	}
+	idx.entries++
+	si := idx.scenario(entry.Scenario)
+	item := &replayItem{response: entry.Response, template: node}
+
+	req := jsonrpc.Request{}
+	if len(entry.Request) > 0 {
+		if err := json.Unmarshal(entry.Request, &req); err != nil {
+			return fmt.Errorf("invalid template request: %w", err)
+		}
+	}
+	if entry.Signature != "" {
+		// Signature templates are indexed like recorded entries so they also
+		// answer under the method and tool match modes.
+		if _, exists := si.bySignature[entry.Signature]; !exists {
+			si.bySignature[entry.Signature] = item
+		}
+		if req.Method != "" {
+			if _, exists := si.byMethod[req.Method]; !exists {
+				si.byMethod[req.Method] = item
+			}
+		}
+		if req.Method == "tools/call" {
+			if tool, err := extractToolName(req.Params); err == nil && tool != "" {
+				if _, exists := si.byTool[tool]; !exists {
+					si.byTool[tool] = item
+				}
+			}
+		}
+		return nil
+	}
+	if req.Method == "" {
+		return errors.New("template entries need a signature or a request with a method")
+	}
+	if req.Method == "tools/call" {
+		tool, err := extractToolName(req.Params)
+		if err != nil {
+			return fmt.Errorf("template tools/call request: %w", err)
+		}
+		if _, exists := si.templateByTool[tool]; !exists {
+			si.templateByTool[tool] = item
+		}
+		return nil
+	}
+	if _, exists := si.templateByMethod[req.Method]; !exists {
+		si.templateByMethod[req.Method] = item
+	}
+	return nil
+}
+
+func (i *replayItem) sameAs(other *replayItem) bool {
+	if !bytes.Equal(i.response, other.response) || len(i.events) != len(other.events) {
+		return false
+	}
+	for n := range i.events {
+		if i.events[n].Data != other.events[n].Data {
+			return false
+		}
+	}
+	return true
+}
+
+// Lookup returns the recorded JSON response for req in the default scenario.
+// Stream entries without a final JSON-RPC response are treated as misses.
+func (r *ReplayStore) Lookup(req *jsonrpc.Request, signature string) (json.RawMessage, bool) {
+	return r.LookupScenario(DefaultScenario, req, signature)
+}
+
+// LookupScenario is Lookup scoped to scenario, falling through to
+// DefaultScenario when the scenario has no matching entry.
+func (r *ReplayStore) LookupScenario(scenario string, req *jsonrpc.Request, signature string) (json.RawMessage, bool) {
+	head := r.lookupItem(scenario, req, signature)
+	item := head.current()
+	if item == nil || len(item.response) == 0 {
+		return nil, false
+	}
+	head.advance()
+	if item.template != nil {
+		resp, err := renderTemplate(item.template, req)
+		if err != nil {
+			msg := err.Error()
+			r.templateErrors.Add(1)
+			r.lastTemplateError.Store(&msg)
+			return nil, false
+		}
+		return resp, true
+	}
+	return item.response, true
+}
+
+// LookupStream returns the recorded events for req when the matched entry
+// was captured from a streamed response.
+func (r *ReplayStore) LookupStream(req *jsonrpc.Request, signature string) ([]StreamEvent, bool) {
+	return r.LookupStreamScenario(DefaultScenario, req, signature)
+}
+
+// LookupStreamScenario is LookupStream scoped to scenario, with the same
+// fall-through as LookupScenario.
+func (r *ReplayStore) LookupStreamScenario(scenario string, req *jsonrpc.Request, signature string) ([]StreamEvent, bool) {
+	head := r.lookupItem(scenario, req, signature)
+	item := head.current()
+	if item == nil || len(item.events) == 0 {
+		return nil, false
+	}
+	head.advance()
+	return item.events, true
+}
+
+// ProtocolVersionScenario returns the protocol version recorded with the
+// entry LookupScenario would serve for req, or "" when it has none.
+func (r *ReplayStore) ProtocolVersionScenario(scenario string, req *jsonrpc.Request, signature string) string {
+	item := r.lookupItem(scenario, req, signature).current()
+	if item == nil {
+		return ""
+	}
+	return item.protocolVersion
+}
+
+func (r *ReplayStore) lookupItem(scenario string, req *jsonrpc.Request, signature string) *replayItem {
+	if r == nil {
+		return nil
+	}
+	idx := r.index.Load()
+	if idx == nil {
+		return nil
+	}
+	if scenario != DefaultScenario {
+		if si, ok := idx.scenarios[scenario]; ok {
+			if item := si.find(r.match, req, signature); item != nil {
+				return item
+			}
+		}
+	}
+	if si, ok := idx.scenarios[DefaultScenario]; ok {
+		return si.find(r.match, req, signature)
+	}
+	return nil
+}
+
+func (si *scenarioIndex) find(match ReplayMatch, req *jsonrpc.Request, signature string) *replayItem {
+	if item := si.findRecorded(match, req, signature); item != nil {
+		return item
+	}
+	if req == nil {
+		return nil
+	}
+	if req.Method == "tools/call" {
+		if tool, err := extractToolName(req.Params); err == nil {
+			if item := si.templateByTool[tool]; item != nil {
+				return item
+			}
+		}
+	}
+	return si.templateByMethod[req.Method]
+}
+
+func (si *scenarioIndex) findRecorded(match ReplayMatch, req *jsonrpc.Request, signature string) *replayItem {
+	switch match {
+	case ReplayMatchMethod:
+		if req == nil || req.Method == "" {
+			return nil
+		}
+		return si.byMethod[req.Method]
+	case ReplayMatchTool:
+		if req == nil || req.Method != "tools/call" {
+			return nil
+		}
+		tool, err := extractToolName(req.Params)
+		if err != nil || tool == "" {
+			return nil
+		}
+		return si.byTool[tool]
+	default:
+		if signature == "" {
+			return nil
+		}
+		return si.bySignature[signature]
+	}
+}
+
+func extractToolName(params json.RawMessage) (string, error) {
+	if len(params) == 0 {
+		return "", errors.New("missing params")
+	}
+	var data struct {
+		Tool string `json:"tool"`
+	}
+	if err := json.Unmarshal(params, &data); err != nil {
+		return "", err
+	}
+	if data.Tool == "" {
+		return "", errors.New("missing tool")
+	}
+	return data.Tool, nil
+}
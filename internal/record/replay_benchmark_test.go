@@ -9,14 +9,12 @@ import (
 )
 
 func BenchmarkReplayLookupSignature(b *testing.B) {
-	store := &ReplayStore{
-		match:       ReplayMatchSignature,
-		bySignature: map[string]json.RawMessage{},
-		byMethod:    map[string]json.RawMessage{},
-		byTool:      map[string]json.RawMessage{},
-	}
+	store := &ReplayStore{match: ReplayMatchSignature}
+	idx := newReplayIndex()
+	store.index.Store(idx)
+	si := idx.scenario(DefaultScenario)
 	for i := 0; i < 50_000; i++ {
-		store.bySignature[fmt.Sprintf("sig-%d", i)] = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+		si.bySignature[fmt.Sprintf("sig-%d", i)] = &replayItem{response: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)}
 	}
 	req := &jsonrpc.Request{Method: "ping"}
 	sig := "sig-4242"
@@ -30,14 +28,12 @@ func BenchmarkReplayLookupSignature(b *testing.B) {
 }
 
 func BenchmarkReplayLookupMethod(b *testing.B) {
-	store := &ReplayStore{
-		match:       ReplayMatchMethod,
-		bySignature: map[string]json.RawMessage{},
-		byMethod:    map[string]json.RawMessage{},
-		byTool:      map[string]json.RawMessage{},
-	}
+	store := &ReplayStore{match: ReplayMatchMethod}
+	idx := newReplayIndex()
+	store.index.Store(idx)
+	si := idx.scenario(DefaultScenario)
 	for i := 0; i < 10_000; i++ {
-		store.byMethod[fmt.Sprintf("m-%d", i)] = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+		si.byMethod[fmt.Sprintf("m-%d", i)] = &replayItem{response: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)}
 	}
 	req := &jsonrpc.Request{Method: "m-4242"}
 
@@ -50,14 +46,12 @@ func BenchmarkReplayLookupMethod(b *testing.B) {
 }
 
 func BenchmarkReplayLookupTool(b *testing.B) {
-	store := &ReplayStore{
-		match:       ReplayMatchTool,
-		bySignature: map[string]json.RawMessage{},
-		byMethod:    map[string]json.RawMessage{},
-		byTool:      map[string]json.RawMessage{},
-	}
+	store := &ReplayStore{match: ReplayMatchTool}
+	idx := newReplayIndex()
+	store.index.Store(idx)
+	si := idx.scenario(DefaultScenario)
 	for i := 0; i < 10_000; i++ {
-		store.byTool[fmt.Sprintf("tool-%d", i)] = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
+		si.byTool[fmt.Sprintf("tool-%d", i)] = &replayItem{response: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)}
 	}
 	req := &jsonrpc.Request{
 		Method: "tools/call",
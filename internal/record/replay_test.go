@@ -0,0 +1,201 @@
+package record
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+func writeFixture(t *testing.T, path string, entries ...Entry) {
+	t.Helper()
+	var data []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		data = append(data, append(line, '\n')...)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+}
+
+func TestLoadReplayDirectoryPrecedenceAndConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, filepath.Join(dir, "10-base.ndjson"),
+		Entry{Signature: "a", Response: json.RawMessage(`{"result":"base-a"}`)},
+		Entry{Signature: "b", Response: json.RawMessage(`{"result":"base-b"}`)},
+	)
+	writeFixture(t, filepath.Join(dir, "20-override.jsonl"),
+		Entry{Signature: "a", Response: json.RawMessage(`{"result":"late-a"}`)},
+		Entry{Signature: "b", Response: json.RawMessage(`{"result":"base-b"}`)},
+		Entry{Signature: "c", Response: json.RawMessage(`{"result":"late-c"}`)},
+	)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store, err := LoadReplay(dir, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	req := &jsonrpc.Request{Method: "ping"}
+	if got, _ := store.Lookup(req, "a"); string(got) != `{"result":"base-a"}` {
+		t.Fatalf("expected earlier file to win, got=%s", got)
+	}
+	if _, ok := store.Lookup(req, "c"); !ok {
+		t.Fatalf("expected entry from second file")
+	}
+
+	st := store.Stats()
+	if st.Files != 2 || st.Entries != 5 || st.Conflicts != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestLoadReplayGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, filepath.Join(dir, "a.ndjson"), Entry{Signature: "a", Response: json.RawMessage(`{}`)})
+	writeFixture(t, filepath.Join(dir, "b.ndjson"), Entry{Signature: "b", Response: json.RawMessage(`{}`)})
+
+	store, err := LoadReplay(filepath.Join(dir, "b*.ndjson"), ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	if _, ok := store.Lookup(nil, "a"); ok {
+		t.Fatalf("expected a.ndjson to be excluded by glob")
+	}
+	if _, ok := store.Lookup(nil, "b"); !ok {
+		t.Fatalf("expected b.ndjson to be loaded")
+	}
+}
+
+func TestReplayServesSequencedEntriesInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	first := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"jobs/status"}`, `{"jsonrpc":"2.0","id":1,"result":{"state":"running"}}`)
+	second := first
+	second.Response = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"state":"done"}}`)
+	merged, stats := MergeEntries([]Entry{first}, []Entry{second}, MergeKeepBoth)
+	if stats.Sequenced != 1 || merged[0].Sequence != 1 || merged[1].Sequence != 2 {
+		t.Fatalf("unexpected merge: %+v %+v", stats, merged)
+	}
+	writeFixture(t, path, merged...)
+
+	store, err := LoadReplay(path, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	for _, want := range []string{"running", "done", "done"} {
+		got, ok := store.Lookup(nil, first.Signature)
+		if !ok || !strings.Contains(string(got), want) {
+			t.Fatalf("expected %s, got=%s", want, got)
+		}
+	}
+	if st := store.Stats(); st.Conflicts != 0 {
+		t.Fatalf("sequenced entries are not conflicts: %+v", st)
+	}
+}
+
+func TestLoadReplayRequiresRecordings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, source := range []string{dir, filepath.Join(dir, "*.ndjson")} {
+		if _, err := LoadReplay(source, ReplayMatchSignature); err == nil {
+			t.Fatalf("expected error for %q without recordings", source)
+		}
+	}
+}
+
+func TestReplayStoreReloadKeepsPreviousIndexOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fixtures.ndjson")
+	writeFixture(t, path, Entry{Signature: "a", Response: json.RawMessage(`{"v":1}`)})
+
+	store, err := LoadReplay(dir, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	if store.changed() {
+		t.Fatalf("expected fingerprint to be stable after load")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.ndjson"), []byte("{not json\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !store.changed() {
+		t.Fatalf("expected new file to change the fingerprint")
+	}
+	if err := store.Reload(); err == nil {
+		t.Fatalf("expected reload error for invalid fixture")
+	}
+	if _, ok := store.Lookup(nil, "a"); !ok {
+		t.Fatalf("expected previous index to survive failed reload")
+	}
+	if store.Stats().LastError == "" {
+		t.Fatalf("expected last_error to be reported")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "broken.ndjson")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	// Ensure the rewritten file gets a distinct mtime on coarse filesystems.
+	future := time.Now().Add(2 * time.Second)
+	writeFixture(t, path, Entry{Signature: "a", Response: json.RawMessage(`{"v":2}`)})
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got, _ := store.Lookup(nil, "a"); string(got) != `{"v":2}` {
+		t.Fatalf("expected reloaded response, got=%s", got)
+	}
+	if st := store.Stats(); st.Reloads != 2 || st.LastError != "" {
+		t.Fatalf("unexpected stats after reload: %+v", st)
+	}
+}
+
+func TestReplayScenarioLookupFallsThroughToDefault(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, filepath.Join(dir, "base.ndjson"),
+		Entry{Signature: "a", Response: json.RawMessage(`{"result":"default-a"}`)},
+		Entry{Signature: "b", Response: json.RawMessage(`{"result":"default-b"}`)},
+	)
+	writeFixture(t, filepath.Join(dir, "checkout@cart.ndjson"),
+		Entry{Signature: "a", Response: json.RawMessage(`{"result":"checkout-a"}`)},
+		Entry{Signature: "b", Scenario: "refund", Response: json.RawMessage(`{"result":"refund-b"}`)},
+	)
+
+	store, err := LoadReplay(dir, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	cases := []struct {
+		scenario string
+		sig      string
+		want     string
+	}{
+		{"", "a", `{"result":"default-a"}`},
+		{"checkout", "a", `{"result":"checkout-a"}`},
+		{"checkout", "b", `{"result":"default-b"}`},
+		{"refund", "b", `{"result":"refund-b"}`},
+		{"unknown", "a", `{"result":"default-a"}`},
+	}
+	for _, tc := range cases {
+		got, ok := store.LookupScenario(tc.scenario, nil, tc.sig)
+		if !ok || string(got) != tc.want {
+			t.Fatalf("scenario=%q sig=%s got=%s ok=%v want=%s", tc.scenario, tc.sig, got, ok, tc.want)
+		}
+	}
+	if st := store.Stats(); st.Scenarios != 3 || st.Conflicts != 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
@@ -0,0 +1,155 @@
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces path with the output of write: data goes to a
+// temporary file in the same directory, which is synced and renamed over
+// path, so readers never observe a partially written file. The original file
+// mode is kept when path already exists.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// RedactStats summarizes a retroactive redaction pass over one file.
+type RedactStats struct {
+	Entries        int            `json:"entries"`
+	EntriesChanged int            `json:"entries_changed"`
+	Keys           map[string]int `json:"keys"`
+}
+
+// Redacted returns the total number of values redacted.
+func (s RedactStats) Redacted() int {
+	n := 0
+	for _, c := range s.Keys {
+		n += c
+	}
+	return n
+}
+
+// RedactFile applies the redactor to every entry already written to path and
+// atomically rewrites the file. Rotated backups (path.1..path.N) are separate
+// files and must be passed in their own calls. Only lines
+// holding a value that was not yet redacted are re-encoded; all other lines
+// are kept byte for byte, and signatures are never recomputed. With dryRun
+// the file is left untouched and only the counts are reported. Any malformed
+// line aborts the pass without modifying the file.
+//
+// RedactFile must run while no Recorder is appending to path: a write or
+// rotation between reading and replacing the file would be lost, so it fails
+// with errFileChanged instead of replacing a file that changed under it.
+func RedactFile(path string, redactor *Redactor, dryRun bool) (RedactStats, error) {
+	stats := RedactStats{Keys: map[string]int{}}
+	if redactor == nil {
+		return stats, nil
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		return stats, err
+	}
+	var lines [][]byte
+	err = scanLines(path, func(lineNo int, line []byte) error {
+		stats.Entries++
+		out, changed, err := redactLine(redactor, line, stats.Keys)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if changed {
+			stats.EntriesChanged++
+		}
+		lines = append(lines, out)
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", path, err)
+	}
+	if dryRun || stats.EntriesChanged == 0 {
+		return stats, nil
+	}
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		for _, line := range lines {
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+		// Checked last so the window before the rename stays short.
+		return checkUnchanged(path, before)
+	})
+	return stats, err
+}
+
+// errFileChanged reports that a file was written or rotated while it was
+// being rewritten.
+var errFileChanged = errors.New("file changed while redacting (is the gateway still recording to it?); stop the recorder and retry")
+
+// checkUnchanged reports errFileChanged unless path is still the file
+// described by before, with the same size and modification time.
+func checkUnchanged(path string, before os.FileInfo) error {
+	now, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(before, now) || now.Size() != before.Size() || !now.ModTime().Equal(before.ModTime()) {
+		return fmt.Errorf("%s: %w", path, errFileChanged)
+	}
+	return nil
+}
+
+// redactLine redacts one recorded entry, returning the original bytes when
+// nothing needed redaction.
+func redactLine(r *Redactor, line []byte, counts map[string]int) ([]byte, bool, error) {
+	entry := Entry{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, false, err
+	}
+	changed, err := r.redactEntry(&entry, counts)
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		// Copy: the scanner reuses its buffer.
+		return append([]byte(nil), line...), false, nil
+	}
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
@@ -0,0 +1,277 @@
+package record
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+)
+
+const (
+	RedactActionRedact       = "redact"
+	RedactActionPseudonymize = "pseudonymize"
+
+	// pseudonymPrefix marks pseudonymized values so later passes leave them
+	// alone.
+	pseudonymPrefix = "pseudo_"
+	// pseudonymHexLen is the length of the HMAC digest after the prefix.
+	pseudonymHexLen = 16
+)
+
+// pathRule redacts or pseudonymizes the value at a JSON path, optionally only
+// for some methods or tools.
+type pathRule struct {
+	raw      string
+	segments []string
+	methods  map[string]struct{}
+	tools    map[string]struct{}
+	action   string
+}
+
+func newPathRules(policies []config.RedactRulePolicy) ([]pathRule, error) {
+	var out []pathRule
+	for i, p := range policies {
+		pattern, err := jsondiff.ParsePath(p.Path)
+		if err != nil {
+			return nil, fmt.Errorf("redact_rules[%d]: %w", i, err)
+		}
+		rule := pathRule{raw: p.Path, segments: pattern.Segments(), action: strings.ToLower(p.Action)}
+		if rule.action == "" {
+			rule.action = RedactActionRedact
+		}
+		if rule.action != RedactActionRedact && rule.action != RedactActionPseudonymize {
+			return nil, fmt.Errorf("redact_rules[%d]: unknown action %q", i, p.Action)
+		}
+		if len(p.Methods) > 0 {
+			rule.methods = map[string]struct{}{}
+			for _, m := range p.Methods {
+				rule.methods[m] = struct{}{}
+			}
+		}
+		if len(p.Tools) > 0 {
+			rule.tools = map[string]struct{}{}
+			for _, t := range p.Tools {
+				rule.tools[t] = struct{}{}
+			}
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+// pseudonymKey reads the HMAC key for pseudonymize rules.
+func pseudonymKey(policy config.RecordPolicy, rules []pathRule) ([]byte, error) {
+	needed := false
+	for _, rule := range rules {
+		if rule.action == RedactActionPseudonymize {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil, nil
+	}
+	if policy.PseudonymKeyEnv == "" {
+		return nil, fmt.Errorf("pseudonymize rules require record.pseudonym_key_env")
+	}
+	key := os.Getenv(policy.PseudonymKeyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("pseudonym key environment variable %s is empty", policy.PseudonymKeyEnv)
+	}
+	return []byte(key), nil
+}
+
+func (rule pathRule) appliesTo(method, tool string) bool {
+	if rule.methods != nil {
+		if _, ok := rule.methods[method]; !ok {
+			return false
+		}
+	}
+	if rule.tools != nil {
+		if method != "tools/call" {
+			return false
+		}
+		if _, ok := rule.tools[tool]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPathRules rewrites the values selected by every rule in scope for the
+// exchange, counting newly rewritten values under "path:<path>".
+func (r *Redactor) applyPathRules(v any, method, tool string, counts map[string]int) any {
+	for _, rule := range r.rules {
+		if !rule.appliesTo(method, tool) {
+			continue
+		}
+		v = rewritePath(v, rule.segments, func(old any) any {
+			if r.isMasked(old) {
+				return old
+			}
+			counts["path:"+rule.raw]++
+			if rule.action == RedactActionPseudonymize {
+				return r.pseudonym(old)
+			}
+			return r.replacement
+		})
+	}
+	return v
+}
+
+// isMasked reports whether v already holds a redaction placeholder or
+// pseudonym.
+func (r *Redactor) isMasked(v any) bool {
+	s, ok := v.(string)
+	return ok && (s == r.replacement || isPseudonym(s))
+}
+
+// pseudonym derives a stable token from v: equal values map to equal tokens
+// for a given key, and the token does not reveal v.
+func (r *Redactor) pseudonym(v any) string {
+	var data []byte
+	if s, ok := v.(string); ok {
+		data = []byte(s)
+	} else {
+		data, _ = json.Marshal(v)
+	}
+	mac := hmac.New(sha256.New, r.pseudonymKey)
+	mac.Write(data)
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:pseudonymHexLen]
+}
+
+// isPseudonym reports whether s has the exact shape of a pseudonym token.
+func isPseudonym(s string) bool {
+	digest, ok := strings.CutPrefix(s, pseudonymPrefix)
+	if !ok || len(digest) != pseudonymHexLen {
+		return false
+	}
+	for _, c := range digest {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// rewritePath applies fn to every value matched by segs, where "*" matches
+// any object key or array index. Missing paths are ignored.
+func rewritePath(v any, segs []string, fn func(any) any) any {
+	if len(segs) == 0 {
+		return fn(v)
+	}
+	seg, rest := segs[0], segs[1:]
+	switch vv := v.(type) {
+	case map[string]any:
+		if seg == "*" {
+			for k, child := range vv {
+				vv[k] = rewritePath(child, rest, fn)
+			}
+			return vv
+		}
+		if child, ok := vv[seg]; ok {
+			vv[seg] = rewritePath(child, rest, fn)
+		}
+	case []any:
+		if seg == "*" {
+			for i := range vv {
+				vv[i] = rewritePath(vv[i], rest, fn)
+			}
+			return vv
+		}
+		i, err := strconv.Atoi(seg)
+		if err == nil && i >= 0 && i < len(vv) {
+			vv[i] = rewritePath(vv[i], rest, fn)
+		}
+	}
+	return v
+}
+
+// redactEntry redacts an entry's request, forwarded request, response, and
+// stream events in place, scoping path rules to the exchange's method and
+// tool. Payloads that need no change keep their original bytes. It reports
+// whether anything changed and adds per-rule counts to counts when non-nil.
+func (r *Redactor) redactEntry(entry *Entry, counts map[string]int) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	method, tool := entryMethodTool(*entry)
+	found := map[string]int{}
+	var err error
+	if entry.Request, err = r.applyScoped(entry.Request, method, tool, found); err != nil {
+		return false, fmt.Errorf("request: %w", err)
+	}
+	if entry.Forwarded, err = r.applyScoped(entry.Forwarded, method, tool, found); err != nil {
+		return false, fmt.Errorf("forwarded: %w", err)
+	}
+	if entry.Response, err = r.applyScoped(entry.Response, method, tool, found); err != nil {
+		return false, fmt.Errorf("response: %w", err)
+	}
+	for i := range entry.Events {
+		data := entry.Events[i].Data
+		if !json.Valid([]byte(data)) {
+			entry.Events[i].Data = r.maskString(data, found)
+			continue
+		}
+		out, err := r.applyScoped(json.RawMessage(data), method, tool, found)
+		if err != nil {
+			return false, fmt.Errorf("events[%d]: %w", i, err)
+		}
+		entry.Events[i].Data = string(out)
+	}
+	for k, n := range found {
+		if counts != nil {
+			counts[k] += n
+		}
+	}
+	return len(found) > 0, nil
+}
+
+// applyScoped runs path rules, key rules, and value detectors over raw. Raw is
+// returned unchanged when nothing matched.
+func (r *Redactor) applyScoped(raw json.RawMessage, method, tool string, counts map[string]int) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return raw, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	found := map[string]int{}
+	v = r.applyPathRules(v, method, tool, found)
+	v = r.redactValue(v, found)
+	if len(found) == 0 {
+		return raw, nil
+	}
+	for k, n := range found {
+		counts[k] += n
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(out), nil
+}
+
+// RedactMessage applies path rules scoped to method and tool, key rules, and
+// value detectors to a single JSON-RPC message outside of a recording. Raw is
+// returned unchanged when nothing matched; counts holds one entry per matching
+// rule ("path:<path>", "detector:<name>", or the redacted key).
+func (r *Redactor) RedactMessage(raw json.RawMessage, method, tool string) (json.RawMessage, map[string]int, error) {
+	if r == nil {
+		return raw, nil, nil
+	}
+	counts := map[string]int{}
+	out, err := r.applyScoped(raw, method, tool, counts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, counts, nil
+}
@@ -0,0 +1,140 @@
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func TestPathRulesScopedToToolAndPseudonymizeConsistently(t *testing.T) {
+	t.Setenv("TEST_PSEUDONYM_KEY", "k1")
+	r, err := NewRedactorFromPolicy(config.RecordPolicy{
+		PseudonymKeyEnv: "TEST_PSEUDONYM_KEY",
+		RedactRules: []config.RedactRulePolicy{
+			{Path: "$.params.arguments.user.email", Tools: []string{"crm.lookup"}, Action: "pseudonymize"},
+			{Path: "$.result.contacts[*].email", Tools: []string{"crm.lookup"}, Action: "pseudonymize"},
+			{Path: "$.result.contacts[*].ssn", Tools: []string{"crm.lookup"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	rec := NewRecorder(path, r, 0, 0)
+	lookup := Entry{
+		Signature: "a",
+		Request:   json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"crm.lookup","arguments":{"user":{"email":"ann@example.com"}}}}`),
+		Response:  json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"contacts":[{"email":"ann@example.com","ssn":"123-45-6789"},{"email":"bob@example.com","ssn":"987-65-4321"}]}}`),
+	}
+	other := Entry{
+		Signature: "b",
+		Request:   json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"mail.send","arguments":{"user":{"email":"ann@example.com"}}}}`),
+		Response:  json.RawMessage(`{"jsonrpc":"2.0","id":2,"result":{}}`),
+	}
+	for _, e := range []Entry{lookup, other} {
+		if err := rec.AppendEntry(e); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	var got struct {
+		Request struct {
+			Params struct {
+				Arguments struct {
+					User struct {
+						Email string `json:"email"`
+					} `json:"user"`
+				} `json:"arguments"`
+			} `json:"params"`
+		} `json:"request"`
+		Response struct {
+			Result struct {
+				Contacts []struct {
+					Email string `json:"email"`
+					SSN   string `json:"ssn"`
+				} `json:"contacts"`
+			} `json:"result"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(lines[0], &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	reqEmail := got.Request.Params.Arguments.User.Email
+	contacts := got.Response.Result.Contacts
+	if !strings.HasPrefix(reqEmail, pseudonymPrefix) || strings.Contains(string(lines[0]), "example.com") {
+		t.Fatalf("email not pseudonymized: %s", lines[0])
+	}
+	if contacts[0].Email != reqEmail || contacts[1].Email == reqEmail {
+		t.Fatalf("pseudonyms must be stable per value: req=%s contacts=%+v", reqEmail, contacts)
+	}
+	if contacts[0].SSN != defaultRedactionReplacement || contacts[1].SSN != defaultRedactionReplacement {
+		t.Fatalf("ssn not redacted: %+v", contacts)
+	}
+	if !strings.Contains(string(lines[1]), "ann@example.com") {
+		t.Fatalf("rule leaked to another tool: %s", lines[1])
+	}
+
+	// A different key yields different pseudonyms.
+	t.Setenv("TEST_PSEUDONYM_KEY", "k2")
+	r2, err := NewRedactorFromPolicy(config.RecordPolicy{
+		PseudonymKeyEnv: "TEST_PSEUDONYM_KEY",
+		RedactRules:     []config.RedactRulePolicy{{Path: "$.x", Action: "pseudonymize"}},
+	})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	if r2.pseudonym("ann@example.com") == reqEmail {
+		t.Fatalf("pseudonym does not depend on the key")
+	}
+}
+
+func TestPseudonymizeRequiresKey(t *testing.T) {
+	t.Setenv("TEST_PSEUDONYM_KEY", "")
+	_, err := NewRedactorFromPolicy(config.RecordPolicy{
+		PseudonymKeyEnv: "TEST_PSEUDONYM_KEY",
+		RedactRules:     []config.RedactRulePolicy{{Path: "$.x", Action: "pseudonymize"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "TEST_PSEUDONYM_KEY") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestPathRulesOnlyTrustWellFormedPseudonyms(t *testing.T) {
+	t.Setenv("TEST_PSEUDONYM_KEY", "k1")
+	r, err := NewRedactorFromPolicy(config.RecordPolicy{
+		PseudonymKeyEnv: "TEST_PSEUDONYM_KEY",
+		RedactRules:     []config.RedactRulePolicy{{Path: "$.params.arguments.token", Action: "pseudonymize"}},
+	})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	masked := r.pseudonym("secret")
+	for value, wantKept := range map[string]bool{
+		masked:                    true,
+		"pseudo_secret":           false,
+		"pseudo_0123456789abcdeF": false,
+		masked + "0":              false,
+	} {
+		v := map[string]any{"params": map[string]any{"arguments": map[string]any{"token": value}}}
+		counts := map[string]int{}
+		out := r.applyPathRules(v, "tools/call", "auth", counts)
+		got := out.(map[string]any)["params"].(map[string]any)["arguments"].(map[string]any)["token"]
+		if kept := got == value; kept != wantKept {
+			t.Fatalf("value %q: kept=%v want %v (got %v)", value, kept, wantKept, got)
+		}
+		if !wantKept && !isPseudonym(got.(string)) {
+			t.Fatalf("value %q was not pseudonymized: %v", value, got)
+		}
+	}
+}
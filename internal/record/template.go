@@ -0,0 +1,279 @@
+package record
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// EntryKindTemplate marks hand-written fixtures whose response is a template
+// evaluated against each replayed request. String values in the response may
+// contain {{expr}} placeholders:
+//
+//	args.<path>    tools/call argument (dot-separated keys or array indexes)
+//	params.<path>  raw request params
+//	method, tool   request method and tools/call tool name
+//	id             request id
+//	now, unix      current time (RFC 3339 UTC, Unix seconds)
+//	uuid           random UUIDv4
+//
+// A string consisting of a single placeholder is replaced by the referenced
+// JSON value (keeping its type); placeholders inside longer strings are
+// interpolated as text. Missing values render as null or "". Only the names
+// above are available, so templates cannot reach anything beyond the request
+// being replayed.
+const EntryKindTemplate = "template"
+
+var templateFuncs = map[string]func() any{
+	"now":  func() any { return time.Now().UTC().Format(time.RFC3339) },
+	"unix": func() any { return time.Now().Unix() },
+	"uuid": func() any { return newUUID() },
+}
+
+type templateNode interface {
+	render(ctx *templateContext) any
+}
+
+type templateContext struct {
+	method string
+	tool   string
+	id     any
+	params any
+	args   any
+}
+
+type templateLiteral struct{ value any }
+
+type templateObject struct {
+	keys   []string
+	values []templateNode
+}
+
+type templateArray struct{ items []templateNode }
+
+// templateString is a string split into alternating literal text and
+// expressions.
+type templateString struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	text string
+	expr *templateExpr
+}
+
+type templateExpr struct {
+	root string
+	path []string
+}
+
+// compileTemplate parses and validates every placeholder in raw so that bad
+// fixtures fail at load time rather than on first use.
+func compileTemplate(raw json.RawMessage) (templateNode, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return compileTemplateValue(v)
+}
+
+func compileTemplateValue(v any) (templateNode, error) {
+	switch vv := v.(type) {
+	case map[string]any:
+		obj := &templateObject{}
+		for k := range vv {
+			obj.keys = append(obj.keys, k)
+		}
+		sort.Strings(obj.keys)
+		for _, k := range obj.keys {
+			child, err := compileTemplateValue(vv[k])
+			if err != nil {
+				return nil, err
+			}
+			obj.values = append(obj.values, child)
+		}
+		return obj, nil
+	case []any:
+		arr := &templateArray{}
+		for _, item := range vv {
+			child, err := compileTemplateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr.items = append(arr.items, child)
+		}
+		return arr, nil
+	case string:
+		if !strings.Contains(vv, "{{") {
+			return templateLiteral{value: vv}, nil
+		}
+		return compileTemplateString(vv)
+	default:
+		return templateLiteral{value: vv}, nil
+	}
+}
+
+func compileTemplateString(s string) (templateNode, error) {
+	ts := &templateString{}
+	rest := s
+	for rest != "" {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			ts.parts = append(ts.parts, templatePart{text: rest})
+			break
+		}
+		if start > 0 {
+			ts.parts = append(ts.parts, templatePart{text: rest[:start]})
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("template %q: unterminated placeholder", s)
+		}
+		expr, err := parseTemplateExpr(rest[start+2 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", s, err)
+		}
+		ts.parts = append(ts.parts, templatePart{expr: expr})
+		rest = rest[start+end+2:]
+	}
+	return ts, nil
+}
+
+func parseTemplateExpr(src string) (*templateExpr, error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, errors.New("empty placeholder")
+	}
+	segments := strings.Split(src, ".")
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid path %q", src)
+		}
+	}
+	expr := &templateExpr{root: segments[0], path: segments[1:]}
+	switch expr.root {
+	case "args", "params":
+		return expr, nil
+	case "method", "tool", "id":
+	default:
+		if _, ok := templateFuncs[expr.root]; !ok {
+			return nil, fmt.Errorf("unknown placeholder %q", expr.root)
+		}
+	}
+	if len(expr.path) > 0 {
+		return nil, fmt.Errorf("%q does not have fields", expr.root)
+	}
+	return expr, nil
+}
+
+func (n templateLiteral) render(*templateContext) any { return n.value }
+
+func (n *templateObject) render(ctx *templateContext) any {
+	out := make(map[string]any, len(n.keys))
+	for i, k := range n.keys {
+		out[k] = n.values[i].render(ctx)
+	}
+	return out
+}
+
+func (n *templateArray) render(ctx *templateContext) any {
+	out := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		out = append(out, item.render(ctx))
+	}
+	return out
+}
+
+func (n *templateString) render(ctx *templateContext) any {
+	if len(n.parts) == 1 && n.parts[0].expr != nil {
+		return n.parts[0].expr.eval(ctx)
+	}
+	var b strings.Builder
+	for _, p := range n.parts {
+		if p.expr == nil {
+			b.WriteString(p.text)
+			continue
+		}
+		switch v := p.expr.eval(ctx).(type) {
+		case nil:
+		case string:
+			b.WriteString(v)
+		default:
+			data, _ := json.Marshal(v)
+			b.Write(data)
+		}
+	}
+	return b.String()
+}
+
+func (e *templateExpr) eval(ctx *templateContext) any {
+	switch e.root {
+	case "args":
+		return lookupPath(ctx.args, e.path)
+	case "params":
+		return lookupPath(ctx.params, e.path)
+	case "method":
+		return ctx.method
+	case "tool":
+		return ctx.tool
+	case "id":
+		return ctx.id
+	default:
+		return templateFuncs[e.root]()
+	}
+}
+
+func lookupPath(v any, path []string) any {
+	for _, seg := range path {
+		switch vv := v.(type) {
+		case map[string]any:
+			v = vv[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil
+			}
+			v = vv[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func renderTemplate(node templateNode, req *jsonrpc.Request) (json.RawMessage, error) {
+	ctx := &templateContext{}
+	if req != nil {
+		ctx.method = req.Method
+		if len(req.ID) > 0 {
+			_ = json.Unmarshal(req.ID, &ctx.id)
+		}
+		if len(req.Params) > 0 {
+			_ = json.Unmarshal(req.Params, &ctx.params)
+		}
+		if params, ok := ctx.params.(map[string]any); ok && req.Method == "tools/call" {
+			ctx.tool, _ = params["tool"].(string)
+			ctx.args = params["arguments"]
+		}
+	}
+	out, err := json.Marshal(node.render(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(out), nil
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
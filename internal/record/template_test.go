@@ -0,0 +1,127 @@
+package record
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+func TestTemplateEntryRendersRequestValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.ndjson")
+	fixture := `{"kind":"template","request":{"jsonrpc":"2.0","method":"tools/call","params":{"tool":"web.search"}},` +
+		`"response":{"jsonrpc":"2.0","id":0,"result":{"echo":"{{args.query}}","limit":"{{args.max_results}}",` +
+		`"summary":"{{tool}} for {{args.query}} (top {{args.max_results}})","first":"{{args.tags.0}}","missing":"{{args.nope}}",` +
+		`"request_id":"{{uuid}}","at":"{{now}}"}}}`
+	if err := os.WriteFile(path, []byte(fixture+"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store, err := LoadReplay(path, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	req := &jsonrpc.Request{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(`5`),
+		Method:  "tools/call",
+		Params:  json.RawMessage(`{"tool":"web.search","arguments":{"query":"golang","max_results":3,"tags":["a","b"]}}`),
+	}
+	resp, ok := store.Lookup(req, "any-signature")
+	if !ok {
+		t.Fatalf("expected template fallback hit")
+	}
+	var out struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if out.Result["echo"] != "golang" || out.Result["limit"] != float64(3) || out.Result["first"] != "a" {
+		t.Fatalf("unexpected rendering: %s", resp)
+	}
+	if out.Result["summary"] != "web.search for golang (top 3)" {
+		t.Fatalf("unexpected interpolation: %q", out.Result["summary"])
+	}
+	if out.Result["missing"] != nil {
+		t.Fatalf("expected missing argument to render null, got=%v", out.Result["missing"])
+	}
+	if id, _ := out.Result["request_id"].(string); !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Fatalf("unexpected uuid: %q", id)
+	}
+
+	other := &jsonrpc.Request{JSONRPC: "2.0", Method: "tools/call", Params: json.RawMessage(`{"tool":"fs.read","arguments":{}}`)}
+	if _, ok := store.Lookup(other, "x"); ok {
+		t.Fatalf("expected template to only cover its tool")
+	}
+}
+
+func TestTemplateEntryLoadErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown placeholder": `{"kind":"template","signature":"s","response":{"result":"{{env.HOME}}"}}`,
+		"unterminated":        `{"kind":"template","signature":"s","response":{"result":"{{args.q"}}`,
+		"field on scalar":     `{"kind":"template","signature":"s","response":{"result":"{{now.year}}"}}`,
+		"no match key":        `{"kind":"template","response":{"result":"ok"}}`,
+	}
+	for name, line := range cases {
+		path := filepath.Join(t.TempDir(), "fixtures.ndjson")
+		data := `{"signature":"ok","response":{"result":1}}` + "\n" + line + "\n"
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		_, err := LoadReplay(path, ReplayMatchSignature)
+		if err == nil {
+			t.Fatalf("%s: expected load error", name)
+		}
+		if !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("%s: expected line number in error, got=%v", name, err)
+		}
+	}
+}
+
+func TestSignatureTemplateAnswersUnderMethodAndToolMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.ndjson")
+	fixture := `{"kind":"template","signature":"sig","request":{"jsonrpc":"2.0","method":"tools/call","params":{"tool":"web.search"}},` +
+		`"response":{"jsonrpc":"2.0","id":0,"result":{"echo":"{{args.query}}"}}}`
+	if err := os.WriteFile(path, []byte(fixture+"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	req := &jsonrpc.Request{JSONRPC: "2.0", Method: "tools/call", Params: json.RawMessage(`{"tool":"web.search","arguments":{"query":"golang"}}`)}
+	for _, match := range []ReplayMatch{ReplayMatchSignature, ReplayMatchMethod, ReplayMatchTool} {
+		store, err := LoadReplay(path, match)
+		if err != nil {
+			t.Fatalf("%s: load replay: %v", match, err)
+		}
+		resp, ok := store.Lookup(req, "sig")
+		if !ok || !strings.Contains(string(resp), `"echo":"golang"`) {
+			t.Fatalf("%s: expected rendered template, ok=%v resp=%s", match, ok, resp)
+		}
+	}
+}
+
+type unrenderableNode struct{}
+
+func (unrenderableNode) render(*templateContext) any { return make(chan int) }
+
+func TestTemplateRenderErrorsAreCounted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.ndjson")
+	if err := os.WriteFile(path, []byte(`{"kind":"template","signature":"sig","response":{"result":"ok"}}`+"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	store, err := LoadReplay(path, ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+	store.index.Load().scenarios[DefaultScenario].bySignature["sig"].template = unrenderableNode{}
+
+	if _, ok := store.Lookup(&jsonrpc.Request{JSONRPC: "2.0", Method: "ping"}, "sig"); ok {
+		t.Fatalf("expected a miss when rendering fails")
+	}
+	if st := store.Stats(); st.TemplateErrors != 1 || !strings.Contains(st.LastTemplateError, "unsupported type") {
+		t.Fatalf("expected the render error to be counted, got %+v", st)
+	}
+}
@@ -0,0 +1,324 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/signature"
+)
+
+// Verification checks reported in Problem.Check.
+const (
+	CheckParse     = "parse"
+	CheckRequest   = "request"
+	CheckSignature = "signature"
+	CheckResponse  = "response"
+	CheckDuplicate = "duplicate"
+	CheckSecret    = "secret"
+	CheckSchema    = "schema"
+)
+
+// Problem is one issue found in a recording line.
+type Problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Check, p.Message)
+}
+
+type VerifyOptions struct {
+	// Redactor flags keys that the current policy would have redacted. Nil
+	// skips the secret check.
+	Redactor *Redactor
+
+	// ValidateArgs returns policy violations for tools/call arguments. Nil
+	// skips the schema check.
+	ValidateArgs func(tool string, args json.RawMessage) ([]string, error)
+}
+
+type VerifyReport struct {
+	Files    int       `json:"files"`
+	Entries  int       `json:"entries"`
+	Problems []Problem `json:"problems"`
+}
+
+// Verify checks every line of the recordings in sources (files, directories,
+// or globs, resolved like --replay). Duplicate detection spans all files, in
+// the same precedence order replay uses. An error is returned only when a
+// source cannot be read; per-line issues are collected in the report.
+func Verify(sources []string, opts VerifyOptions) (VerifyReport, error) {
+	v := &verifier{opts: opts, seen: map[string]seenEntry{}}
+	for _, source := range sources {
+		files, err := resolveReplayFiles(source)
+		if err != nil {
+			return v.report, err
+		}
+		if len(files) == 0 {
+			return v.report, fmt.Errorf("no recordings found at %q", source)
+		}
+		for _, f := range files {
+			if err := scanLines(f, func(lineNo int, line []byte) error {
+				v.verifyLine(f, lineNo, line)
+				return nil
+			}); err != nil {
+				return v.report, fmt.Errorf("%s: %w", f, err)
+			}
+			v.report.Files++
+		}
+	}
+	return v.report, nil
+}
+
+type seenEntry struct {
+	file     string
+	line     int
+	response json.RawMessage
+}
+
+type verifier struct {
+	opts   VerifyOptions
+	seen   map[string]seenEntry
+	report VerifyReport
+}
+
+func (v *verifier) problem(file string, line int, check, format string, args ...any) {
+	v.report.Problems = append(v.report.Problems, Problem{File: file, Line: line, Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *verifier) verifyLine(file string, lineNo int, line []byte) {
+	v.report.Entries++
+	entry := Entry{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		v.problem(file, lineNo, CheckParse, "invalid entry: %v", err)
+		return
+	}
+	if entry.Kind != "" && entry.Kind != EntryKindSSE && entry.Kind != EntryKindTemplate && entry.Kind != EntryKindServerRequest {
+		v.problem(file, lineNo, CheckParse, "unknown kind %q", entry.Kind)
+	}
+	if entry.Kind == EntryKindTemplate {
+		if _, err := compileTemplate(entry.Response); err != nil {
+			v.problem(file, lineNo, CheckResponse, "invalid response template: %v", err)
+		}
+	}
+
+	req, ok := v.verifyRequest(file, lineNo, entry)
+	if ok {
+		v.verifySignature(file, lineNo, entry, req)
+		v.verifySchema(file, lineNo, req)
+	}
+	if entry.Kind != EntryKindTemplate {
+		v.verifyResponse(file, lineNo, entry, req)
+	}
+	v.verifyDuplicate(file, lineNo, entry)
+	v.verifySecrets(file, lineNo, entry)
+}
+
+func (v *verifier) verifyRequest(file string, lineNo int, entry Entry) (*jsonrpc.Request, bool) {
+	if len(entry.Request) == 0 || string(entry.Request) == "null" {
+		if entry.Kind != EntryKindTemplate {
+			v.problem(file, lineNo, CheckRequest, "missing request")
+		}
+		return nil, false
+	}
+	req := &jsonrpc.Request{}
+	if err := json.Unmarshal(entry.Request, req); err != nil {
+		v.problem(file, lineNo, CheckRequest, "invalid JSON-RPC request: %v", err)
+		return nil, false
+	}
+	if err := req.Validate(); err != nil {
+		v.problem(file, lineNo, CheckRequest, "invalid JSON-RPC request: %v", err)
+		return nil, false
+	}
+	return req, true
+}
+
+func (v *verifier) verifySignature(file string, lineNo int, entry Entry, req *jsonrpc.Request) {
+	if entry.Signature == "" {
+		if entry.Kind != EntryKindTemplate && entry.Kind != EntryKindServerRequest {
+			v.problem(file, lineNo, CheckSignature, "missing signature")
+		}
+		return
+	}
+	sig, err := signature.FromRequest(req)
+	if err != nil {
+		v.problem(file, lineNo, CheckSignature, "cannot compute signature: %v", err)
+		return
+	}
+	// The recorder signs the original request but stores it redacted, so a
+	// masked request cannot reproduce its signature.
+	if sig != entry.Signature && !v.hasMaskedValue(entry.Request) {
+		v.problem(file, lineNo, CheckSignature, "stored signature %s does not match request (computed %s)", entry.Signature, sig)
+	}
+}
+
+// hasMaskedValue reports whether raw holds a redaction placeholder, a
+// detector mask, or a pseudonym.
+func (v *verifier) hasMaskedValue(raw json.RawMessage) bool {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return false
+	}
+	replacement := defaultRedactionReplacement
+	if v.opts.Redactor != nil {
+		replacement = v.opts.Redactor.replacement
+	}
+	var walk func(any) bool
+	walk = func(x any) bool {
+		switch xx := x.(type) {
+		case string:
+			return strings.Contains(xx, replacement) || isPseudonym(xx)
+		case map[string]any:
+			for _, child := range xx {
+				if walk(child) {
+					return true
+				}
+			}
+		case []any:
+			for _, child := range xx {
+				if walk(child) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return walk(doc)
+}
+
+func (v *verifier) verifySchema(file string, lineNo int, req *jsonrpc.Request) {
+	if v.opts.ValidateArgs == nil || req.Method != "tools/call" {
+		return
+	}
+	var params struct {
+		Tool      string          `json:"tool"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Tool == "" {
+		v.problem(file, lineNo, CheckSchema, "tools/call request without a tool name")
+		return
+	}
+	violations, err := v.opts.ValidateArgs(params.Tool, params.Arguments)
+	if err != nil {
+		v.problem(file, lineNo, CheckSchema, "tool %s: %v", params.Tool, err)
+		return
+	}
+	if len(violations) > 0 {
+		v.problem(file, lineNo, CheckSchema, "tool %s: %s", params.Tool, strings.Join(violations, "; "))
+	}
+}
+
+func (v *verifier) verifyResponse(file string, lineNo int, entry Entry, req *jsonrpc.Request) {
+	if entry.Kind == EntryKindSSE && len(entry.Events) == 0 {
+		v.problem(file, lineNo, CheckResponse, "sse entry has no events")
+	}
+	if len(entry.Response) == 0 || string(entry.Response) == "null" {
+		if entry.Kind != EntryKindSSE && req != nil && len(req.ID) > 0 {
+			v.problem(file, lineNo, CheckResponse, "missing response")
+		}
+		return
+	}
+	var resp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(entry.Response, &resp); err != nil {
+		v.problem(file, lineNo, CheckResponse, "invalid JSON-RPC response: %v", err)
+		return
+	}
+	if resp.JSONRPC != "2.0" {
+		v.problem(file, lineNo, CheckResponse, "jsonrpc must be 2.0")
+	}
+	if (len(resp.Result) > 0) == (len(resp.Error) > 0) {
+		v.problem(file, lineNo, CheckResponse, "response must have exactly one of result or error")
+	}
+	if req != nil && len(req.ID) > 0 && !sameJSON(req.ID, resp.ID) {
+		v.problem(file, lineNo, CheckResponse, "response id %s does not match request id %s", string(resp.ID), string(req.ID))
+	}
+}
+
+func (v *verifier) verifyDuplicate(file string, lineNo int, entry Entry) {
+	if entry.Signature == "" || entry.Fault != "" || entry.Approval.Denied() {
+		return
+	}
+	scenario := entry.Scenario
+	if scenario == "" {
+		scenario = ScenarioFromFileName(file)
+	}
+	key := scenario + "\x00" + entry.Signature + "\x00" + strconv.Itoa(entry.Sequence)
+	first, ok := v.seen[key]
+	if !ok {
+		v.seen[key] = seenEntry{file: file, line: lineNo, response: entry.Response}
+		return
+	}
+	detail := "identical response"
+	if !sameJSON(first.response, entry.Response) {
+		detail = "conflicting response, first wins on replay"
+	}
+	v.problem(file, lineNo, CheckDuplicate, "signature %s already recorded at %s:%d (%s)", entry.Signature, first.file, first.line, detail)
+}
+
+func (v *verifier) verifySecrets(file string, lineNo int, entry Entry) {
+	if v.opts.Redactor == nil {
+		return
+	}
+	type part struct {
+		name string
+		raw  json.RawMessage
+	}
+	parts := []part{{"request", entry.Request}, {"response", entry.Response}}
+	for i, ev := range entry.Events {
+		if json.Valid([]byte(ev.Data)) {
+			parts = append(parts, part{fmt.Sprintf("events[%d]", i), json.RawMessage(ev.Data)})
+		}
+	}
+	for _, part := range parts {
+		paths, err := v.opts.Redactor.Unredacted(part.raw)
+		if err != nil {
+			continue
+		}
+		if len(paths) > 0 {
+			v.problem(file, lineNo, CheckSecret, "%s has unredacted keys: %s", part.name, strings.Join(paths, ", "))
+		}
+	}
+
+	// Path rules are scoped to the exchange, so run them on a copy of the
+	// whole entry.
+	counts := map[string]int{}
+	probe := entry
+	probe.Events = append([]StreamEvent(nil), entry.Events...)
+	if _, err := v.opts.Redactor.redactEntry(&probe, counts); err != nil {
+		return
+	}
+	var rules []string
+	for k := range counts {
+		if strings.HasPrefix(k, "path:") {
+			rules = append(rules, strings.TrimPrefix(k, "path:"))
+		}
+	}
+	if len(rules) > 0 {
+		sort.Strings(rules)
+		v.problem(file, lineNo, CheckSecret, "values not yet redacted by path rules: %s", strings.Join(rules, ", "))
+	}
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var av, bv any
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
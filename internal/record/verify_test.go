@@ -0,0 +1,131 @@
+package record
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/signature"
+)
+
+func mustEntry(t *testing.T, rawReq, rawResp string) Entry {
+	t.Helper()
+	req := jsonrpc.Request{}
+	if err := json.Unmarshal([]byte(rawReq), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	sig, err := signature.FromRequest(&req)
+	if err != nil {
+		t.Fatalf("signature: %v", err)
+	}
+	return Entry{Signature: sig, Request: json.RawMessage(rawReq), Response: json.RawMessage(rawResp)}
+}
+
+func TestVerifyReportsPerLineProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.ndjson")
+
+	good := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search","arguments":{"q":"a"}}}`, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	staleSig := mustEntry(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+	staleSig.Signature = "deadbeef"
+	badID := mustEntry(t, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, `{"jsonrpc":"2.0","id":4,"result":{}}`)
+	dup := good
+	dup.Response = json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"other":true}}`)
+	secret := mustEntry(t, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"tool":"search","arguments":{"q":1,"api_key":"sk-live"}}}`, `{"jsonrpc":"2.0","id":5,"result":{}}`)
+	writeFixture(t, path, good, staleSig, badID, dup, secret)
+
+	redactor, err := NewRedactor([]string{"api_key"}, nil)
+	if err != nil {
+		t.Fatalf("redactor: %v", err)
+	}
+	report, err := Verify([]string{path}, VerifyOptions{
+		Redactor: redactor,
+		ValidateArgs: func(tool string, args json.RawMessage) ([]string, error) {
+			var a struct {
+				Q any `json:"q"`
+			}
+			_ = json.Unmarshal(args, &a)
+			if _, ok := a.Q.(string); !ok {
+				return []string{"q: Invalid type. Expected: string"}, nil
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if report.Files != 1 || report.Entries != 5 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+
+	want := []struct {
+		line  int
+		check string
+		text  string
+	}{
+		{2, CheckSignature, "does not match"},
+		{3, CheckResponse, "response id 4 does not match request id 3"},
+		{4, CheckDuplicate, "conflicting response"},
+		{5, CheckSchema, "Expected: string"},
+		{5, CheckSecret, "$.params.arguments.api_key"},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got problems %+v", report.Problems)
+	}
+	for i, w := range want {
+		p := report.Problems[i]
+		if p.Line != w.line || p.Check != w.check || !strings.Contains(p.Message, w.text) {
+			t.Fatalf("problem[%d]=%s want line=%d check=%s containing %q", i, p, w.line, w.check, w.text)
+		}
+	}
+}
+
+func TestVerifyFlagsMalformedLinesAndContinues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.ndjson")
+	bad, _ := json.Marshal(Entry{Signature: "x", Request: json.RawMessage(`{"jsonrpc":"1.0","method":"ping"}`)})
+	good, _ := json.Marshal(mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":1,"result":{}}`))
+	data := string(bad) + "\n{not json\n" + string(good) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	report, err := Verify([]string{dir}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if report.Entries != 3 || len(report.Problems) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Problems[0].Check != CheckRequest || report.Problems[1].Check != CheckParse || report.Problems[1].Line != 2 {
+		t.Fatalf("unexpected problems: %+v", report.Problems)
+	}
+}
+
+func TestVerifyAcceptsRedactedRecordings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	redactor, err := NewRedactor([]string{"api_key"}, nil)
+	if err != nil {
+		t.Fatalf("redactor: %v", err)
+	}
+	e := mustEntry(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search","arguments":{"q":"a","api_key":"sk-live"}}}`, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	if err := NewRecorder(path, redactor, 0, 0).Append(e.Signature, e.Request, e.Response); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	// Requests without masked values must still match their signature.
+	stale := mustEntry(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"tool":"search","arguments":{"q":"b"}}}`, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+	if err := NewRecorder(path, redactor, 0, 0).Append(e.Signature+"0", stale.Request, stale.Response); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	report, err := Verify([]string{path}, VerifyOptions{Redactor: redactor})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Line != 2 || report.Problems[0].Check != CheckSignature {
+		t.Fatalf("unexpected problems: %+v", report.Problems)
+	}
+}
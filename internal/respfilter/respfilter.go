@@ -0,0 +1,167 @@
+package respfilter
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+const (
+	ModeEnforce = "enforce"
+	ModeAudit   = "audit"
+	ModeOff     = "off"
+)
+
+// Filter redacts secrets from upstream results before they are returned to
+// the client. In audit mode matches are only counted and reported.
+type Filter struct {
+	redactor *record.Redactor
+	mode     string
+	tools    map[string]string
+
+	masked  atomic.Uint64
+	audited atomic.Uint64
+
+	byToolMu sync.Mutex
+	byTool   map[string]uint64
+}
+
+// Result describes the matches found in one response.
+type Result struct {
+	Mode string
+	// Matches counts matches per rule: "path:<path>", "detector:<name>", or
+	// the redacted key.
+	Matches map[string]int
+}
+
+func (r Result) Matched() bool {
+	return len(r.Matches) > 0
+}
+
+// Stats are cumulative filter counters.
+type Stats struct {
+	MaskedTotal  uint64 `json:"masked_total"`
+	AuditedTotal uint64 `json:"audited_total"`
+	// MatchesByTool counts filtered responses by tool (method for non
+	// tools/call requests).
+	MatchesByTool map[string]uint64 `json:"matches_by_tool,omitempty"`
+}
+
+// New builds a filter from policy. It returns nil when no redaction rules are
+// configured or every mode is off.
+func New(policy config.ResponseFilterPolicy) (*Filter, error) {
+	redactor, err := record.NewRedactorFromPolicy(policy.Redaction())
+	if err != nil || redactor == nil {
+		return nil, err
+	}
+	mode := policy.Mode
+	if mode == "" {
+		mode = ModeEnforce
+	}
+	enabled := mode != ModeOff
+	for _, m := range policy.Tools {
+		if m != ModeOff {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return &Filter{redactor: redactor, mode: mode, tools: policy.Tools, byTool: map[string]uint64{}}, nil
+}
+
+// Mode returns the filter mode for a request. Per-tool overrides only apply
+// to tools/call.
+func (f *Filter) Mode(method, tool string) string {
+	if f == nil {
+		return ModeOff
+	}
+	if method == "tools/call" {
+		if m, ok := f.tools[tool]; ok {
+			return m
+		}
+	}
+	return f.mode
+}
+
+// Apply filters the result of a JSON-RPC response, or the params of a
+// notification streamed with it (notifications/message, progress messages).
+// Error responses, requests, and messages without matches are returned
+// unchanged, as is every message in audit mode. method and tool are those of
+// the client request the message answers.
+func (f *Filter) Apply(method, tool string, raw json.RawMessage) (json.RawMessage, Result) {
+	res := Result{Mode: f.Mode(method, tool)}
+	if res.Mode == ModeOff {
+		return raw, res
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw, res
+	}
+	field := "result"
+	if _, isNotification := msg["method"]; isNotification {
+		if _, hasID := msg["id"]; hasID {
+			return raw, res
+		}
+		field = "params"
+	}
+	result, ok := msg[field]
+	if !ok {
+		return raw, res
+	}
+
+	// Wrapping the result keeps path rules anchored at the message root
+	// while leaving jsonrpc, id, and method out of reach of key rules.
+	wrapped, _ := json.Marshal(map[string]json.RawMessage{field: result})
+	filtered, matches, err := f.redactor.RedactMessage(wrapped, method, tool)
+	if err != nil || len(matches) == 0 {
+		return raw, res
+	}
+	res.Matches = matches
+	f.count(method, tool, res.Mode)
+	if res.Mode == ModeAudit {
+		return raw, res
+	}
+
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(filtered, &out); err != nil {
+		return raw, res
+	}
+	msg[field] = out[field]
+	rewritten, err := json.Marshal(msg)
+	if err != nil {
+		return raw, res
+	}
+	return json.RawMessage(rewritten), res
+}
+
+func (f *Filter) count(method, tool, mode string) {
+	if mode == ModeAudit {
+		f.audited.Add(1)
+	} else {
+		f.masked.Add(1)
+	}
+	key := tool
+	if key == "" {
+		key = method
+	}
+	f.byToolMu.Lock()
+	f.byTool[key]++
+	f.byToolMu.Unlock()
+}
+
+func (f *Filter) Stats() Stats {
+	st := Stats{MaskedTotal: f.masked.Load(), AuditedTotal: f.audited.Load()}
+	f.byToolMu.Lock()
+	defer f.byToolMu.Unlock()
+	if len(f.byTool) > 0 {
+		st.MatchesByTool = make(map[string]uint64, len(f.byTool))
+		for k, v := range f.byTool {
+			st.MatchesByTool[k] = v
+		}
+	}
+	return st
+}
@@ -0,0 +1,151 @@
+package screen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Detector finds suspicious spans in a string value of a tool result.
+// Implementations must be safe for concurrent use.
+type Detector interface {
+	Name() string
+	// Find returns the byte offsets [start, end) of every match in text.
+	Find(text string) [][2]int
+}
+
+// regexDetector matches any of a set of patterns.
+type regexDetector struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+func newRegexDetector(name string, patterns ...string) (*regexDetector, error) {
+	d := &regexDetector{name: name}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid screening pattern %q: %w", name, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return d, nil
+}
+
+func (d *regexDetector) Name() string { return d.name }
+
+func (d *regexDetector) Find(text string) [][2]int {
+	var spans [][2]int
+	for _, re := range d.patterns {
+		for _, m := range re.FindAllStringIndex(text, -1) {
+			spans = append(spans, [2]int{m[0], m[1]})
+		}
+	}
+	return spans
+}
+
+// hiddenUnicode matches runs of zero-width, bidi control, and Unicode tag
+// characters that render invisibly but are read by models.
+type hiddenUnicode struct{}
+
+func (hiddenUnicode) Name() string { return "hidden_unicode" }
+
+func (hiddenUnicode) Find(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		if isHiddenRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+func isHiddenRune(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, // zero-width space/joiners, LRM/RLM
+		r >= 0x202A && r <= 0x202E,   // bidi embeddings and overrides
+		r >= 0x2060 && r <= 0x2064,   // word joiner, invisible operators
+		r >= 0x2066 && r <= 0x2069,   // bidi isolates
+		r == 0xFEFF,                  // zero-width no-break space
+		r >= 0xE0000 && r <= 0xE007F: // tag characters
+		return true
+	}
+	return false
+}
+
+const (
+	DetectorInstructions  = "instructions"
+	DetectorHiddenUnicode = "hidden_unicode"
+	DetectorExfilLinks    = "exfil_links"
+)
+
+var instructionPatterns = []string{
+	`(?i)\b(?:ignore|disregard|forget|override)\s+(?:(?:all|any|the|your|of)\s+)*(?:previous|prior|above|earlier|preceding|system)\s+(?:instructions?|prompts?|rules|directions|messages)`,
+	`(?i)\byou\s+are\s+now\s+(?:a|an|in|the)\b`,
+	`(?i)\b(?:new|updated|revised)\s+(?:system\s+)?instructions\s*:`,
+	`(?i)\bdo\s+not\s+(?:tell|inform|alert|mention\s+(?:this\s+)?to)\s+the\s+user\b`,
+	`(?i)\b(?:reveal|print|repeat|output)\s+(?:your|the)\s+system\s+prompt\b`,
+	`(?i)<\|?(?:im_start|im_end|system)\|?>`,
+}
+
+var exfilPatterns = []string{
+	// Markdown images are fetched when rendered; a query string or a long
+	// path segment can carry data out.
+	`!\[[^\]]*\]\(\s*<?(?:https?:)?//[^)\s]*(?:\?[^)\s]*|/[A-Za-z0-9_%=+.-]{32,})[^)\s]*\)`,
+	`(?i)\bdata:[a-z]+/[a-z0-9.+-]+(?:;[a-z0-9=.+-]+)*;base64,[A-Za-z0-9+/=]{16,}`,
+}
+
+func builtinDetector(name string) (Detector, error) {
+	switch name {
+	case DetectorInstructions:
+		return newRegexDetector(name, instructionPatterns...)
+	case DetectorHiddenUnicode:
+		return hiddenUnicode{}, nil
+	case DetectorExfilLinks:
+		return newRegexDetector(name, exfilPatterns...)
+	}
+	return nil, fmt.Errorf("unknown screening detector %q (known: %s)", name, strings.Join(BuiltinDetectorNames(), ", "))
+}
+
+// BuiltinDetectorNames lists the detectors accepted by screening.detectors.
+func BuiltinDetectorNames() []string {
+	names := []string{DetectorInstructions, DetectorHiddenUnicode, DetectorExfilLinks}
+	sort.Strings(names)
+	return names
+}
+
+// maxExcerpt bounds how much matched text is kept in findings.
+const maxExcerpt = 80
+
+// excerpt renders matched text for logs and recordings, spelling out
+// invisible characters.
+func excerpt(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n >= maxExcerpt {
+			b.WriteString("...")
+			break
+		}
+		if isHiddenRune(r) || r == utf8.RuneError {
+			fmt.Fprintf(&b, "<U+%04X>", r)
+		} else {
+			b.WriteRune(r)
+		}
+		n++
+	}
+	return b.String()
+}
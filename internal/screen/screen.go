@@ -0,0 +1,238 @@
+package screen
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+const (
+	ActionAnnotate = "annotate"
+	ActionStrip    = "strip"
+	ActionBlock    = "block"
+	ActionOff      = "off"
+)
+
+// MetaKey is the result._meta key under which annotate and strip report
+// findings to the client.
+const MetaKey = "mcp-proxy-gateway/screening"
+
+// Screener runs content detectors over the string values of tools/call
+// results and applies the configured action to results with findings.
+type Screener struct {
+	detectors []Detector
+	action    string
+	tools     map[string]string
+
+	flagged atomic.Uint64
+	blocked atomic.Uint64
+
+	byDetectorMu sync.Mutex
+	byDetector   map[string]uint64
+}
+
+// Result describes the screening outcome for one response.
+type Result struct {
+	Action   string
+	Findings []record.Finding
+}
+
+// Stats are cumulative screening counters.
+type Stats struct {
+	FlaggedTotal       uint64            `json:"flagged_total"`
+	BlockedTotal       uint64            `json:"blocked_total"`
+	FindingsByDetector map[string]uint64 `json:"findings_by_detector,omitempty"`
+}
+
+// New builds a screener from policy plus any extra detectors. It returns nil
+// when every action is off.
+func New(policy config.ScreeningPolicy, extra ...Detector) (*Screener, error) {
+	action := policy.Action
+	if action == "" {
+		action = ActionOff
+	}
+	enabled := action != ActionOff
+	for _, a := range policy.Tools {
+		if a != ActionOff {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	names := policy.Detectors
+	if len(names) == 0 {
+		names = BuiltinDetectorNames()
+	}
+	s := &Screener{action: action, tools: policy.Tools, byDetector: map[string]uint64{}}
+	for _, name := range names {
+		d, err := builtinDetector(strings.ToLower(strings.TrimSpace(name)))
+		if err != nil {
+			return nil, err
+		}
+		s.detectors = append(s.detectors, d)
+	}
+	for _, p := range policy.Patterns {
+		d, err := newRegexDetector(p.Name, p.Regex)
+		if err != nil {
+			return nil, err
+		}
+		s.detectors = append(s.detectors, d)
+	}
+	s.detectors = append(s.detectors, extra...)
+	return s, nil
+}
+
+// Action returns the action for results of tool.
+func (s *Screener) Action(tool string) string {
+	if s == nil {
+		return ActionOff
+	}
+	if a, ok := s.tools[tool]; ok {
+		return a
+	}
+	return s.action
+}
+
+// Screen checks the result of a tools/call response for tool. Responses
+// without findings are returned unchanged. Annotate adds the findings to
+// result._meta, strip additionally removes the matched text, and block
+// replaces the response with a JSON-RPC error listing the findings.
+func (s *Screener) Screen(tool string, raw json.RawMessage) (json.RawMessage, Result) {
+	res := Result{Action: s.Action(tool)}
+	if res.Action == ActionOff {
+		return raw, res
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return raw, res
+	}
+	rawResult, ok := msg["result"]
+	if !ok {
+		return raw, res
+	}
+	var result any
+	if err := json.Unmarshal(rawResult, &result); err != nil {
+		return raw, res
+	}
+
+	result = s.scan(result, "$.result", res.Action == ActionStrip, &res.Findings)
+	if len(res.Findings) == 0 {
+		return raw, res
+	}
+	sort.SliceStable(res.Findings, func(i, j int) bool {
+		return res.Findings[i].Path < res.Findings[j].Path
+	})
+	s.count(res)
+
+	if res.Action == ActionBlock {
+		id := msg["id"]
+		payload, _ := json.Marshal(jsonrpc.ErrorResponse(id, jsonrpc.ErrServer, "tool result blocked by content screening", withoutExcerpts(res.Findings)))
+		return payload, res
+	}
+	if obj, ok := result.(map[string]any); ok {
+		meta, _ := obj["_meta"].(map[string]any)
+		if meta == nil {
+			meta = map[string]any{}
+		}
+		meta[MetaKey] = map[string]any{"action": res.Action, "findings": withoutExcerpts(res.Findings)}
+		obj["_meta"] = meta
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return raw, res
+	}
+	msg["result"] = out
+	rewritten, err := json.Marshal(msg)
+	if err != nil {
+		return raw, res
+	}
+	return json.RawMessage(rewritten), res
+}
+
+// scan walks v collecting findings for every string value and, when strip
+// is set, returns v with the matched text removed.
+func (s *Screener) scan(v any, path string, strip bool, findings *[]record.Finding) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, child := range vv {
+			vv[k] = s.scan(child, path+"."+k, strip, findings)
+		}
+	case []any:
+		for i := range vv {
+			vv[i] = s.scan(vv[i], path+"["+strconv.Itoa(i)+"]", strip, findings)
+		}
+	case string:
+		var spans [][2]int
+		for _, d := range s.detectors {
+			for _, span := range d.Find(vv) {
+				*findings = append(*findings, record.Finding{Detector: d.Name(), Path: path, Excerpt: excerpt(vv[span[0]:span[1]])})
+				spans = append(spans, span)
+			}
+		}
+		if strip && len(spans) > 0 {
+			return removeSpans(vv, spans)
+		}
+	}
+	return v
+}
+
+// withoutExcerpts copies findings for the client, dropping the matched text
+// so it is not echoed back to the model.
+func withoutExcerpts(findings []record.Finding) []record.Finding {
+	out := make([]record.Finding, len(findings))
+	for i, f := range findings {
+		out[i] = record.Finding{Detector: f.Detector, Path: f.Path}
+	}
+	return out
+}
+
+// removeSpans deletes possibly overlapping byte ranges from s.
+func removeSpans(s string, spans [][2]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span[0] > last {
+			b.WriteString(s[last:span[0]])
+		}
+		if span[1] > last {
+			last = span[1]
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (s *Screener) count(res Result) {
+	s.flagged.Add(1)
+	if res.Action == ActionBlock {
+		s.blocked.Add(1)
+	}
+	s.byDetectorMu.Lock()
+	for _, f := range res.Findings {
+		s.byDetector[f.Detector]++
+	}
+	s.byDetectorMu.Unlock()
+}
+
+func (s *Screener) Stats() Stats {
+	st := Stats{FlaggedTotal: s.flagged.Load(), BlockedTotal: s.blocked.Load()}
+	s.byDetectorMu.Lock()
+	defer s.byDetectorMu.Unlock()
+	if len(s.byDetector) > 0 {
+		st.FindingsByDetector = make(map[string]uint64, len(s.byDetector))
+		for k, v := range s.byDetector {
+			st.FindingsByDetector[k] = v
+		}
+	}
+	return st
+}
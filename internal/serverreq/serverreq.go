@@ -0,0 +1,290 @@
+package serverreq
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+)
+
+// DefaultTimeout is how long a relayed request waits for the client's
+// response when the policy sets no timeout.
+const DefaultTimeout = 5 * time.Minute
+
+// idPrefix marks request ids assigned by the gateway. The rest of the id is
+// random so clients cannot guess the ids of requests relayed to others.
+const idPrefix = "mcpgw-"
+
+// Route is where the client's answer to a relayed request is sent.
+type Route struct {
+	// Header holds the headers for the upstream POST, including the
+	// upstream session id.
+	Header http.Header
+	// Client identifies the client the request is delivered to; only a
+	// response from the same client resolves it.
+	Client string
+}
+
+// Pending is a relayed request waiting for the client's response.
+type Pending struct {
+	Route
+	Method string
+	// Request is the request as the upstream sent it; Delivered is the
+	// rewritten request (with the upstream id) when the policy changed it.
+	Request   json.RawMessage
+	Delivered json.RawMessage
+
+	upstreamID json.RawMessage
+	expires    time.Time
+}
+
+// Stats counts server-to-client requests by outcome.
+type Stats struct {
+	Pending   int    `json:"pending"`
+	Relayed   uint64 `json:"relayed_total"`
+	Denied    uint64 `json:"denied_total"`
+	Capped    uint64 `json:"capped_total"`
+	Routed    uint64 `json:"routed_total"`
+	Expired   uint64 `json:"expired_total"`
+	Cancelled uint64 `json:"cancelled_total"`
+}
+
+// Relay applies policy to requests the upstream sends to the client and maps
+// their ids so client responses can be routed back to the upstream session
+// that asked.
+type Relay struct {
+	deny      []string
+	maxTokens int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*Pending
+
+	relayed, denied, capped, routed, expired, cancelled atomic.Uint64
+}
+
+// New builds a relay. Unlike most policy-driven components it is never nil:
+// id mapping keeps client responses routable even without policy rules.
+func New(policy config.ServerRequestPolicy) *Relay {
+	timeout := DefaultTimeout
+	if policy.Timeout != "" {
+		if d, err := time.ParseDuration(policy.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	return &Relay{
+		deny:      policy.Deny,
+		maxTokens: policy.MaxTokens,
+		timeout:   timeout,
+		now:       time.Now,
+		pending:   map[string]*Pending{},
+	}
+}
+
+// IsRequest reports whether raw is a JSON-RPC request carrying an id, as
+// opposed to a response or notification.
+func IsRequest(raw []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return false
+	}
+	return msg.Method != "" && len(msg.ID) > 0 && string(msg.ID) != "null"
+}
+
+// Intercept handles a server-to-client request read from an upstream
+// stream. It returns the request to deliver to the client, with a gateway
+// id, or a non-nil denial: the error response to send upstream instead.
+func (r *Relay) Intercept(raw json.RawMessage, route Route) (deliver json.RawMessage, denial json.RawMessage, err error) {
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, nil, err
+	}
+	var method string
+	if err := json.Unmarshal(msg["method"], &method); err != nil {
+		return nil, nil, err
+	}
+	upstreamID := msg["id"]
+
+	if r.denies(method) {
+		r.denied.Add(1)
+		payload, err := json.Marshal(jsonrpc.ErrorResponse(upstreamID, jsonrpc.ErrMethodNotFound, "Method not found", method))
+		return nil, payload, err
+	}
+
+	p := &Pending{Route: route, Method: method, Request: raw, upstreamID: upstreamID}
+	if method == "sampling/createMessage" && r.maxTokens > 0 {
+		params := map[string]any{}
+		if err := json.Unmarshal(msg["params"], &params); err == nil {
+			if v, ok := params["maxTokens"].(float64); !ok || v > float64(r.maxTokens) {
+				params["maxTokens"] = r.maxTokens
+				if msg["params"], err = json.Marshal(params); err != nil {
+					return nil, nil, err
+				}
+				if p.Delivered, err = json.Marshal(msg); err != nil {
+					return nil, nil, err
+				}
+				r.capped.Add(1)
+			}
+		}
+	}
+
+	var nonce [12]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, nil, err
+	}
+	id := strconv.Quote(idPrefix + hex.EncodeToString(nonce[:]))
+	r.mu.Lock()
+	r.sweepLocked()
+	p.expires = r.now().Add(r.timeout)
+	r.pending[id] = p
+	r.mu.Unlock()
+
+	msg["id"] = json.RawMessage(id)
+	if deliver, err = json.Marshal(msg); err != nil {
+		return nil, nil, err
+	}
+	r.relayed.Add(1)
+	return deliver, nil, nil
+}
+
+// Resolve matches a response from client to a relayed request. It returns
+// the response with the upstream id restored and the pending request, or ok
+// false when the id was not assigned by the relay, has expired, or belongs
+// to a request relayed to another client.
+func (r *Relay) Resolve(raw json.RawMessage, client string) (json.RawMessage, *Pending, bool) {
+	if r == nil {
+		return nil, nil, false
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, nil, false
+	}
+	r.mu.Lock()
+	r.sweepLocked()
+	p, ok := r.pending[string(msg["id"])]
+	ok = ok && p.Client == client
+	if ok {
+		delete(r.pending, string(msg["id"]))
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+	msg["id"] = p.upstreamID
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return nil, nil, false
+	}
+	r.routed.Add(1)
+	return out, p, true
+}
+
+// Cancel maps an upstream notifications/cancelled for a relayed request to
+// the gateway id the client knows and forgets the request. It returns ok
+// false when the notification does not refer to a request relayed on the
+// route's session.
+func (r *Relay) Cancel(raw json.RawMessage, route Route) (json.RawMessage, bool) {
+	if r == nil {
+		return nil, false
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, false
+	}
+	var method string
+	if err := json.Unmarshal(msg["method"], &method); err != nil || method != "notifications/cancelled" {
+		return nil, false
+	}
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		return nil, false
+	}
+	requestID := compact(params["requestId"])
+	session := route.Header.Get("Mcp-Session-Id")
+
+	r.mu.Lock()
+	r.sweepLocked()
+	var id string
+	for gid, p := range r.pending {
+		if compact(p.upstreamID) == requestID && p.Header.Get("Mcp-Session-Id") == session {
+			id = gid
+			break
+		}
+	}
+	delete(r.pending, id)
+	r.mu.Unlock()
+	if id == "" || requestID == "" {
+		return nil, false
+	}
+
+	params["requestId"] = json.RawMessage(id)
+	var err error
+	if msg["params"], err = json.Marshal(params); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		return nil, false
+	}
+	r.cancelled.Add(1)
+	return out, true
+}
+
+// Stats returns the relay counters.
+func (r *Relay) Stats() Stats {
+	r.mu.Lock()
+	pending := len(r.pending)
+	r.mu.Unlock()
+	return Stats{
+		Pending:   pending,
+		Relayed:   r.relayed.Load(),
+		Denied:    r.denied.Load(),
+		Capped:    r.capped.Load(),
+		Routed:    r.routed.Load(),
+		Expired:   r.expired.Load(),
+		Cancelled: r.cancelled.Load(),
+	}
+}
+
+func compact(id json.RawMessage) string {
+	var v any
+	if len(id) == 0 || json.Unmarshal(id, &v) != nil {
+		return ""
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+func (r *Relay) denies(method string) bool {
+	for _, pattern := range r.deny {
+		if pattern == method {
+			return true
+		}
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Relay) sweepLocked() {
+	now := r.now()
+	for id, p := range r.pending {
+		if now.After(p.expires) {
+			delete(r.pending, id)
+			r.expired.Add(1)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package serverreq
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+// response answers the relayed request deliver with an empty result.
+func response(t *testing.T, deliver json.RawMessage) json.RawMessage {
+	t.Helper()
+	var msg struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(deliver, &msg); err != nil || !strings.HasPrefix(string(msg.ID), `"`+idPrefix) {
+		t.Fatalf("expected a gateway id in %s", deliver)
+	}
+	return json.RawMessage(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{}}`)
+}
+
+func TestInterceptAndResolve(t *testing.T) {
+	r := New(config.ServerRequestPolicy{})
+	route := Route{Header: http.Header{"Mcp-Session-Id": []string{"a"}}, Client: "session:a"}
+	first, _, err := r.Intercept(json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`), route)
+	if err != nil {
+		t.Fatalf("intercept: %v", err)
+	}
+	// Another session reusing the same upstream id gets its own gateway id.
+	second, _, _ := r.Intercept(json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`), Route{Header: http.Header{"Mcp-Session-Id": []string{"b"}}, Client: "session:b"})
+	if string(first) == string(second) {
+		t.Fatalf("expected distinct gateway ids, got %s", first)
+	}
+
+	// Only the client the request was delivered to may answer it.
+	if _, _, ok := r.Resolve(response(t, first), "session:b"); ok {
+		t.Fatalf("another client resolved the request")
+	}
+	out, p, ok := r.Resolve(response(t, first), "session:a")
+	if !ok || p.Header.Get("Mcp-Session-Id") != "a" || !strings.Contains(string(out), `"id":1`) {
+		t.Fatalf("resolve: ok=%v out=%s", ok, out)
+	}
+	if _, _, ok := r.Resolve(response(t, first), "session:a"); ok {
+		t.Fatalf("a response must resolve only once")
+	}
+	if st := r.Stats(); st.Relayed != 2 || st.Routed != 1 || st.Pending != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestPendingRequestsExpire(t *testing.T) {
+	r := New(config.ServerRequestPolicy{Timeout: "1m"})
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+	deliver, _, _ := r.Intercept(json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`), Route{})
+	now = now.Add(2 * time.Minute)
+	if _, _, ok := r.Resolve(response(t, deliver), ""); ok {
+		t.Fatalf("expired request resolved")
+	}
+	if st := r.Stats(); st.Expired != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestCancelMapsUpstreamIDToGatewayID(t *testing.T) {
+	r := New(config.ServerRequestPolicy{})
+	a := Route{Header: http.Header{"Mcp-Session-Id": []string{"a"}}}
+	b := Route{Header: http.Header{"Mcp-Session-Id": []string{"b"}}}
+	_, _, _ = r.Intercept(json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`), a)
+	toB, _, _ := r.Intercept(json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`), b)
+	var delivered struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(toB, &delivered)
+
+	cancel := json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"timeout"}}`)
+	out, ok := r.Cancel(cancel, b)
+	if !ok || !strings.Contains(string(out), `"requestId":"`+delivered.ID+`"`) || !strings.Contains(string(out), `"reason":"timeout"`) {
+		t.Fatalf("cancel: ok=%v out=%s", ok, out)
+	}
+	if _, ok := r.Cancel(cancel, b); ok {
+		t.Fatalf("a request must be cancelled only once")
+	}
+	if _, ok := r.Cancel(json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2}}`), a); ok {
+		t.Fatalf("unknown upstream id must not map")
+	}
+	if _, _, ok := r.Resolve(response(t, toB), ""); ok {
+		t.Fatalf("cancelled request must not resolve")
+	}
+	if st := r.Stats(); st.Cancelled != 1 || st.Pending != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
@@ -0,0 +1,333 @@
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+const (
+	SourceReplay    = "replay"
+	SourceCandidate = "candidate"
+
+	StatusMatch    = "match"
+	StatusDiverged = "diverged"
+	StatusMissing  = "missing"
+	StatusError    = "error"
+	// StatusSkipped counts observations not mirrored because the candidate
+	// was saturated; they are not comparisons.
+	StatusSkipped = "skipped"
+)
+
+// maxCandidateBody bounds how much of a candidate response is read.
+const maxCandidateBody = 16 << 20
+
+// DefaultMaxInflight caps concurrent candidate mirrors when Options leaves
+// MaxInflight unset.
+const DefaultMaxInflight = 32
+
+type Options struct {
+	// Baseline recordings to compare live responses against.
+	Replay *record.ReplayStore
+	// Optional second upstream that receives a mirrored copy of each request.
+	Candidate *url.URL
+	// Timeout for mirrored candidate requests.
+	Timeout time.Duration
+	// MaxInflight caps concurrent candidate mirrors (default
+	// DefaultMaxInflight). Observations beyond it are skipped.
+	MaxInflight int
+	// JSON paths excluded from comparison. "$.id" is always ignored.
+	Ignore []string
+	// Optional NDJSON report of every non-matching comparison.
+	ReportPath string
+}
+
+// Comparer checks live upstream responses against a baseline (recordings or
+// a candidate upstream) and reports structural differences.
+type Comparer struct {
+	replay    *record.ReplayStore
+	candidate *url.URL
+	client    *http.Client
+	ignore    []jsondiff.PathPattern
+
+	reportMu sync.Mutex
+	report   *os.File
+
+	inflight sync.WaitGroup
+	slots    chan struct{}
+
+	compared atomic.Uint64
+	matched  atomic.Uint64
+	diverged atomic.Uint64
+	missing  atomic.Uint64
+	errors   atomic.Uint64
+	skipped  atomic.Uint64
+
+	byToolMu sync.Mutex
+	byTool   map[string]uint64
+}
+
+// Observation is one live exchange handed to the comparer.
+type Observation struct {
+	Request   *jsonrpc.Request
+	Signature string
+	Scenario  string
+	Body      []byte
+	// Header carries the request headers forwarded to the candidate.
+	Header http.Header
+	Live   json.RawMessage
+}
+
+// ReportEntry is one line of the diff report.
+type ReportEntry struct {
+	Time        string                `json:"time"`
+	Source      string                `json:"source"`
+	Status      string                `json:"status"`
+	Signature   string                `json:"signature"`
+	Method      string                `json:"method"`
+	Tool        string                `json:"tool,omitempty"`
+	Differences []jsondiff.Difference `json:"differences,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+type Stats struct {
+	ComparedTotal  uint64            `json:"compared_total"`
+	MatchedTotal   uint64            `json:"matched_total"`
+	DivergedTotal  uint64            `json:"diverged_total"`
+	MissingTotal   uint64            `json:"missing_total"`
+	ErrorsTotal    uint64            `json:"errors_total"`
+	SkippedTotal   uint64            `json:"skipped_total"`
+	DivergedByTool map[string]uint64 `json:"diverged_by_tool"`
+}
+
+func New(opts Options) (*Comparer, error) {
+	if opts.Replay == nil && opts.Candidate == nil {
+		return nil, nil
+	}
+	if opts.Replay != nil && opts.Candidate != nil {
+		return nil, errors.New("shadow mode compares against either recordings or a candidate upstream, not both")
+	}
+	c := &Comparer{
+		replay:    opts.Replay,
+		candidate: opts.Candidate,
+		client:    &http.Client{Timeout: opts.Timeout},
+		byTool:    map[string]uint64{},
+	}
+	if opts.Candidate != nil {
+		limit := opts.MaxInflight
+		if limit <= 0 {
+			limit = DefaultMaxInflight
+		}
+		c.slots = make(chan struct{}, limit)
+	}
+	idPath, _ := jsondiff.ParsePath("$.id")
+	c.ignore = append(c.ignore, idPath)
+	for _, raw := range opts.Ignore {
+		p, err := jsondiff.ParsePath(raw)
+		if err != nil {
+			return nil, err
+		}
+		c.ignore = append(c.ignore, p)
+	}
+	if opts.ReportPath != "" {
+		f, err := os.OpenFile(opts.ReportPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		c.report = f
+	}
+	return c, nil
+}
+
+// Observe compares one live response. Recording comparisons run inline;
+// candidate requests are mirrored in the background so they never delay the
+// client. When MaxInflight mirrors are already running the observation is
+// skipped and counted instead of queued.
+func (c *Comparer) Observe(obs Observation) {
+	if c == nil || obs.Request == nil || len(obs.Live) == 0 {
+		return
+	}
+	if c.replay != nil {
+		baseline, ok := c.replay.LookupScenario(obs.Scenario, obs.Request, obs.Signature)
+		if !ok {
+			c.finish(SourceReplay, obs, StatusMissing, nil, nil)
+			return
+		}
+		c.compare(SourceReplay, obs, baseline)
+		return
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		c.skipped.Add(1)
+		return
+	}
+	c.inflight.Add(1)
+	go func() {
+		defer func() {
+			<-c.slots
+			c.inflight.Done()
+		}()
+		baseline, err := c.mirror(obs)
+		if err != nil {
+			c.finish(SourceCandidate, obs, StatusError, nil, err)
+			return
+		}
+		c.compare(SourceCandidate, obs, baseline)
+	}()
+}
+
+func (c *Comparer) mirror(obs Observation) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, c.candidate.String(), bytes.NewReader(obs.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vals := range obs.Header {
+		for _, v := range vals {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCandidateBody))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(body), nil
+}
+
+func (c *Comparer) compare(source string, obs Observation, baseline json.RawMessage) {
+	diffs, err := jsondiff.Diff(baseline, obs.Live, c.ignore)
+	if err != nil {
+		c.finish(source, obs, StatusError, nil, err)
+		return
+	}
+	if len(diffs) == 0 {
+		c.finish(source, obs, StatusMatch, nil, nil)
+		return
+	}
+	c.finish(source, obs, StatusDiverged, diffs, nil)
+}
+
+func (c *Comparer) finish(source string, obs Observation, status string, diffs []jsondiff.Difference, err error) {
+	c.compared.Add(1)
+	tool := toolName(obs.Request)
+	switch status {
+	case StatusMatch:
+		c.matched.Add(1)
+		return
+	case StatusDiverged:
+		c.diverged.Add(1)
+		key := tool
+		if key == "" {
+			key = obs.Request.Method
+		}
+		c.byToolMu.Lock()
+		c.byTool[key]++
+		c.byToolMu.Unlock()
+	case StatusMissing:
+		c.missing.Add(1)
+	case StatusError:
+		c.errors.Add(1)
+	}
+
+	entry := ReportEntry{
+		Time:        time.Now().UTC().Format(time.RFC3339Nano),
+		Source:      source,
+		Status:      status,
+		Signature:   obs.Signature,
+		Method:      obs.Request.Method,
+		Tool:        tool,
+		Differences: diffs,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.writeReport(entry)
+}
+
+func (c *Comparer) writeReport(entry ReportEntry) {
+	if c.report == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.reportMu.Lock()
+	defer c.reportMu.Unlock()
+	_, _ = c.report.Write(append(data, '\n'))
+}
+
+// Wait blocks until all mirrored candidate requests have been compared.
+func (c *Comparer) Wait() {
+	if c == nil {
+		return
+	}
+	c.inflight.Wait()
+}
+
+// Close waits for in-flight comparisons and closes the report file.
+func (c *Comparer) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.Wait()
+	c.reportMu.Lock()
+	defer c.reportMu.Unlock()
+	if c.report == nil {
+		return nil
+	}
+	err := c.report.Close()
+	c.report = nil
+	return err
+}
+
+func (c *Comparer) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	st := Stats{
+		ComparedTotal:  c.compared.Load(),
+		MatchedTotal:   c.matched.Load(),
+		DivergedTotal:  c.diverged.Load(),
+		MissingTotal:   c.missing.Load(),
+		ErrorsTotal:    c.errors.Load(),
+		SkippedTotal:   c.skipped.Load(),
+		DivergedByTool: map[string]uint64{},
+	}
+	c.byToolMu.Lock()
+	for k, v := range c.byTool {
+		st.DivergedByTool[k] = v
+	}
+	c.byToolMu.Unlock()
+	return st
+}
+
+func toolName(req *jsonrpc.Request) string {
+	if req == nil || req.Method != "tools/call" || len(req.Params) == 0 {
+		return ""
+	}
+	var params struct {
+		Tool string `json:"tool"`
+	}
+	_ = json.Unmarshal(req.Params, &params)
+	return params.Tool
+}
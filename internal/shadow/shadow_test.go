@@ -0,0 +1,170 @@
+package shadow
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsonrpc"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/record"
+)
+
+func readReport(t *testing.T, path string) []ReportEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer f.Close()
+	var out []ReportEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e ReportEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("unmarshal report line: %v", err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestComparerAgainstRecordings(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "baseline.ndjson")
+	lines := []record.Entry{
+		{Signature: "sig-search", Request: json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"tool":"search"}}`), Response: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"hits":2,"took_ms":5}}`)},
+		{Signature: "sig-ping", Request: json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"ping"}`), Response: json.RawMessage(`{"jsonrpc":"2.0","id":2,"result":{}}`)},
+	}
+	var data []byte
+	for _, e := range lines {
+		line, _ := json.Marshal(e)
+		data = append(data, append(line, '\n')...)
+	}
+	if err := os.WriteFile(fixture, data, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	store, err := record.LoadReplay(fixture, record.ReplayMatchSignature)
+	if err != nil {
+		t.Fatalf("load replay: %v", err)
+	}
+
+	reportPath := filepath.Join(dir, "report.ndjson")
+	c, err := New(Options{Replay: store, Ignore: []string{"$.result.took_ms"}, ReportPath: reportPath})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	search := &jsonrpc.Request{JSONRPC: "2.0", ID: json.RawMessage(`9`), Method: "tools/call", Params: json.RawMessage(`{"tool":"search"}`)}
+	c.Observe(Observation{Request: search, Signature: "sig-search", Live: json.RawMessage(`{"jsonrpc":"2.0","id":9,"result":{"hits":2,"took_ms":40}}`)})
+	c.Observe(Observation{Request: search, Signature: "sig-search", Live: json.RawMessage(`{"jsonrpc":"2.0","id":9,"result":{"hits":3,"took_ms":40}}`)})
+	ping := &jsonrpc.Request{JSONRPC: "2.0", ID: json.RawMessage(`3`), Method: "ping"}
+	c.Observe(Observation{Request: ping, Signature: "sig-unknown", Live: json.RawMessage(`{"jsonrpc":"2.0","id":3,"result":{}}`)})
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	st := c.Stats()
+	if st.ComparedTotal != 3 || st.MatchedTotal != 1 || st.DivergedTotal != 1 || st.MissingTotal != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if st.DivergedByTool["search"] != 1 {
+		t.Fatalf("expected divergence counted for search, got %+v", st.DivergedByTool)
+	}
+
+	report := readReport(t, reportPath)
+	if len(report) != 2 {
+		t.Fatalf("expected 2 report lines, got %+v", report)
+	}
+	if report[0].Status != StatusDiverged || report[0].Tool != "search" || len(report[0].Differences) != 1 || report[0].Differences[0].Path != "$.result.hits" {
+		t.Fatalf("unexpected diverged line: %+v", report[0])
+	}
+	if report[1].Status != StatusMissing || report[1].Source != SourceReplay {
+		t.Fatalf("unexpected missing line: %+v", report[1])
+	}
+}
+
+func TestComparerMirrorsToCandidate(t *testing.T) {
+	var gotAuth string
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		var req jsonrpc.Request
+		_ = json.Unmarshal(body, &req)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{"version":"v2"}}`))
+	}))
+	t.Cleanup(candidate.Close)
+
+	u, _ := url.Parse(candidate.URL)
+	c, err := New(Options{Candidate: u})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	body := []byte(`{"jsonrpc":"2.0","id":4,"method":"server/info"}`)
+	req := &jsonrpc.Request{JSONRPC: "2.0", ID: json.RawMessage(`4`), Method: "server/info"}
+	c.Observe(Observation{
+		Request: req,
+		Body:    body,
+		Header:  http.Header{"Authorization": []string{"Bearer t"}},
+		Live:    json.RawMessage(`{"jsonrpc":"2.0","id":4,"result":{"version":"v1"}}`),
+	})
+	c.Wait()
+
+	if gotAuth != "Bearer t" {
+		t.Fatalf("expected forwarded Authorization, got %q", gotAuth)
+	}
+	st := c.Stats()
+	if st.DivergedTotal != 1 || st.DivergedByTool["server/info"] != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestComparerSkipsMirrorsWhenSaturated(t *testing.T) {
+	release := make(chan struct{})
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	t.Cleanup(candidate.Close)
+
+	u, _ := url.Parse(candidate.URL)
+	c, err := New(Options{Candidate: u, MaxInflight: 1})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	obs := Observation{
+		Request: &jsonrpc.Request{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: "server/info"},
+		Body:    []byte(`{"jsonrpc":"2.0","id":1,"method":"server/info"}`),
+		Live:    json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{}}`),
+	}
+	for i := 0; i < 3; i++ {
+		c.Observe(obs)
+	}
+	close(release)
+	c.Wait()
+
+	st := c.Stats()
+	if st.SkippedTotal != 2 || st.MatchedTotal != 1 || st.ComparedTotal != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestNewRequiresSingleBaseline(t *testing.T) {
+	if c, err := New(Options{}); c != nil || err != nil {
+		t.Fatalf("expected disabled comparer, got %v %v", c, err)
+	}
+	u, _ := url.Parse("http://127.0.0.1:1")
+	if _, err := New(Options{Replay: &record.ReplayStore{}, Candidate: u}); err == nil {
+		t.Fatalf("expected error when both baselines are set")
+	}
+	if _, err := New(Options{Candidate: u, Ignore: []string{"result"}}); err == nil {
+		t.Fatalf("expected invalid ignore path error")
+	}
+}
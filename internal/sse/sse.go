@@ -0,0 +1,126 @@
+package sse
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Event is a single server-sent event. Multi-line data fields are joined
+// with "\n" as described in the HTML event stream spec.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry string
+}
+
+// Parse decodes one event block (the lines between blank-line separators).
+// It reports false for blocks that carry no fields, such as comment-only
+// keepalives.
+func Parse(block []byte) (Event, bool) {
+	ev := Event{}
+	seen := false
+	var data []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(block), "\r\n", "\n"), "\n") {
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			ev.Event = value
+		case "id":
+			ev.ID = value
+		case "retry":
+			ev.Retry = value
+		default:
+			continue
+		}
+		seen = true
+	}
+	ev.Data = strings.Join(data, "\n")
+	return ev, seen
+}
+
+// Bytes encodes the event including its terminating blank line.
+func (e Event) Bytes() []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry != "" {
+		buf.WriteString("retry: " + e.Retry + "\n")
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// Splitter is an io.Writer that splits arbitrary stream chunks into complete
+// event blocks, invoking fn for each parsed event.
+type Splitter struct {
+	buf     bytes.Buffer
+	fn      func(Event)
+	comment func(string)
+}
+
+func NewSplitter(fn func(Event)) *Splitter {
+	return &Splitter{fn: fn}
+}
+
+// OnComment makes the splitter report comment lines (such as ": ping"
+// keepalives), without the leading colon, before the event of their block.
+func (s *Splitter) OnComment(fn func(string)) {
+	s.comment = fn
+}
+
+func (s *Splitter) Write(p []byte) (int, error) {
+	// Dropping CR keeps CRLF streams intact even when a chunk ends between
+	// the CR and the LF.
+	s.buf.Write(bytes.ReplaceAll(p, []byte("\r"), nil))
+	for {
+		data := s.buf.Bytes()
+		idx := bytes.Index(data, []byte("\n\n"))
+		if idx < 0 {
+			break
+		}
+		block := make([]byte, idx)
+		copy(block, data[:idx])
+		s.buf.Next(idx + 2)
+		s.emit(block)
+	}
+	return len(p), nil
+}
+
+// Flush parses any buffered partial block as a final event: a trailing event
+// without a terminating blank line.
+func (s *Splitter) Flush() {
+	if s.buf.Len() == 0 {
+		return
+	}
+	block := make([]byte, s.buf.Len())
+	copy(block, s.buf.Bytes())
+	s.buf.Reset()
+	s.emit(block)
+}
+
+func (s *Splitter) emit(block []byte) {
+	if s.comment != nil {
+		for _, line := range strings.Split(string(block), "\n") {
+			if comment, ok := strings.CutPrefix(line, ":"); ok {
+				s.comment(comment)
+			}
+		}
+	}
+	if ev, ok := Parse(block); ok {
+		s.fn(ev)
+	}
+}
@@ -0,0 +1,54 @@
+package sse
+
+import "testing"
+
+func TestSplitterParsesFields(t *testing.T) {
+	var got []Event
+	s := NewSplitter(func(ev Event) { got = append(got, ev) })
+	_, _ = s.Write([]byte(": keepalive\n\nid: 1\nevent: message\ndata: line one\ndata: line two\n\r\n"))
+	if len(got) != 1 || got[0].ID != "1" || got[0].Event != "message" || got[0].Data != "line one\nline two" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+}
+
+func TestSplitterHandlesChunkBoundaries(t *testing.T) {
+	var got []Event
+	s := NewSplitter(func(ev Event) { got = append(got, ev) })
+	for _, chunk := range []string{"data: a", "\r", "\n\r\ndata: b\n", "\n"} {
+		if _, err := s.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if len(got) != 2 || got[0].Data != "a" || got[1].Data != "b" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+}
+
+func TestSplitterFlushEmitsTrailingEvent(t *testing.T) {
+	var got []Event
+	s := NewSplitter(func(ev Event) { got = append(got, ev) })
+	_, _ = s.Write([]byte("data: a\n\ndata: tail"))
+	s.Flush()
+	s.Flush()
+	if len(got) != 2 || got[1].Data != "tail" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+}
+
+func TestSplitterReportsComments(t *testing.T) {
+	var events, comments []string
+	s := NewSplitter(func(ev Event) { events = append(events, ev.Data) })
+	s.OnComment(func(c string) { comments = append(comments, c) })
+	_, _ = s.Write([]byte(": ping\n\n:note\ndata: a\n\n"))
+	if len(events) != 1 || events[0] != "a" || len(comments) != 2 || comments[0] != " ping" || comments[1] != "note" {
+		t.Fatalf("events=%q comments=%q", events, comments)
+	}
+}
+
+func TestEventBytesRoundTrip(t *testing.T) {
+	in := Event{ID: "7", Event: "message", Data: "x\ny"}
+	out, ok := Parse(in.Bytes())
+	if !ok || out != in {
+		t.Fatalf("round trip mismatch: %+v", out)
+	}
+}
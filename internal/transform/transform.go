@@ -0,0 +1,411 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/jsondiff"
+)
+
+const (
+	OpSet     = "set"
+	OpDefault = "default"
+	OpRemove  = "remove"
+	OpClamp   = "clamp"
+	OpRename  = "rename"
+)
+
+// argsPrefix is the request path every op path starts with.
+const argsPrefix = "$.params.arguments"
+
+type op struct {
+	kind  string
+	path  string
+	segs  []string // below params.arguments
+	to    []string
+	value []byte // JSON, decoded per call so calls never share state
+	min   *float64
+	max   *float64
+}
+
+type toolTransform struct {
+	schema        map[string]any
+	stripUnknown  bool
+	applyDefaults bool
+	ops           []op
+}
+
+// Transformer rewrites tools/call arguments according to per-tool policy.
+type Transformer struct {
+	tools map[string]*toolTransform
+}
+
+// New builds a transformer from the tool entries of a policy. It returns nil
+// when no tool has a transform.
+func New(tools map[string]config.ToolEntry) (*Transformer, error) {
+	t := &Transformer{tools: map[string]*toolTransform{}}
+	for name, entry := range tools {
+		cfg := entry.Transform
+		if !cfg.StripUnknown && !cfg.ApplyDefaults && len(cfg.Ops) == 0 {
+			continue
+		}
+		tt := &toolTransform{schema: entry.Schema, stripUnknown: cfg.StripUnknown, applyDefaults: cfg.ApplyDefaults}
+		for i, c := range cfg.Ops {
+			o := op{kind: strings.ToLower(c.Op), path: c.Path, min: c.Min, max: c.Max}
+			var err error
+			if o.segs, err = argSegments(c.Path); err != nil {
+				return nil, fmt.Errorf("tool %s transform op %d: %w", name, i, err)
+			}
+			if o.kind == OpRename {
+				if o.to, err = argSegments(c.To); err != nil {
+					return nil, fmt.Errorf("tool %s transform op %d: %w", name, i, err)
+				}
+			}
+			if o.kind == OpSet || o.kind == OpDefault {
+				if o.value, err = json.Marshal(c.Value); err != nil {
+					return nil, fmt.Errorf("tool %s transform op %d: %w", name, i, err)
+				}
+			}
+			tt.ops = append(tt.ops, o)
+		}
+		t.tools[name] = tt
+	}
+	if len(t.tools) == 0 {
+		return nil, nil
+	}
+	return t, nil
+}
+
+func argSegments(path string) ([]string, error) {
+	p, err := jsondiff.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	segs := p.Segments()
+	if len(segs) < 3 || segs[0] != "params" || segs[1] != "arguments" {
+		return nil, fmt.Errorf("path %q must be below %s", path, argsPrefix)
+	}
+	return segs[2:], nil
+}
+
+// Apply rewrites args for tool and lists the changes made (e.g. "clamp
+// $.params.arguments.limit"). args is returned unchanged, with no changes,
+// when the tool has no transform, nothing applied, or args is not an object.
+func (t *Transformer) Apply(tool string, args json.RawMessage) (json.RawMessage, []string, error) {
+	if t == nil {
+		return args, nil, nil
+	}
+	tt, ok := t.tools[tool]
+	if !ok {
+		return args, nil, nil
+	}
+	root := map[string]any{}
+	if len(args) > 0 && string(args) != "null" {
+		var v any
+		if err := json.Unmarshal(args, &v); err != nil {
+			return nil, nil, err
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return args, nil, nil
+		}
+		root = obj
+	}
+
+	var changes []string
+	if tt.stripUnknown {
+		stripUnknown(root, tt.schema, argsPrefix, &changes)
+	}
+	if tt.applyDefaults {
+		applyDefaults(root, tt.schema, argsPrefix, &changes)
+	}
+	for _, o := range tt.ops {
+		changed, err := o.apply(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		if changed {
+			changes = append(changes, o.kind+" "+o.path)
+		}
+	}
+	if len(changes) == 0 {
+		return args, nil, nil
+	}
+	out, err := json.Marshal(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return json.RawMessage(out), changes, nil
+}
+
+func (o op) apply(root map[string]any) (bool, error) {
+	switch o.kind {
+	case OpSet, OpDefault:
+		var v any
+		if err := json.Unmarshal(o.value, &v); err != nil {
+			return false, err
+		}
+		return setPath(root, o.segs, v, o.kind == OpDefault), nil
+	case OpRemove:
+		return removePath(root, o.segs), nil
+	case OpClamp:
+		return clampPath(root, o.segs, o.min, o.max), nil
+	case OpRename:
+		v, ok := getPath(root, o.segs)
+		if !ok {
+			return false, nil
+		}
+		removePath(root, o.segs)
+		if !setPath(root, o.to, v, false) {
+			// The destination is unreachable (e.g. a missing array index);
+			// keep the value where it was.
+			setPath(root, o.segs, v, false)
+			return false, nil
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown transform op %q", o.kind)
+}
+
+// setPath sets the value at segs, creating missing objects along the way.
+// Array indexes must already exist. With onlyMissing an existing value is
+// kept. It reports whether anything changed.
+func setPath(v any, segs []string, value any, onlyMissing bool) bool {
+	seg, rest := segs[0], segs[1:]
+	switch vv := v.(type) {
+	case map[string]any:
+		child, ok := vv[seg]
+		if len(rest) == 0 {
+			if ok && onlyMissing {
+				return false
+			}
+			vv[seg] = value
+			return true
+		}
+		if !ok || child == nil {
+			child = map[string]any{}
+			vv[seg] = child
+		}
+		return setPath(child, rest, value, onlyMissing)
+	case []any:
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(vv) {
+			return false
+		}
+		if len(rest) == 0 {
+			if onlyMissing {
+				return false
+			}
+			vv[i] = value
+			return true
+		}
+		return setPath(vv[i], rest, value, onlyMissing)
+	}
+	return false
+}
+
+func getPath(v any, segs []string) (any, bool) {
+	for _, seg := range segs {
+		switch vv := v.(type) {
+		case map[string]any:
+			child, ok := vv[seg]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil, false
+			}
+			v = vv[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// removePath deletes object members at segs, where "*" matches any key or
+// index on the way. Array elements are not removed.
+func removePath(v any, segs []string) bool {
+	seg, rest := segs[0], segs[1:]
+	changed := false
+	switch vv := v.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			if seg == "*" {
+				changed = len(vv) > 0
+				for k := range vv {
+					delete(vv, k)
+				}
+				return changed
+			}
+			_, ok := vv[seg]
+			delete(vv, seg)
+			return ok
+		}
+		if seg == "*" {
+			for _, child := range vv {
+				changed = removePath(child, rest) || changed
+			}
+			return changed
+		}
+		if child, ok := vv[seg]; ok {
+			return removePath(child, rest)
+		}
+	case []any:
+		if len(rest) == 0 {
+			return false
+		}
+		if seg == "*" {
+			for _, child := range vv {
+				changed = removePath(child, rest) || changed
+			}
+			return changed
+		}
+		if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(vv) {
+			return removePath(vv[i], rest)
+		}
+	}
+	return false
+}
+
+// clampPath bounds every number at segs. Non-numeric values are left alone.
+func clampPath(v any, segs []string, min, max *float64) bool {
+	clamp := func(x any) (any, bool) {
+		n, ok := x.(float64)
+		if !ok {
+			return x, false
+		}
+		if min != nil && n < *min {
+			return *min, true
+		}
+		if max != nil && n > *max {
+			return *max, true
+		}
+		return x, false
+	}
+	seg, rest := segs[0], segs[1:]
+	changed := false
+	visit := func(child any, set func(any)) {
+		if len(rest) == 0 {
+			if out, ok := clamp(child); ok {
+				set(out)
+				changed = true
+			}
+			return
+		}
+		changed = clampPath(child, rest, min, max) || changed
+	}
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, child := range vv {
+			if seg == "*" || seg == k {
+				k := k
+				visit(child, func(x any) { vv[k] = x })
+			}
+		}
+	case []any:
+		for i, child := range vv {
+			if seg == "*" || seg == strconv.Itoa(i) {
+				i := i
+				visit(child, func(x any) { vv[i] = x })
+			}
+		}
+	}
+	return changed
+}
+
+// stripUnknown removes object members not declared in the schema's
+// properties. Objects whose schema allows additional properties, or declares
+// no properties, are kept as they are.
+func stripUnknown(v any, schema map[string]any, path string, changes *[]string) {
+	switch vv := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if props == nil {
+			return
+		}
+		additional := false
+		switch ap := schema["additionalProperties"].(type) {
+		case bool:
+			additional = ap
+		case map[string]any:
+			additional = true
+		}
+		for _, k := range sortedKeys(vv) {
+			sub, declared := props[k].(map[string]any)
+			if _, ok := props[k]; !ok && !additional {
+				delete(vv, k)
+				*changes = append(*changes, "strip "+path+"."+k)
+				continue
+			}
+			if declared {
+				stripUnknown(vv[k], sub, path+"."+k, changes)
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return
+		}
+		for i, child := range vv {
+			stripUnknown(child, items, path+"["+strconv.Itoa(i)+"]", changes)
+		}
+	}
+}
+
+// applyDefaults fills missing object members from the "default" keyword of
+// their property schemas, descending into members that are present.
+func applyDefaults(v any, schema map[string]any, path string, changes *[]string) {
+	switch vv := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		for _, k := range sortedKeys(props) {
+			sub, ok := props[k].(map[string]any)
+			if !ok {
+				continue
+			}
+			if child, ok := vv[k]; ok {
+				applyDefaults(child, sub, path+"."+k, changes)
+				continue
+			}
+			def, ok := sub["default"]
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(def)
+			if err != nil {
+				continue
+			}
+			var copied any
+			if err := json.Unmarshal(data, &copied); err != nil {
+				continue
+			}
+			vv[k] = copied
+			*changes = append(*changes, "default "+path+"."+k)
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return
+		}
+		for i, child := range vv {
+			applyDefaults(child, items, path+"["+strconv.Itoa(i)+"]", changes)
+		}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestApplyOpsAndSchemaSteps(t *testing.T) {
+	tr, err := New(map[string]config.ToolEntry{
+		"search": {
+			Schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query":       map[string]any{"type": "string"},
+					"max_results": map[string]any{"type": "integer", "default": 5},
+					"filters": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"lang": map[string]any{"type": "string", "default": "en"}},
+					},
+					"workspace_root": map[string]any{"type": "string"},
+					"q":              map[string]any{"type": "string"},
+				},
+			},
+			Transform: config.TransformPolicy{
+				StripUnknown:  true,
+				ApplyDefaults: true,
+				Ops: []config.TransformOp{
+					{Op: OpSet, Path: "$.params.arguments.workspace_root", Value: "/srv/ws"},
+					{Op: OpClamp, Path: "$.params.arguments.max_results", Min: float(1), Max: float(10)},
+					{Op: OpRename, Path: "$.params.arguments.q", To: "$.params.arguments.query"},
+					{Op: OpDefault, Path: "$.params.arguments.query", Value: "*"},
+				},
+			},
+		},
+		"plain": {},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	out, changes, err := tr.Apply("search", json.RawMessage(`{"q":"go","max_results":50,"workspace_root":"/","debug":true,"filters":{}}`))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]any{
+		"query":          "go",
+		"max_results":    float64(10),
+		"workspace_root": "/srv/ws",
+		"filters":        map[string]any{"lang": "en"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	wantChanges := []string{
+		"strip $.params.arguments.debug",
+		"default $.params.arguments.filters.lang",
+		"set $.params.arguments.workspace_root",
+		"clamp $.params.arguments.max_results",
+		"rename $.params.arguments.q",
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Fatalf("changes=%v want %v", changes, wantChanges)
+	}
+
+	// Missing arguments get defaults and set values; untouched args keep
+	// their bytes.
+	out, _, _ = tr.Apply("search", nil)
+	if string(out) != `{"max_results":5,"query":"*","workspace_root":"/srv/ws"}` {
+		t.Fatalf("unexpected result for empty args: %s", out)
+	}
+	raw := json.RawMessage(`{ "x": 1 }`)
+	if out, changes, _ := tr.Apply("plain", raw); string(out) != string(raw) || changes != nil {
+		t.Fatalf("tool without transform changed: %s %v", out, changes)
+	}
+}
+
+func TestRemoveWildcardAndNoTransforms(t *testing.T) {
+	tr, err := New(map[string]config.ToolEntry{
+		"batch": {Transform: config.TransformPolicy{Ops: []config.TransformOp{
+			{Op: OpRemove, Path: "$.params.arguments.items[*].secret"},
+			{Op: OpClamp, Path: "$.params.arguments.items[*].n", Max: float(3)},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	out, _, err := tr.Apply("batch", json.RawMessage(`{"items":[{"secret":"a","n":9},{"n":"x"}]}`))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if string(out) != `{"items":[{"n":3},{"n":"x"}]}` {
+		t.Fatalf("unexpected result: %s", out)
+	}
+
+	if tr, err := New(map[string]config.ToolEntry{"x": {}}); tr != nil || err != nil {
+		t.Fatalf("expected nil transformer, got %v %v", tr, err)
+	}
+}
@@ -0,0 +1,300 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+type uriRule struct {
+	scheme string
+	prefix string
+	glob   string
+}
+
+func (r uriRule) String() string {
+	switch {
+	case r.scheme != "":
+		return "scheme " + r.scheme
+	case r.prefix != "":
+		return "prefix " + r.prefix
+	}
+	return "glob " + r.glob
+}
+
+func (r uriRule) match(uri string) bool {
+	switch {
+	case r.scheme != "":
+		return strings.EqualFold(uriScheme(uri), r.scheme)
+	case r.prefix != "":
+		return strings.HasPrefix(uri, r.prefix)
+	}
+	ok, _ := path.Match(r.glob, uri)
+	return ok
+}
+
+// coversTemplate reports whether the rule matches every URI starting with
+// the literal prefix lit of a URI template.
+func (r uriRule) coversTemplate(lit string) bool {
+	switch {
+	case r.scheme != "":
+		return strings.Contains(lit, ":") && strings.EqualFold(uriScheme(lit), r.scheme)
+	case r.prefix != "":
+		return strings.HasPrefix(lit, r.prefix)
+	}
+	return false
+}
+
+// mayMatchTemplate reports whether the rule can match some URI starting with
+// the literal prefix lit of a URI template.
+func (r uriRule) mayMatchTemplate(lit string) bool {
+	if r.coversTemplate(lit) {
+		return true
+	}
+	p := r.prefix
+	switch {
+	case r.scheme != "":
+		return !strings.Contains(lit, ":") && strings.HasPrefix(strings.ToLower(r.scheme), strings.ToLower(lit))
+	case r.glob != "":
+		p = r.glob
+		if i := strings.IndexAny(p, `*?[\`); i >= 0 {
+			p = p[:i]
+		}
+	}
+	return strings.HasPrefix(lit, p) || strings.HasPrefix(p, lit)
+}
+
+func uriScheme(uri string) string {
+	scheme, _, ok := strings.Cut(uri, ":")
+	if !ok {
+		return ""
+	}
+	return scheme
+}
+
+// lowerScheme lowercases the scheme of a rule pattern so it compares with
+// normalized URIs.
+func lowerScheme(pattern string) string {
+	scheme, rest, ok := strings.Cut(pattern, ":")
+	if !ok || strings.ContainsAny(scheme, "/*?[") {
+		return pattern
+	}
+	return strings.ToLower(scheme) + ":" + rest
+}
+
+var errURITraversal = errors.New("resource URI contains a .. path segment")
+
+// normalizeURI returns the form of uri that rules are matched against: the
+// scheme and host lowercased, and the path percent-decoded and cleaned, so
+// "file:///a/%2e%2e/b", "file:///a//b", and "FILE:///a/x/../b" cannot slip
+// past a prefix rule. A ".." that survives cleaning (relative paths) is an
+// error.
+func normalizeURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if u.Scheme != "" {
+		b.WriteString(strings.ToLower(u.Scheme))
+		b.WriteString(":")
+	}
+	p := u.Path
+	if u.Opaque != "" {
+		if p, err = url.PathUnescape(u.Opaque); err != nil {
+			return "", err
+		}
+	}
+	if u.Host != "" || strings.HasPrefix(uri[len(u.Scheme):], "://") {
+		b.WriteString("//")
+		b.WriteString(strings.ToLower(u.Host))
+	}
+	if p != "" {
+		clean := path.Clean(p)
+		if strings.HasSuffix(p, "/") && clean != "/" {
+			clean += "/"
+		}
+		for _, seg := range strings.Split(clean, "/") {
+			if seg == ".." {
+				return "", errURITraversal
+			}
+		}
+		b.WriteString(clean)
+	}
+	if u.RawQuery != "" {
+		b.WriteString("?")
+		b.WriteString(u.RawQuery)
+	}
+	return b.String(), nil
+}
+
+func newURIRules(rules []config.URIRule) []uriRule {
+	out := make([]uriRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, uriRule{scheme: r.Scheme, prefix: lowerScheme(r.Prefix), glob: lowerScheme(r.Glob)})
+	}
+	return out
+}
+
+// access holds the resource and prompt policies.
+type access struct {
+	resourceAllow []uriRule
+	resourceDeny  []uriRule
+	promptAllow   map[string]struct{}
+	promptDeny    map[string]struct{}
+	promptSchemas map[string]*gojsonschema.Schema
+}
+
+func newAccess(resources config.ResourcePolicy, prompts config.PromptPolicy) (*access, error) {
+	a := &access{
+		resourceAllow: newURIRules(resources.Allow),
+		resourceDeny:  newURIRules(resources.Deny),
+		promptAllow:   map[string]struct{}{},
+		promptDeny:    map[string]struct{}{},
+		promptSchemas: map[string]*gojsonschema.Schema{},
+	}
+	for _, name := range prompts.Allow {
+		a.promptAllow[name] = struct{}{}
+	}
+	for _, name := range prompts.Deny {
+		a.promptDeny[name] = struct{}{}
+	}
+	for name, raw := range prompts.Schemas {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("schema for prompt %s: %w", name, err)
+		}
+		a.promptSchemas[name] = schema
+	}
+	return a, nil
+}
+
+func (a *access) active() bool {
+	return a != nil && (len(a.resourceAllow) > 0 || len(a.resourceDeny) > 0 || len(a.promptAllow) > 0 || len(a.promptDeny) > 0)
+}
+
+func (a *access) resourceViolations(uri string) []string {
+	if a == nil || (len(a.resourceAllow) == 0 && len(a.resourceDeny) == 0) {
+		return nil
+	}
+	uri, err := normalizeURI(uri)
+	if err != nil {
+		return []string{"invalid resource URI: " + err.Error()}
+	}
+	var violations []string
+	for _, rule := range a.resourceDeny {
+		if rule.match(uri) {
+			violations = append(violations, "resource is denied by "+rule.String())
+			break
+		}
+	}
+	if len(a.resourceAllow) > 0 {
+		allowed := false
+		for _, rule := range a.resourceAllow {
+			if rule.match(uri) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, "resource not in allowlist")
+		}
+	}
+	return violations
+}
+
+func (a *access) promptViolations(name string) []string {
+	if a == nil {
+		return nil
+	}
+	var violations []string
+	if _, denied := a.promptDeny[name]; denied {
+		violations = append(violations, "prompt is denied")
+	}
+	if len(a.promptAllow) > 0 {
+		if _, ok := a.promptAllow[name]; !ok {
+			violations = append(violations, "prompt not in allowlist")
+		}
+	}
+	return violations
+}
+
+// ValidateResource checks a resources/read or resources/subscribe URI
+// against the resource policy.
+func (v *Validator) ValidateResource(uri string) Decision {
+	if v.mode == "off" {
+		return Decision{Allowed: true}
+	}
+	return v.decide(v.access.resourceViolations(uri))
+}
+
+// ValidatePrompt checks a prompts/get request against the prompt policy and
+// the prompt's argument schema. Missing arguments validate as {}.
+func (v *Validator) ValidatePrompt(name string, args json.RawMessage) (Decision, error) {
+	if v.mode == "off" {
+		return Decision{Allowed: true}, nil
+	}
+	violations := v.access.promptViolations(name)
+	if v.access != nil {
+		if schema, ok := v.access.promptSchemas[name]; ok {
+			if len(args) == 0 || string(args) == "null" {
+				args = json.RawMessage("{}")
+			}
+			result, err := schema.Validate(gojsonschema.NewBytesLoader(args))
+			if err != nil {
+				return Decision{}, err
+			}
+			for _, desc := range result.Errors() {
+				violations = append(violations, desc.String())
+			}
+		}
+	}
+	return v.decide(violations), nil
+}
+
+// FiltersLists reports whether resources/list, resources/templates/list, and
+// prompts/list results are filtered. Lists are only filtered in enforce mode.
+func (v *Validator) FiltersLists() bool {
+	return v != nil && v.mode == "enforce" && v.access.active()
+}
+
+// ResourceVisible reports whether a resources/list entry is kept.
+func (v *Validator) ResourceVisible(uri string) bool {
+	return !v.FiltersLists() || len(v.access.resourceViolations(uri)) == 0
+}
+
+// ResourceTemplateVisible reports whether a resources/templates/list entry
+// is kept: templates are hidden when no URI they expand to can be allowed,
+// judged by the literal text before the first expression.
+func (v *Validator) ResourceTemplateVisible(template string) bool {
+	if !v.FiltersLists() {
+		return true
+	}
+	lit, _, _ := strings.Cut(template, "{")
+	for _, rule := range v.access.resourceDeny {
+		if rule.coversTemplate(lit) {
+			return false
+		}
+	}
+	if len(v.access.resourceAllow) == 0 {
+		return true
+	}
+	for _, rule := range v.access.resourceAllow {
+		if rule.mayMatchTemplate(lit) {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptVisible reports whether a prompts/list entry is kept.
+func (v *Validator) PromptVisible(name string) bool {
+	return !v.FiltersLists() || len(v.access.promptViolations(name)) == 0
+}
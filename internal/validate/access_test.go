@@ -0,0 +1,116 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func newAccessValidator(t *testing.T, mode string) *Validator {
+	t.Helper()
+	v, err := New(&config.Policy{
+		Mode: mode,
+		Resources: config.ResourcePolicy{
+			Allow: []config.URIRule{{Prefix: "file:///repo/"}, {Scheme: "https"}, {Glob: "db://*/tables"}},
+			Deny:  []config.URIRule{{Glob: "file:///repo/*.env"}},
+		},
+		Prompts: config.PromptPolicy{
+			Deny: []string{"jailbreak"},
+			Schemas: map[string]map[string]any{
+				"summarize": {"type": "object", "required": []any{"text"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator init: %v", err)
+	}
+	return v
+}
+
+func TestValidateResource(t *testing.T) {
+	v := newAccessValidator(t, "enforce")
+	cases := map[string]bool{
+		"file:///repo/main.go":  true,
+		"file:///repo/.env":     false,
+		"file:///etc/passwd":    false,
+		"HTTPS://example.com/a": true,
+		"db://prod/tables":      true,
+		"db://prod/tables/x":    false,
+	}
+	for uri, want := range cases {
+		if got := v.ValidateResource(uri).Allowed; got != want {
+			t.Errorf("%s: allowed=%v want %v", uri, got, want)
+		}
+		if got := v.ResourceVisible(uri); got != want {
+			t.Errorf("%s: visible=%v want %v", uri, got, want)
+		}
+	}
+
+	templates := map[string]bool{
+		"file:///repo/{path}": true,
+		"file:///{path}":      true,
+		"ftp://{host}/{path}": false,
+		"{+uri}":              true,
+	}
+	for tmpl, want := range templates {
+		if got := v.ResourceTemplateVisible(tmpl); got != want {
+			t.Errorf("template %s: visible=%v want %v", tmpl, got, want)
+		}
+	}
+
+	audit := newAccessValidator(t, "audit")
+	if d := audit.ValidateResource("file:///etc/passwd"); !d.Allowed || len(d.Violations) == 0 {
+		t.Fatalf("audit should allow with violations, got %+v", d)
+	}
+	if !audit.ResourceVisible("file:///etc/passwd") {
+		t.Fatalf("audit mode must not filter lists")
+	}
+}
+
+func TestValidateResourceNormalizesURIs(t *testing.T) {
+	v, err := New(&config.Policy{
+		Mode: "enforce",
+		Resources: config.ResourcePolicy{
+			Allow: []config.URIRule{{Prefix: "file:///srv/docs/"}},
+			Deny:  []config.URIRule{{Prefix: "file:///srv/docs/secret/"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator init: %v", err)
+	}
+	cases := map[string]bool{
+		"file:///srv/docs/readme.md":           true,
+		"FILE:///srv/docs/readme.md":           true,
+		"file:///srv/docs/a/./b.md":            true,
+		"file:///srv/docs/../../etc/passwd":    false,
+		"file:///srv/docs/%2e%2e/etc/passwd":   false,
+		"file:///srv/docs/%73ecret/key":        false,
+		"file:///srv/docs//secret/key":         false,
+		"file:///srv/docs/a/../secret/key":     false,
+		"file:///srv/docs/a%2F..%2Fsecret/key": false,
+		"FILE:///srv/docs/secret/key":          false,
+		"file:srv/../../docs/x":                false,
+	}
+	for uri, want := range cases {
+		if got := v.ValidateResource(uri).Allowed; got != want {
+			t.Errorf("%s: allowed=%v want %v", uri, got, want)
+		}
+	}
+}
+
+func TestValidatePrompt(t *testing.T) {
+	v := newAccessValidator(t, "enforce")
+	if d, err := v.ValidatePrompt("jailbreak", nil); err != nil || d.Allowed {
+		t.Fatalf("expected denied prompt, got %+v err=%v", d, err)
+	}
+	if d, err := v.ValidatePrompt("summarize", nil); err != nil || d.Allowed {
+		t.Fatalf("expected missing argument violation, got %+v err=%v", d, err)
+	}
+	if d, err := v.ValidatePrompt("summarize", json.RawMessage(`{"text":"hi"}`)); err != nil || !d.Allowed {
+		t.Fatalf("expected allowed, got %+v err=%v", d, err)
+	}
+	if v.PromptVisible("jailbreak") || !v.PromptVisible("other") {
+		t.Fatalf("unexpected prompt visibility")
+	}
+}
@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+// methodPolicy holds the JSON-RPC method allow/deny lists and param schemas.
+type methodPolicy struct {
+	defaultDeny bool
+	allow       []string
+	deny        []string
+	params      map[string]*gojsonschema.Schema
+}
+
+func newMethodPolicy(policy config.MethodPolicy) (*methodPolicy, error) {
+	m := &methodPolicy{
+		defaultDeny: policy.DefaultDeny,
+		allow:       policy.Allow,
+		deny:        policy.Deny,
+		params:      map[string]*gojsonschema.Schema{},
+	}
+	for method, raw := range policy.Params {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("params schema for %s: %w", method, err)
+		}
+		m.params[method] = schema
+	}
+	return m, nil
+}
+
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if pattern == method {
+			return true
+		}
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMethod checks method against the method allow and deny lists.
+// Rejected methods are answered as not found.
+func (v *Validator) ValidateMethod(method string) Decision {
+	if v == nil || v.mode == "off" || v.methods == nil {
+		return Decision{Allowed: true}
+	}
+	violations := []string{}
+	if matchMethod(v.methods.deny, method) {
+		violations = append(violations, "method is denied")
+	}
+	if (v.methods.defaultDeny || len(v.methods.allow) > 0) && !matchMethod(v.methods.allow, method) {
+		violations = append(violations, "method not in allowlist")
+	}
+	return v.decide(violations)
+}
+
+// ValidateMethodParams checks params against the method's params schema.
+// Methods without a schema are always allowed.
+func (v *Validator) ValidateMethodParams(method string, params json.RawMessage) (Decision, error) {
+	if v == nil || v.mode == "off" || v.methods == nil {
+		return Decision{Allowed: true}, nil
+	}
+	schema, ok := v.methods.params[method]
+	if !ok {
+		return Decision{Allowed: true}, nil
+	}
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("{}")
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(params))
+	if err != nil {
+		return Decision{}, err
+	}
+	violations := []string{}
+	for _, desc := range result.Errors() {
+		violations = append(violations, desc.String())
+	}
+	return v.decide(violations), nil
+}
@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func TestValidateMethod(t *testing.T) {
+	v, err := New(&config.Policy{
+		Mode: "enforce",
+		Methods: config.MethodPolicy{
+			Allow: []string{"initialize", "tools/*", "notifications/*"},
+			Deny:  []string{"notifications/message"},
+			Params: map[string]map[string]any{
+				"tools/list": {"type": "object", "properties": map[string]any{"cursor": map[string]any{"type": "string"}}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("validator init: %v", err)
+	}
+	cases := map[string]bool{
+		"initialize":                true,
+		"tools/call":                true,
+		"notifications/initialized": true,
+		"notifications/message":     false,
+		"sampling/createMessage":    false,
+	}
+	for method, want := range cases {
+		if got := v.ValidateMethod(method).Allowed; got != want {
+			t.Errorf("%s: allowed=%v want %v", method, got, want)
+		}
+	}
+
+	if d, err := v.ValidateMethodParams("tools/list", json.RawMessage(`{"cursor":1}`)); err != nil || d.Allowed {
+		t.Fatalf("expected params violation, got %+v err=%v", d, err)
+	}
+	if d, err := v.ValidateMethodParams("tools/list", nil); err != nil || !d.Allowed {
+		t.Fatalf("missing params should validate as {}, got %+v err=%v", d, err)
+	}
+}
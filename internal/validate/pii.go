@@ -0,0 +1,232 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+const (
+	PIIAllow  = "allow"
+	PIIMask   = "mask"
+	PIIReject = "reject"
+	PIIOff    = "off"
+)
+
+// PIIFinding is personal data found in tools/call arguments, located by JSON
+// path in the request (e.g. "$.params.arguments.user.email").
+type PIIFinding struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+func (f PIIFinding) String() string {
+	return f.Type + " at " + f.Path
+}
+
+// piiDetector finds one kind of personal data. check, when set, confirms a
+// regex candidate (e.g. the Luhn checksum of a card number).
+type piiDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	check   func(string) bool
+}
+
+// Detectors run in this order; a span claimed by an earlier detector is not
+// reported again by a later, broader one (e.g. card digits as a phone).
+var piiDetectors = []piiDetector{
+	{name: "email", pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{name: "credit_card", pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), check: luhnValid},
+	{name: "national_id", pattern: regexp.MustCompile(`\b(?:\d{3}-\d{2}-\d{4}|[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D])\b`), check: nationalIDValid},
+	{name: "ip", pattern: regexp.MustCompile(`(?i)\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`), check: func(s string) bool { return net.ParseIP(s) != nil }},
+	// International numbers need a leading +; national ones need
+	// parentheses or -/. separators so plain digit groups do not match.
+	{name: "phone", pattern: regexp.MustCompile(`\+\d{1,3}[ .-]?(?:\(\d{1,4}\)|\d{1,4})(?:[ .-]?\d{2,4}){2,3}\b|(?:\(\d{3}\) ?|\b\d{3}[.-])\d{3}[.-]\d{4}\b`)},
+}
+
+// PIIDetectorNames lists the detectors accepted by pii.detectors.
+func PIIDetectorNames() []string {
+	names := make([]string, 0, len(piiDetectors))
+	for _, d := range piiDetectors {
+		names = append(names, d.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type piiScanner struct {
+	detectors []piiDetector
+	action    string
+	tools     map[string]string
+}
+
+func newPIIScanner(policy config.PIIPolicy) (*piiScanner, error) {
+	action := policy.Action
+	if action == "" {
+		action = PIIOff
+	}
+	enabled := action != PIIOff
+	for _, a := range policy.Tools {
+		if a != PIIOff {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+	p := &piiScanner{action: action, tools: policy.Tools}
+	if len(policy.Detectors) == 0 {
+		p.detectors = piiDetectors
+		return p, nil
+	}
+	want := map[string]struct{}{}
+	for _, name := range policy.Detectors {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, d := range piiDetectors {
+			known = known || d.name == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown pii detector %q (known: %s)", name, strings.Join(PIIDetectorNames(), ", "))
+		}
+		want[name] = struct{}{}
+	}
+	for _, d := range piiDetectors {
+		if _, ok := want[d.name]; ok {
+			p.detectors = append(p.detectors, d)
+		}
+	}
+	return p, nil
+}
+
+func (p *piiScanner) actionFor(tool string) string {
+	if p == nil {
+		return PIIOff
+	}
+	if a, ok := p.tools[tool]; ok {
+		return a
+	}
+	return p.action
+}
+
+// scan returns the findings in args and, when mask is set, the arguments
+// with every finding replaced by "[PII:<type>]". masked is nil when nothing
+// was found.
+func (p *piiScanner) scan(args json.RawMessage, mask bool) ([]PIIFinding, json.RawMessage, error) {
+	if len(args) == 0 {
+		return nil, nil, nil
+	}
+	var v any
+	if err := json.Unmarshal(args, &v); err != nil {
+		return nil, nil, err
+	}
+	var findings []PIIFinding
+	v = p.walk(v, "$.params.arguments", mask, &findings)
+	if len(findings) == 0 {
+		return nil, nil, nil
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Path < findings[j].Path })
+	if !mask {
+		return findings, nil, nil
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return findings, json.RawMessage(out), nil
+}
+
+func (p *piiScanner) walk(v any, path string, mask bool, findings *[]PIIFinding) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, child := range vv {
+			vv[k] = p.walk(child, path+"."+k, mask, findings)
+		}
+	case []any:
+		for i := range vv {
+			vv[i] = p.walk(vv[i], path+"["+strconv.Itoa(i)+"]", mask, findings)
+		}
+	case string:
+		return p.scanString(vv, path, mask, findings)
+	}
+	return v
+}
+
+func (p *piiScanner) scanString(s, path string, mask bool, findings *[]PIIFinding) string {
+	type span struct {
+		start, end int
+		kind       string
+	}
+	var spans []span
+	overlaps := func(start, end int) bool {
+		for _, sp := range spans {
+			if start < sp.end && sp.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range p.detectors {
+		for _, m := range d.pattern.FindAllStringIndex(s, -1) {
+			if overlaps(m[0], m[1]) || (d.check != nil && !d.check(s[m[0]:m[1]])) {
+				continue
+			}
+			spans = append(spans, span{m[0], m[1], d.name})
+			*findings = append(*findings, PIIFinding{Type: d.name, Path: path})
+		}
+	}
+	if !mask || len(spans) == 0 {
+		return s
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(s[last:sp.start])
+		b.WriteString("[PII:" + sp.kind + "]")
+		last = sp.end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// luhnValid reports whether the digits in s form a 13-19 digit number with a
+// valid Luhn checksum.
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// nationalIDValid rejects US SSN area numbers that are never issued.
+func nationalIDValid(s string) bool {
+	if len(s) != 11 || s[3] != '-' {
+		return true
+	}
+	area := s[:3]
+	return area != "000" && area != "666" && area[0] != '9' && s[4:6] != "00" && s[7:] != "0000"
+}
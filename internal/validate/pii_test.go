@@ -0,0 +1,93 @@
+package validate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sarveshkapre/mcp-proxy-gateway/internal/config"
+)
+
+func TestPIIDetectors(t *testing.T) {
+	p, err := newPIIScanner(config.PIIPolicy{Action: PIIAllow})
+	if err != nil {
+		t.Fatalf("new scanner: %v", err)
+	}
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"mail ann.lee+crm@example.co.uk today", []string{"email"}},
+		{"card 4111 1111 1111 1111 exp 12/29", []string{"credit_card"}},
+		{"order 4111 1111 1111 1112", nil}, // fails Luhn
+		{"ssn 123-45-6789", []string{"national_id"}},
+		{"ssn 666-45-6789", nil},
+		{"NI AB 12 34 56 C", []string{"national_id"}},
+		{"from 10.0.12.7 and fe80::1ff:fe23:4567:890a", []string{"ip", "ip"}},
+		{"version 1.2.3.4000", nil},
+		{"call +1 (415) 555-0100", []string{"phone"}},
+		{"call 415-555-0100", []string{"phone"}},
+		{"meeting at 12:30:45", nil},
+	}
+	for _, tc := range cases {
+		args, _ := json.Marshal(map[string]string{"q": tc.text})
+		findings, _, err := p.scan(args, false)
+		if err != nil {
+			t.Fatalf("scan %q: %v", tc.text, err)
+		}
+		var got []string
+		for _, f := range findings {
+			got = append(got, f.Type)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%q: got=%v want=%v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestValidateToolCallPIIActions(t *testing.T) {
+	policy := &config.Policy{
+		Mode: "enforce",
+		PII: config.PIIPolicy{
+			Action: PIIReject,
+			Tools:  map[string]string{"crm.lookup": PIIAllow, "mail.send": PIIMask},
+		},
+	}
+	v, err := New(policy)
+	if err != nil {
+		t.Fatalf("validator init: %v", err)
+	}
+	args := json.RawMessage(`{"to":"ann@example.com","body":"card 4111111111111111","n":3}`)
+
+	decision, err := v.ValidateToolCall("web.search", args)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if decision.Allowed || len(decision.Violations) != 2 || decision.Violations[0] != "pii detected: credit_card at $.params.arguments.body" {
+		t.Fatalf("expected rejection, got %+v", decision)
+	}
+
+	decision, _ = v.ValidateToolCall("crm.lookup", args)
+	if !decision.Allowed || len(decision.PII) != 2 || decision.Arguments != nil {
+		t.Fatalf("allow should report findings without rewriting, got %+v", decision)
+	}
+
+	decision, _ = v.ValidateToolCall("mail.send", args)
+	if !decision.Allowed || decision.Arguments == nil {
+		t.Fatalf("expected masked arguments, got %+v", decision)
+	}
+	var masked map[string]any
+	if err := json.Unmarshal(decision.Arguments, &masked); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if masked["to"] != "[PII:email]" || masked["body"] != "card [PII:credit_card]" || masked["n"] != float64(3) {
+		t.Fatalf("unexpected masked arguments: %v", masked)
+	}
+
+	policy.Mode = "audit"
+	v, _ = New(policy)
+	decision, _ = v.ValidateToolCall("web.search", args)
+	if !decision.Allowed || len(decision.Violations) != 2 {
+		t.Fatalf("audit mode should allow with violations, got %+v", decision)
+	}
+}
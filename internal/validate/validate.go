@@ -15,11 +15,21 @@ type Validator struct {
 	allow       map[string]struct{}
 	deny        map[string]struct{}
 	schemas     map[string]*gojsonschema.Schema
+	outputs     map[string]*gojsonschema.Schema
+	pii         *piiScanner
+	access      *access
+	methods     *methodPolicy
 }
 
 type Decision struct {
 	Allowed    bool
 	Violations []string
+
+	// PII lists personal data found in the arguments.
+	PII []PIIFinding
+	// Arguments replaces the call's arguments when PII was masked; nil means
+	// the original arguments are forwarded.
+	Arguments json.RawMessage
 }
 
 func New(policy *config.Policy) (*Validator, error) {
@@ -29,6 +39,7 @@ func New(policy *config.Policy) (*Validator, error) {
 		allow:       map[string]struct{}{},
 		deny:        map[string]struct{}{},
 		schemas:     map[string]*gojsonschema.Schema{},
+		outputs:     map[string]*gojsonschema.Schema{},
 	}
 	if policy == nil {
 		v.mode = "off"
@@ -42,7 +53,25 @@ func New(policy *config.Policy) (*Validator, error) {
 	for _, name := range policy.DenyTools {
 		v.deny[name] = struct{}{}
 	}
+	pii, err := newPIIScanner(policy.PII)
+	if err != nil {
+		return nil, err
+	}
+	v.pii = pii
+	if v.access, err = newAccess(policy.Resources, policy.Prompts); err != nil {
+		return nil, err
+	}
+	if v.methods, err = newMethodPolicy(policy.Methods); err != nil {
+		return nil, err
+	}
 	for name, entry := range policy.Tools {
+		if entry.OutputSchema != nil {
+			schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(entry.OutputSchema))
+			if err != nil {
+				return nil, fmt.Errorf("output schema for %s: %w", name, err)
+			}
+			v.outputs[name] = schema
+		}
 		if entry.Schema == nil {
 			continue
 		}
@@ -52,9 +81,29 @@ func New(policy *config.Policy) (*Validator, error) {
 		}
 		v.schemas[name] = schema
 	}
+	// Local tools are validated against their input_schema unless tools has
+	// an entry for them.
+	for name, local := range policy.LocalTools {
+		if _, ok := v.schemas[name]; ok || local.InputSchema == nil {
+			continue
+		}
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(local.InputSchema))
+		if err != nil {
+			return nil, fmt.Errorf("input schema for local tool %s: %w", name, err)
+		}
+		v.schemas[name] = schema
+	}
 	return v, nil
 }
 
+// Mode returns the validation mode (enforce, audit, or off).
+func (v *Validator) Mode() string {
+	if v == nil {
+		return "off"
+	}
+	return v.mode
+}
+
 func (v *Validator) ValidateToolCall(tool string, args json.RawMessage) (Decision, error) {
 	if v.mode == "off" {
 		return Decision{Allowed: true}, nil
@@ -75,29 +124,126 @@ func (v *Validator) ValidateToolCall(tool string, args json.RawMessage) (Decisio
 		}
 	}
 
-	if schema, ok := v.schemas[tool]; ok {
-		if len(args) == 0 {
-			violations = append(violations, "arguments are required")
-		} else {
-			result, err := schema.Validate(gojsonschema.NewBytesLoader(args))
-			if err != nil {
-				return Decision{}, err
-			}
-			if !result.Valid() {
-				for _, desc := range result.Errors() {
-					violations = append(violations, desc.String())
-				}
+	schemaViolations, err := v.schemaViolations(tool, args)
+	if err != nil {
+		return Decision{}, err
+	}
+	violations = append(violations, schemaViolations...)
+
+	var findings []PIIFinding
+	var masked json.RawMessage
+	if action := v.pii.actionFor(tool); action != PIIOff {
+		var err error
+		findings, masked, err = v.pii.scan(args, action == PIIMask)
+		if err != nil {
+			return Decision{}, err
+		}
+		if action == PIIReject {
+			for _, f := range findings {
+				violations = append(violations, "pii detected: "+f.String())
 			}
 		}
 	}
 
-	if len(violations) == 0 {
+	decision := v.decide(violations)
+	decision.PII = findings
+	if decision.Allowed {
+		decision.Arguments = masked
+	}
+	return decision, nil
+}
+
+// ValidateTarget checks the arguments of a call rewritten by an alias
+// against the schema of the tool it resolves to (a tools entry or a local
+// tool's input_schema). Allow and deny lists and PII policies apply to the
+// client-visible name in ValidateToolCall and are not checked again.
+func (v *Validator) ValidateTarget(tool string, args json.RawMessage) (Decision, error) {
+	if v.mode == "off" {
 		return Decision{Allowed: true}, nil
 	}
+	violations, err := v.schemaViolations(tool, args)
+	if err != nil {
+		return Decision{}, err
+	}
+	return v.decide(violations), nil
+}
+
+// schemaViolations validates args against the input schema of tool, if any.
+func (v *Validator) schemaViolations(tool string, args json.RawMessage) ([]string, error) {
+	schema, ok := v.schemas[tool]
+	if !ok {
+		return nil, nil
+	}
+	if len(args) == 0 {
+		return []string{"arguments are required"}, nil
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(args))
+	if err != nil {
+		return nil, err
+	}
+	var violations []string
+	for _, desc := range result.Errors() {
+		violations = append(violations, desc.String())
+	}
+	return violations, nil
+}
 
+// HasOutputSchema reports whether results of tool are validated.
+func (v *Validator) HasOutputSchema(tool string) bool {
+	if v == nil || v.mode == "off" {
+		return false
+	}
+	_, ok := v.outputs[tool]
+	return ok
+}
+
+// ValidateToolResult checks the structuredContent of a tools/call result
+// against the tool's output schema. Tools without an output schema and
+// results flagged isError are always allowed.
+func (v *Validator) ValidateToolResult(tool string, result json.RawMessage) (Decision, error) {
+	if !v.HasOutputSchema(tool) {
+		return Decision{Allowed: true}, nil
+	}
+	payload := struct {
+		IsError           bool            `json:"isError"`
+		StructuredContent json.RawMessage `json:"structuredContent"`
+	}{}
+	if err := json.Unmarshal(result, &payload); err != nil {
+		return v.decide([]string{"result must be an object"}), nil
+	}
+	if payload.IsError {
+		return Decision{Allowed: true}, nil
+	}
+
+	violations := []string{}
+	if len(payload.StructuredContent) == 0 || string(payload.StructuredContent) == "null" {
+		violations = append(violations, "structuredContent is required")
+	} else {
+		res, err := v.outputs[tool].Validate(gojsonschema.NewBytesLoader(payload.StructuredContent))
+		if err != nil {
+			return Decision{}, err
+		}
+		for _, desc := range res.Errors() {
+			violations = append(violations, desc.String())
+		}
+	}
+	return v.decide(violations), nil
+}
+
+func (v *Validator) decide(violations []string) Decision {
+	if len(violations) == 0 {
+		return Decision{Allowed: true}
+	}
 	if v.mode == "audit" {
-		return Decision{Allowed: true, Violations: violations}, nil
+		return Decision{Allowed: true, Violations: violations}
 	}
+	return Decision{Allowed: false, Violations: violations}
+}
 
-	return Decision{Allowed: false, Violations: violations}, nil
+// PIIAction returns the PII action (allow, mask, reject, or off) for tool.
+func (v *Validator) PIIAction(tool string) string {
+	if v == nil || v.mode == "off" {
+		return PIIOff
+	}
+	return v.pii.actionFor(tool)
 }
@@ -102,3 +102,53 @@ func TestValidatorAllowList(t *testing.T) {
 		t.Fatalf("expected rejection for tool not in allowlist")
 	}
 }
+
+func TestValidateToolResult(t *testing.T) {
+	policy := &config.Policy{
+		Mode: "enforce",
+		Tools: map[string]config.ToolEntry{
+			"weather.get": {
+				OutputSchema: map[string]any{
+					"type":     "object",
+					"required": []any{"temp"},
+					"properties": map[string]any{
+						"temp": map[string]any{"type": "number"},
+					},
+				},
+			},
+		},
+	}
+	v, err := New(policy)
+	if err != nil {
+		t.Fatalf("validator init: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		tool    string
+		result  string
+		allowed bool
+	}{
+		{"valid", "weather.get", `{"structuredContent":{"temp":21.5}}`, true},
+		{"wrong type", "weather.get", `{"structuredContent":{"temp":"warm"}}`, false},
+		{"missing structured content", "weather.get", `{"content":[{"type":"text","text":"21"}]}`, false},
+		{"tool error", "weather.get", `{"isError":true,"content":[{"type":"text","text":"down"}]}`, true},
+		{"no output schema", "web.search", `{"anything":1}`, true},
+	}
+	for _, tc := range cases {
+		decision, err := v.ValidateToolResult(tc.tool, json.RawMessage(tc.result))
+		if err != nil {
+			t.Fatalf("%s: validate: %v", tc.name, err)
+		}
+		if decision.Allowed != tc.allowed {
+			t.Fatalf("%s: allowed=%v want=%v violations=%v", tc.name, decision.Allowed, tc.allowed, decision.Violations)
+		}
+	}
+
+	policy.Mode = "audit"
+	v, _ = New(policy)
+	decision, _ := v.ValidateToolResult("weather.get", json.RawMessage(`{"structuredContent":{}}`))
+	if !decision.Allowed || len(decision.Violations) == 0 {
+		t.Fatalf("audit mode should allow with violations, got %+v", decision)
+	}
+}